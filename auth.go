@@ -0,0 +1,73 @@
+package windmill
+
+import (
+	"github.com/scmofeoluwa/windmill/internal/api"
+)
+
+// Authenticator identifies the caller of each dashboard and API request.
+// Implementations return ErrUnauthenticated when a request carries no valid
+// credentials.
+type Authenticator = api.Authenticator
+
+// Principal is the authenticated caller of a request.
+type Principal = api.Principal
+
+// User is a set of Basic Auth credentials with a role and an optional list
+// of stream patterns (e.g. "payments.*") the user is restricted to.
+type User = api.User
+
+// Token is a static bearer token with a role and optional stream patterns.
+type Token = api.Token
+
+// ProxyAuthConfig configures authentication from trusted reverse-proxy
+// headers.
+type ProxyAuthConfig = api.ProxyAuthConfig
+
+// OIDCConfig configures dashboard login through an OpenID Connect provider.
+type OIDCConfig = api.OIDCConfig
+
+// Role controls which operations a Principal may perform.
+type Role = api.Role
+
+const (
+	// RoleViewer can browse streams and the DLQ but cannot change anything.
+	RoleViewer = api.RoleViewer
+	// RoleOperator can additionally requeue and delete individual messages.
+	RoleOperator = api.RoleOperator
+	// RoleAdmin can additionally run bulk operations such as requeue-all.
+	RoleAdmin = api.RoleAdmin
+)
+
+var ErrUnauthenticated = api.ErrUnauthenticated
+
+// BasicAuth authenticates users with HTTP Basic Auth. Passwords may be
+// given in plain text or as bcrypt hashes.
+func BasicAuth(users ...User) Authenticator {
+	return api.NewBasicAuthenticator(users)
+}
+
+// TokenAuth authenticates requests carrying one of the given static
+// tokens in an "Authorization: Bearer" header.
+func TokenAuth(tokens ...Token) Authenticator {
+	return api.NewTokenAuthenticator(tokens)
+}
+
+// ProxyAuth trusts the identity headers set by a reverse proxy, for
+// requests originating from one of the configured trusted proxies.
+func ProxyAuth(config ProxyAuthConfig) Authenticator {
+	return api.NewProxyAuthenticator(config)
+}
+
+// OIDCAuth logs users in with an OpenID Connect provider using the
+// authorization code flow with PKCE. Roles are derived from the groups
+// claim of the ID token, and sessions are kept in a signed cookie. The
+// login, callback and logout routes are served under /auth.
+func OIDCAuth(config OIDCConfig) Authenticator {
+	return api.NewOIDCAuthenticator(config)
+}
+
+// NoAuth disables authentication and treats every caller as an admin.
+// Use it only when the host router already authenticates requests.
+func NoAuth() Authenticator {
+	return api.NoAuthenticator{}
+}
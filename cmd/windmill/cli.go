@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/scmofeoluwa/windmill/internal/monitor"
+)
+
+// cli holds the state shared by the client subcommands: config flags,
+// output format and where input is read from and results are written.
+type cli struct {
+	fs     *flag.FlagSet
+	config *configFlags
+	format *string
+	stdin  io.Reader
+	stdout io.Writer
+	getenv func(string) string
+
+	redis redis.UniversalClient
+}
+
+func newCLI(name string, stdout io.Writer, getenv func(string) string) *cli {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	return &cli{
+		fs:     fs,
+		config: registerConfigFlags(fs),
+		format: fs.String("o", "table", "output format: table, json or ndjson"),
+		stdin:  os.Stdin,
+		stdout: stdout,
+		getenv: getenv,
+	}
+}
+
+// parse parses flags interleaved with positional arguments, so that
+// "messages get orders 1-0 -o json" works like flags given first.
+func (c *cli) parse(args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := c.fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		args = c.fs.Args()
+		if len(args) == 0 {
+			break
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	switch *c.format {
+	case "table", "json", "ndjson":
+	default:
+		return nil, fmt.Errorf("unknown output format %q", *c.format)
+	}
+
+	return positional, nil
+}
+
+// monitor connects to Redis and returns a monitor for the configured DLQ.
+func (c *cli) monitor(ctx context.Context) (*monitor.Monitor, error) {
+	cfg, err := c.config.resolve(c.getenv)
+	if err != nil {
+		return nil, err
+	}
+
+	inst, err := cfg.instance()
+	if err != nil {
+		return nil, err
+	}
+
+	c.redis, err = newRedisClient(inst.RedisURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.redis.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("could not connect to redis: %w", err)
+	}
+
+	mon := monitor.NewWithOptions(c.redis, inst.DLQ, monitor.Options{
+		Catalog: monitor.CatalogOpts{Include: inst.Streams, Exclude: inst.ExcludeStreams},
+	})
+
+	// Fall back to other commands where the server denies some.
+	if _, err := mon.ProbeCapabilities(ctx); err != nil {
+		return nil, fmt.Errorf("could not probe redis capabilities: %w", err)
+	}
+
+	return mon, nil
+}
+
+// dlqMonitor is like monitor but requires a DLQ name.
+func (c *cli) dlqMonitor(ctx context.Context) (*monitor.Monitor, error) {
+	cfg, err := c.config.resolve(c.getenv)
+	if err != nil {
+		return nil, err
+	}
+
+	inst, err := cfg.instance()
+	if err != nil {
+		return nil, err
+	}
+
+	if inst.DLQ == "" {
+		return nil, errors.New("dlq is required")
+	}
+
+	return c.monitor(ctx)
+}
+
+func (c *cli) close() {
+	if c.redis != nil {
+		_ = c.redis.Close()
+	}
+}
+
+type paginationFlags struct {
+	cursor *string
+	limit  *int64
+	order  *string
+}
+
+func (c *cli) paginationFlags() *paginationFlags {
+	return &paginationFlags{
+		cursor: c.fs.String("cursor", "", "return messages after this ID"),
+		limit:  c.fs.Int64("limit", 50, "maximum number of messages (at most 100)"),
+		order:  c.fs.String("order", "desc", "sort order: asc or desc"),
+	}
+}
+
+// opts mirrors the HTTP API's pagination parsing.
+func (p *paginationFlags) opts() (monitor.PaginationOpts, error) {
+	const MaxLimit = 100
+
+	order, err := monitor.ParseSortOrder(*p.order)
+	if err != nil {
+		return monitor.PaginationOpts{}, fmt.Errorf("invalid order")
+	}
+
+	limit := *p.limit
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	return monitor.PaginationOpts{
+		Cursor: *p.cursor,
+		Limit:  limit,
+		Order:  order,
+	}.WithDefaults(), nil
+}
+
+// table describes how to render values of type T as table rows.
+type table[T any] struct {
+	header []string
+	row    func(T) []string
+}
+
+// printList writes items in the selected format. In table mode footer, if
+// non-empty, is printed after the rows.
+func printList[T any](c *cli, items []T, t table[T], footer string) error {
+	switch *c.format {
+	case "json":
+		return writeJSON(c.stdout, items)
+	case "ndjson":
+		enc := json.NewEncoder(c.stdout)
+		for _, item := range items {
+			if err := enc.Encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		tw := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+		for _, item := range items {
+			fmt.Fprintln(tw, strings.Join(t.row(item), "\t"))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		if footer != "" {
+			fmt.Fprintln(c.stdout, footer)
+		}
+		return nil
+	}
+}
+
+// printValue writes a single value, as key/value rows in table mode.
+func printValue(c *cli, v any, rows [][2]string) error {
+	if *c.format != "table" {
+		enc := json.NewEncoder(c.stdout)
+		if *c.format == "json" {
+			enc.SetIndent("", "  ")
+		}
+		return enc.Encode(v)
+	}
+
+	tw := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	for _, row := range rows {
+		fmt.Fprintf(tw, "%s:\t%s\n", row[0], row[1])
+	}
+	return tw.Flush()
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func compactJSON(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "<invalid>"
+	}
+	return string(b)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-3] + "..."
+}
+
+func optional(s *string) string {
+	if s == nil {
+		return "-"
+	}
+	return *s
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"github.com/vmihailenco/msgpack"
+
+	"github.com/scmofeoluwa/windmill/internal/monitor"
+)
+
+type CLITestSuite struct {
+	suite.Suite
+	mr     *miniredis.Miniredis
+	client redis.UniversalClient
+	env    map[string]string
+}
+
+func (s *CLITestSuite) SetupTest() {
+	s.mr = miniredis.RunT(s.T())
+	s.client = redis.NewClient(&redis.Options{Addr: s.mr.Addr()})
+	s.env = map[string]string{
+		"WINDMILL_REDIS_URL": "redis://" + s.mr.Addr(),
+		"WINDMILL_DLQ":       "test_dlq",
+	}
+}
+
+func (s *CLITestSuite) TearDownTest() {
+	s.client.Close()
+	s.mr.Close()
+}
+
+func (s *CLITestSuite) run(fn func(context.Context, []string, *bytes.Buffer, func(string) string) error, args ...string) string {
+	var out bytes.Buffer
+	err := fn(context.Background(), args, &out, func(k string) string { return s.env[k] })
+	s.Require().NoError(err)
+	return out.String()
+}
+
+func (s *CLITestSuite) add(stream string, metadata map[string]string, payload map[string]any) string {
+	payloadBytes, err := json.Marshal(payload)
+	s.Require().NoError(err)
+
+	metadataBytes, err := msgpack.Marshal(metadata)
+	s.Require().NoError(err)
+
+	id, err := s.client.XAdd(context.Background(), &redis.XAddArgs{
+		Stream: stream,
+		Values: map[string]any{
+			monitor.WatermillUUIDKey:     "test-uuid",
+			monitor.WatermillPayloadKey:  string(payloadBytes),
+			monitor.WatermillMetadataKey: string(metadataBytes),
+		},
+	}).Result()
+	s.Require().NoError(err)
+	return id
+}
+
+func (s *CLITestSuite) addDLQ(topic string, payload map[string]any) string {
+	return s.add("test_dlq", map[string]string{
+		monitor.TopicPoisonedKey:  topic,
+		monitor.ReasonPoisonedKey: "boom",
+	}, payload)
+}
+
+func streamsCmd(ctx context.Context, args []string, out *bytes.Buffer, getenv func(string) string) error {
+	return runStreams(ctx, args, out, getenv)
+}
+
+func messagesCmd(ctx context.Context, args []string, out *bytes.Buffer, getenv func(string) string) error {
+	return runMessages(ctx, args, out, getenv)
+}
+
+func dlqCmd(ctx context.Context, args []string, out *bytes.Buffer, getenv func(string) string) error {
+	return runDLQ(ctx, args, out, getenv)
+}
+
+func (s *CLITestSuite) TestStreamsList() {
+	s.add("orders.created", nil, map[string]any{"id": 1})
+	s.addDLQ("orders.created", map[string]any{"id": 2})
+
+	var streams []monitor.StreamInfo
+	s.Require().NoError(json.Unmarshal([]byte(s.run(streamsCmd, "ls", "-o", "json")), &streams))
+	s.Require().Len(streams, 1)
+	s.Equal("orders.created", streams[0].Name)
+
+	table := s.run(streamsCmd, "ls")
+	s.Contains(table, "NAME")
+	s.Contains(table, "orders.created")
+
+	s.add("payments.processed", nil, map[string]any{"id": 3})
+	s.add("payments.processed", nil, map[string]any{"id": 4})
+
+	table = s.run(streamsCmd, "ls", "-sort", "length", "-order", "desc", "-limit", "1")
+	s.Contains(table, "payments.processed")
+	s.NotContains(table, "orders.created")
+	s.Contains(table, "1 of 2 streams, next page: -cursor 1")
+
+	s.NotContains(s.run(streamsCmd, "ls", "-q", "payments"), "orders.created")
+}
+
+func (s *CLITestSuite) TestStreamsTrim() {
+	for i := range 5 {
+		s.add("orders.created", nil, map[string]any{"id": i})
+	}
+
+	s.Contains(s.run(streamsCmd, "trim", "orders.created", "-maxlen", "2"), "would remove 3 entries")
+	s.Contains(s.run(streamsCmd, "trim", "orders.created", "-maxlen", "2", "-yes"), "removed 3 entries")
+
+	length, err := s.client.XLen(context.Background(), "orders.created").Result()
+	s.Require().NoError(err)
+	s.Equal(int64(2), length)
+}
+
+func (s *CLITestSuite) TestMessages() {
+	s.add("orders.created", nil, map[string]any{"id": 1})
+	id := s.add("orders.created", nil, map[string]any{"id": 2})
+
+	out := s.run(messagesCmd, "ls", "orders.created", "-o", "ndjson", "-limit", "1")
+	s.Len(strings.Split(strings.TrimSpace(out), "\n"), 1)
+	s.Contains(out, id)
+
+	s.Contains(s.run(messagesCmd, "get", "orders.created", id), `{"id":2}`)
+
+	s.run(messagesCmd, "rm", "orders.created", id)
+	length, err := s.client.XLen(context.Background(), "orders.created").Result()
+	s.Require().NoError(err)
+	s.Equal(int64(1), length)
+}
+
+func (s *CLITestSuite) TestDLQRequeueAndExport() {
+	id := s.addDLQ("orders.created", map[string]any{"id": 1})
+	s.addDLQ("payments.processed", map[string]any{"id": 2})
+
+	out := s.run(dlqCmd, "export")
+	s.Len(strings.Split(strings.TrimSpace(out), "\n"), 2)
+
+	s.Contains(s.run(dlqCmd, "requeue", id), "requeued "+id)
+	s.Contains(s.run(dlqCmd, "purge", "-yes"), "deleted 1 messages")
+
+	length, err := s.client.XLen(context.Background(), "test_dlq").Result()
+	s.Require().NoError(err)
+	s.Equal(int64(0), length)
+}
+
+func (s *CLITestSuite) TestMessagesReplay() {
+	s.addDLQ("orders.created", map[string]any{"id": 1})
+
+	file := filepath.Join(s.T().TempDir(), "dlq.ndjson")
+	s.run(dlqCmd, "export", "-file", file)
+
+	s.Contains(s.run(messagesCmd, "replay", file, "-dry-run"), "Replayed:  1")
+	s.False(s.mr.Exists("orders.created"))
+
+	var result monitor.ReplayResult
+	s.Require().NoError(json.Unmarshal([]byte(s.run(messagesCmd, "replay", file, "-o", "json")), &result))
+	s.Equal(1, result.Replayed)
+
+	length, err := s.client.XLen(context.Background(), "orders.created").Result()
+	s.Require().NoError(err)
+	s.Equal(int64(1), length)
+}
+
+func (s *CLITestSuite) TestDLQPurgeFiltered() {
+	s.addDLQ("orders.created", map[string]any{"id": 1})
+	s.addDLQ("payments.processed", map[string]any{"id": 2})
+
+	s.Contains(s.run(dlqCmd, "purge", "-topic", "orders.created", "-dry-run"), "would delete 1 messages")
+
+	file := filepath.Join(s.T().TempDir(), "purged.ndjson")
+	out := s.run(dlqCmd, "purge", "-topic", "orders.created", "-confirm", "test_dlq", "-export", file)
+	s.Contains(out, "deleted 1 messages")
+
+	exported, err := os.ReadFile(file)
+	s.Require().NoError(err)
+	s.Contains(string(exported), "orders.created")
+
+	err = runDLQ(context.Background(), []string{"purge", "-confirm", "other"}, &bytes.Buffer{}, func(k string) string { return s.env[k] })
+	s.ErrorIs(err, monitor.ErrPurgeNotConfirmed)
+
+	length, err := s.client.XLen(context.Background(), "test_dlq").Result()
+	s.Require().NoError(err)
+	s.Equal(int64(1), length)
+}
+
+func (s *CLITestSuite) TestDLQScheduledRequeue() {
+	id := s.addDLQ("orders.created", map[string]any{"id": 1})
+
+	s.Contains(s.run(dlqCmd, "requeue", "-delay", "1h", id), "scheduled "+id)
+	out := s.run(dlqCmd, "scheduled")
+	s.Contains(out, id)
+	s.Contains(out, "orders.created")
+
+	s.Contains(s.run(dlqCmd, "requeue", "-cancel", id), "cancelled "+id)
+	s.NotContains(s.run(dlqCmd, "scheduled"), id)
+
+	err := runDLQ(context.Background(), []string{"requeue", "-cancel", id}, &bytes.Buffer{}, func(k string) string { return s.env[k] })
+	s.Error(err)
+
+	err = runDLQ(context.Background(), []string{"requeue", "-all", "-delay", "1h"}, &bytes.Buffer{}, func(k string) string { return s.env[k] })
+	s.Error(err)
+}
+
+func (s *CLITestSuite) TestDLQPurgeRequiresConfirmation() {
+	err := runDLQ(context.Background(), []string{"purge"}, &bytes.Buffer{}, func(k string) string { return s.env[k] })
+	s.Error(err)
+}
+
+func TestCLISuite(t *testing.T) {
+	suite.Run(t, new(CLITestSuite))
+}
+
+func TestPaginationFlags(t *testing.T) {
+	c := newCLI("test", &bytes.Buffer{}, func(string) string { return "" })
+	p := c.paginationFlags()
+
+	_, err := c.parse([]string{"-limit", "500", "-order", "asc"})
+	require.NoError(t, err)
+
+	opts, err := p.opts()
+	require.NoError(t, err)
+	require.Equal(t, int64(100), opts.Limit)
+	require.Equal(t, monitor.SortOrderAsc, opts.Order)
+}
+
+func TestParseBefore(t *testing.T) {
+	now := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	got, err := parseBefore("24h", now)
+	require.NoError(t, err)
+	require.Equal(t, now.Add(-24*time.Hour), got)
+
+	got, err = parseBefore("2024-01-01T00:00:00Z", now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), got)
+
+	_, err = parseBefore("yesterday", now)
+	require.Error(t, err)
+}
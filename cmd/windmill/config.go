@@ -0,0 +1,395 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/scmofeoluwa/windmill"
+)
+
+// Config holds the server settings. Values are read from a YAML file,
+// then overridden by WINDMILL_* environment variables, then by flags.
+type Config struct {
+	RedisURL       string     `yaml:"redis_url"`
+	DLQ            string     `yaml:"dlq"`
+	Listen         string     `yaml:"listen"`
+	TLSCert        string     `yaml:"tls_cert"`
+	TLSKey         string     `yaml:"tls_key"`
+	BasePath       string     `yaml:"base_path"`
+	ReadOnly       bool       `yaml:"read_only"`
+	AllowedOrigins []string   `yaml:"allowed_origins"`
+	FrameAncestors []string   `yaml:"frame_ancestors"`
+	Auth           AuthConfig `yaml:"auth"`
+
+	Streams               []string      `yaml:"streams"`
+	ExcludeStreams        []string      `yaml:"exclude_streams"`
+	StreamRefreshInterval time.Duration `yaml:"stream_refresh_interval"`
+
+	Retention         []windmill.RetentionPolicy `yaml:"retention"`
+	RetentionInterval time.Duration              `yaml:"retention_interval"`
+
+	Retry         []windmill.RetryPolicy `yaml:"retry"`
+	RetryInterval time.Duration          `yaml:"retry_interval"`
+
+	ScheduleInterval time.Duration `yaml:"schedule_interval"`
+
+	InstanceID  string        `yaml:"instance_id"`
+	LeaderLease time.Duration `yaml:"leader_lease"`
+
+	// Instances serves several Redis deployments from one dashboard. Each
+	// has its own redis_url, dlq, streams, exclude_streams, retention and
+	// retry, replacing the top-level ones.
+	Instances []InstanceConfig `yaml:"instances"`
+
+	// Instance names the instance the client commands act on, the first
+	// one by default.
+	Instance string `yaml:"instance"`
+}
+
+type InstanceConfig struct {
+	Name           string                     `yaml:"name"`
+	RedisURL       string                     `yaml:"redis_url"`
+	DLQ            string                     `yaml:"dlq"`
+	Streams        []string                   `yaml:"streams"`
+	ExcludeStreams []string                   `yaml:"exclude_streams"`
+	Retention      []windmill.RetentionPolicy `yaml:"retention"`
+	Retry          []windmill.RetryPolicy     `yaml:"retry"`
+}
+
+type AuthConfig struct {
+	// Mode is one of basic, token, proxy, oidc or none.
+	Mode   string        `yaml:"mode"`
+	Users  []UserConfig  `yaml:"users"`
+	Tokens []TokenConfig `yaml:"tokens"`
+	Proxy  ProxyConfig   `yaml:"proxy"`
+	OIDC   OIDCConfig    `yaml:"oidc"`
+}
+
+type UserConfig struct {
+	Username     string        `yaml:"username"`
+	Password     string        `yaml:"password"`
+	PasswordHash string        `yaml:"password_hash"`
+	Role         windmill.Role `yaml:"role"`
+	Streams      []string      `yaml:"streams"`
+}
+
+type TokenConfig struct {
+	Name    string        `yaml:"name"`
+	Token   string        `yaml:"token"`
+	Role    windmill.Role `yaml:"role"`
+	Streams []string      `yaml:"streams"`
+}
+
+type ProxyConfig struct {
+	UserHeader     string        `yaml:"user_header"`
+	RoleHeader     string        `yaml:"role_header"`
+	DefaultRole    windmill.Role `yaml:"default_role"`
+	TrustedProxies []string      `yaml:"trusted_proxies"`
+}
+
+type OIDCConfig struct {
+	IssuerURL    string                   `yaml:"issuer_url"`
+	ClientID     string                   `yaml:"client_id"`
+	ClientSecret string                   `yaml:"client_secret"`
+	RedirectURL  string                   `yaml:"redirect_url"`
+	Scopes       []string                 `yaml:"scopes"`
+	GroupsClaim  string                   `yaml:"groups_claim"`
+	GroupRoles   map[string]windmill.Role `yaml:"group_roles"`
+	DefaultRole  windmill.Role            `yaml:"default_role"`
+	SessionKey   string                   `yaml:"session_key"`
+	SessionTTL   time.Duration            `yaml:"session_ttl"`
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		RedisURL: "redis://localhost:6379",
+		Listen:   ":3000",
+		Auth:     AuthConfig{Mode: "basic"},
+	}
+}
+
+// setting is a Config field settable from a flag and its matching
+// environment variable.
+type setting struct {
+	name   string
+	usage  string
+	isBool bool
+	apply  func(c *Config, value string) error
+}
+
+var settings = []setting{
+	{name: "redis-url", usage: "Redis URL: redis://, rediss://, redis+sentinel:// or redis+cluster://", apply: func(c *Config, v string) error { c.RedisURL = v; return nil }},
+	{name: "dlq", usage: "name of the dead letter queue stream", apply: func(c *Config, v string) error { c.DLQ = v; return nil }},
+	{name: "instance", usage: "name of the configured instance client commands act on", apply: func(c *Config, v string) error { c.Instance = v; return nil }},
+	{name: "listen", usage: "address to listen on", apply: func(c *Config, v string) error { c.Listen = v; return nil }},
+	{name: "tls-cert", usage: "TLS certificate file", apply: func(c *Config, v string) error { c.TLSCert = v; return nil }},
+	{name: "tls-key", usage: "TLS private key file", apply: func(c *Config, v string) error { c.TLSKey = v; return nil }},
+	{name: "base-path", usage: "URL prefix the dashboard is served under", apply: func(c *Config, v string) error { c.BasePath = v; return nil }},
+	{name: "read-only", usage: "forbid deleting and requeueing messages", isBool: true, apply: func(c *Config, v string) (err error) {
+		c.ReadOnly, err = strconv.ParseBool(v)
+		return err
+	}},
+	{name: "allowed-origins", usage: "comma-separated extra origins allowed to send mutating requests", apply: func(c *Config, v string) error { c.AllowedOrigins = splitList(v); return nil }},
+	{name: "frame-ancestors", usage: "comma-separated origins allowed to embed the dashboard", apply: func(c *Config, v string) error { c.FrameAncestors = splitList(v); return nil }},
+	{name: "auth", usage: "authentication mode: basic, token, proxy, oidc or none", apply: func(c *Config, v string) error { c.Auth.Mode = v; return nil }},
+	{name: "auth-token", usage: "static admin bearer token for token auth", apply: func(c *Config, v string) error {
+		c.Auth.Tokens = append(c.Auth.Tokens, TokenConfig{Name: "default", Token: v, Role: windmill.RoleAdmin})
+		return nil
+	}},
+	{name: "proxy-user-header", usage: "header carrying the username for proxy auth", apply: func(c *Config, v string) error { c.Auth.Proxy.UserHeader = v; return nil }},
+	{name: "proxy-role-header", usage: "header carrying the role for proxy auth", apply: func(c *Config, v string) error { c.Auth.Proxy.RoleHeader = v; return nil }},
+	{name: "proxy-default-role", usage: "role given to proxy-authenticated users", apply: func(c *Config, v string) error { return c.Auth.Proxy.DefaultRole.UnmarshalText([]byte(v)) }},
+	{name: "trusted-proxies", usage: "comma-separated CIDRs of trusted reverse proxies", apply: func(c *Config, v string) error { c.Auth.Proxy.TrustedProxies = splitList(v); return nil }},
+	{name: "oidc-issuer", usage: "OIDC issuer URL", apply: func(c *Config, v string) error { c.Auth.OIDC.IssuerURL = v; return nil }},
+	{name: "oidc-client-id", usage: "OIDC client ID", apply: func(c *Config, v string) error { c.Auth.OIDC.ClientID = v; return nil }},
+	{name: "oidc-client-secret", usage: "OIDC client secret", apply: func(c *Config, v string) error { c.Auth.OIDC.ClientSecret = v; return nil }},
+	{name: "oidc-redirect-url", usage: "OIDC callback URL, ending in /auth/callback", apply: func(c *Config, v string) error { c.Auth.OIDC.RedirectURL = v; return nil }},
+	{name: "oidc-session-key", usage: "key signing session cookies, at least 32 bytes", apply: func(c *Config, v string) error { c.Auth.OIDC.SessionKey = v; return nil }},
+	{name: "oidc-group-roles", usage: "comma-separated group=role mappings", apply: func(c *Config, v string) error {
+		roles, err := parseGroupRoles(v)
+		c.Auth.OIDC.GroupRoles = roles
+		return err
+	}},
+	{name: "oidc-default-role", usage: "role given to users in no mapped group", apply: func(c *Config, v string) error { return c.Auth.OIDC.DefaultRole.UnmarshalText([]byte(v)) }},
+}
+
+// settingValue records a flag given on the command line so it can be
+// applied after the config file and environment.
+type settingValue struct {
+	isBool bool
+	value  string
+}
+
+func (v *settingValue) String() string     { return v.value }
+func (v *settingValue) Set(s string) error { v.value = s; return nil }
+func (v *settingValue) IsBoolFlag() bool   { return v.isBool }
+
+func envName(name string) string {
+	return "WINDMILL_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// configFlags holds the config flags registered on a FlagSet until they
+// are resolved.
+type configFlags struct {
+	fs     *flag.FlagSet
+	path   *string
+	values map[string]*settingValue
+}
+
+func registerConfigFlags(fs *flag.FlagSet) *configFlags {
+	cf := &configFlags{
+		fs:     fs,
+		path:   fs.String("config", "", "path to a YAML config file (env WINDMILL_CONFIG)"),
+		values: make(map[string]*settingValue, len(settings)),
+	}
+
+	for _, s := range settings {
+		v := &settingValue{isBool: s.isBool}
+		cf.values[s.name] = v
+		fs.Var(v, s.name, fmt.Sprintf("%s (env %s)", s.usage, envName(s.name)))
+	}
+
+	return cf
+}
+
+// resolve builds the configuration from, in order of increasing
+// precedence, defaults, the config file, environment variables and the
+// flags given on the command line. It must be called after parsing.
+func (cf *configFlags) resolve(getenv func(string) string) (*Config, error) {
+	cfg := defaultConfig()
+
+	path := *cf.path
+	if path == "" {
+		path = getenv("WINDMILL_CONFIG")
+	}
+
+	if path != "" {
+		if err := readConfigFile(path, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range settings {
+		if v := getenv(envName(s.name)); v != "" {
+			if err := s.apply(cfg, v); err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", envName(s.name), err)
+			}
+		}
+	}
+
+	var flagErr error
+	cf.fs.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.name == f.Name && flagErr == nil {
+				if err := s.apply(cfg, cf.values[s.name].value); err != nil {
+					flagErr = fmt.Errorf("invalid -%s: %w", s.name, err)
+				}
+			}
+		}
+	})
+	if flagErr != nil {
+		return nil, flagErr
+	}
+
+	return cfg, nil
+}
+
+// loadConfig parses args and resolves the configuration.
+func loadConfig(fs *flag.FlagSet, args []string, getenv func(string) string) (*Config, error) {
+	cf := registerConfigFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	return cf.resolve(getenv)
+}
+
+func readConfigFile(path string, cfg *Config) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil {
+		return fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Config) validate() error {
+	if len(c.Instances) == 0 && c.DLQ == "" {
+		return errors.New("dlq is required")
+	}
+
+	for _, inst := range c.Instances {
+		switch {
+		case inst.Name == "":
+			return errors.New("instance name is required")
+		case inst.RedisURL == "":
+			return fmt.Errorf("instance %q: redis_url is required", inst.Name)
+		case inst.DLQ == "":
+			return fmt.Errorf("instance %q: dlq is required", inst.Name)
+		}
+	}
+
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		return errors.New("tls-cert and tls-key must be set together")
+	}
+
+	return nil
+}
+
+// instances returns the configured instances, or the single one formed by
+// the top-level settings when there are none.
+func (c *Config) instances() []InstanceConfig {
+	if len(c.Instances) > 0 {
+		return c.Instances
+	}
+
+	return []InstanceConfig{{
+		Name:           "default",
+		RedisURL:       c.RedisURL,
+		DLQ:            c.DLQ,
+		Streams:        c.Streams,
+		ExcludeStreams: c.ExcludeStreams,
+		Retention:      c.Retention,
+		Retry:          c.Retry,
+	}}
+}
+
+// instance returns the instance selected with -instance, or the first one.
+func (c *Config) instance() (InstanceConfig, error) {
+	instances := c.instances()
+	if c.Instance == "" {
+		return instances[0], nil
+	}
+
+	for _, inst := range instances {
+		if inst.Name == c.Instance {
+			return inst, nil
+		}
+	}
+	return InstanceConfig{}, fmt.Errorf("unknown instance %q", c.Instance)
+}
+
+// authenticator builds the windmill.Authenticator for the configured mode.
+// Basic auth without configured users returns nil so that windmill falls
+// back to WINDMILL_USERNAME and WINDMILL_PASSWORD.
+func (c *Config) authenticator() (windmill.Authenticator, error) {
+	switch c.Auth.Mode {
+	case "basic", "":
+		if len(c.Auth.Users) == 0 {
+			return nil, nil
+		}
+
+		users := make([]windmill.User, 0, len(c.Auth.Users))
+		for _, u := range c.Auth.Users {
+			users = append(users, windmill.User(u))
+		}
+		return windmill.BasicAuth(users...), nil
+	case "token":
+		tokens := make([]windmill.Token, 0, len(c.Auth.Tokens))
+		for _, t := range c.Auth.Tokens {
+			tokens = append(tokens, windmill.Token(t))
+		}
+		return windmill.TokenAuth(tokens...), nil
+	case "proxy":
+		return windmill.ProxyAuth(windmill.ProxyAuthConfig(c.Auth.Proxy)), nil
+	case "oidc":
+		o := c.Auth.OIDC
+		return windmill.OIDCAuth(windmill.OIDCConfig{
+			IssuerURL:    o.IssuerURL,
+			ClientID:     o.ClientID,
+			ClientSecret: o.ClientSecret,
+			RedirectURL:  o.RedirectURL,
+			Scopes:       o.Scopes,
+			GroupsClaim:  o.GroupsClaim,
+			GroupRoles:   o.GroupRoles,
+			DefaultRole:  o.DefaultRole,
+			SessionKey:   []byte(o.SessionKey),
+			SessionTTL:   o.SessionTTL,
+		}), nil
+	case "none":
+		return windmill.NoAuth(), nil
+	default:
+		return nil, fmt.Errorf("unknown auth mode %q", c.Auth.Mode)
+	}
+}
+
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func parseGroupRoles(v string) (map[string]windmill.Role, error) {
+	roles := make(map[string]windmill.Role)
+	for _, pair := range splitList(v) {
+		group, role, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid group role mapping %q", pair)
+		}
+
+		var r windmill.Role
+		if err := r.UnmarshalText([]byte(role)); err != nil {
+			return nil, err
+		}
+		roles[group] = r
+	}
+	return roles, nil
+}
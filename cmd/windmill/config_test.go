@@ -0,0 +1,197 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+
+	"github.com/scmofeoluwa/windmill"
+)
+
+func testFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+func TestLoadConfig_Precedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "windmill.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+dlq: file_dlq
+listen: ":4000"
+base_path: /file
+auth:
+  mode: basic
+  users:
+    - username: admin
+      password: secret
+      role: admin
+streams: ["orders.*", "payments.*"]
+exclude_streams: ["*.tmp"]
+stream_refresh_interval: 5s
+retention:
+  - stream: "orders.*"
+    max_len: 10000
+    max_age: 168h
+    approximate: true
+retry:
+  - topic: "orders.*"
+    error_pattern: "timeout"
+    max_attempts: 5
+    initial_backoff: 30s
+schedule_interval: 10s
+instance_id: replica-1
+leader_lease: 30s
+`), 0o600))
+
+	env := map[string]string{
+		"WINDMILL_CONFIG":    path,
+		"WINDMILL_LISTEN":    ":5000",
+		"WINDMILL_BASE_PATH": "/env",
+	}
+
+	cfg, err := loadConfig(testFlagSet(), []string{"-base-path", "/flag", "-read-only"}, func(k string) string { return env[k] })
+	require.NoError(t, err)
+
+	require.Equal(t, "file_dlq", cfg.DLQ)
+	require.Equal(t, ":5000", cfg.Listen)
+	require.Equal(t, "/flag", cfg.BasePath)
+	require.True(t, cfg.ReadOnly)
+	require.Equal(t, "redis://localhost:6379", cfg.RedisURL)
+	require.Len(t, cfg.Auth.Users, 1)
+	require.Equal(t, windmill.RoleAdmin, cfg.Auth.Users[0].Role)
+	require.Equal(t, []string{"orders.*", "payments.*"}, cfg.Streams)
+	require.Equal(t, []string{"*.tmp"}, cfg.ExcludeStreams)
+	require.Equal(t, 5*time.Second, cfg.StreamRefreshInterval)
+	require.Equal(t, []windmill.RetentionPolicy{
+		{Stream: "orders.*", MaxLen: 10000, MaxAge: 168 * time.Hour, Approximate: true},
+	}, cfg.Retention)
+	require.Equal(t, []windmill.RetryPolicy{
+		{Topic: "orders.*", ErrorPattern: "timeout", MaxAttempts: 5, InitialBackoff: 30 * time.Second},
+	}, cfg.Retry)
+	require.Equal(t, 10*time.Second, cfg.ScheduleInterval)
+	require.Equal(t, "replica-1", cfg.InstanceID)
+	require.Equal(t, 30*time.Second, cfg.LeaderLease)
+}
+
+func TestLoadConfig_Validation(t *testing.T) {
+	noEnv := func(string) string { return "" }
+
+	cfg, err := loadConfig(testFlagSet(), nil, noEnv)
+	require.NoError(t, err)
+	require.ErrorContains(t, cfg.validate(), "dlq is required")
+
+	cfg, err = loadConfig(testFlagSet(), []string{"-dlq", "dlq", "-tls-cert", "cert.pem"}, noEnv)
+	require.NoError(t, err)
+	require.ErrorContains(t, cfg.validate(), "tls-key")
+
+	_, err = loadConfig(testFlagSet(), []string{"-dlq", "dlq", "-oidc-group-roles", "sre=root"}, noEnv)
+	require.Error(t, err)
+}
+
+func TestConfig_Instances(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "windmill.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+instances:
+  - name: us-east
+    redis_url: redis://us.example.com:6379
+    dlq: us_dlq
+    streams: ["orders.*"]
+  - name: eu-west
+    redis_url: redis://eu.example.com:6379
+    dlq: eu_dlq
+`), 0o600))
+
+	noEnv := func(string) string { return "" }
+
+	cfg, err := loadConfig(testFlagSet(), []string{"-config", path}, noEnv)
+	require.NoError(t, err)
+	require.NoError(t, cfg.validate())
+	require.Len(t, cfg.instances(), 2)
+
+	inst, err := cfg.instance()
+	require.NoError(t, err)
+	require.Equal(t, "us-east", inst.Name)
+	require.Equal(t, []string{"orders.*"}, inst.Streams)
+
+	cfg, err = loadConfig(testFlagSet(), []string{"-config", path, "-instance", "eu-west"}, noEnv)
+	require.NoError(t, err)
+	inst, err = cfg.instance()
+	require.NoError(t, err)
+	require.Equal(t, "eu_dlq", inst.DLQ)
+
+	cfg.Instance = "ap-south"
+	_, err = cfg.instance()
+	require.ErrorContains(t, err, "unknown instance")
+
+	cfg.Instances[1].DLQ = ""
+	require.ErrorContains(t, cfg.validate(), `instance "eu-west": dlq is required`)
+
+	cfg, err = loadConfig(testFlagSet(), []string{"-dlq", "dlq"}, noEnv)
+	require.NoError(t, err)
+	inst, err = cfg.instance()
+	require.NoError(t, err)
+	require.Equal(t, InstanceConfig{Name: "default", RedisURL: "redis://localhost:6379", DLQ: "dlq"}, inst)
+}
+
+func TestConfig_Authenticator(t *testing.T) {
+	cfg := defaultConfig()
+
+	auth, err := cfg.authenticator()
+	require.NoError(t, err)
+	require.Nil(t, auth)
+
+	cfg.Auth.Mode = "none"
+	auth, err = cfg.authenticator()
+	require.NoError(t, err)
+	require.NotNil(t, auth)
+
+	cfg.Auth.Mode = "ldap"
+	_, err = cfg.authenticator()
+	require.Error(t, err)
+}
+
+func TestNewRedisClient(t *testing.T) {
+	tests := []struct {
+		url     string
+		want    any
+		wantErr bool
+	}{
+		{url: "redis://localhost:6379/1", want: &redis.Client{}},
+		{url: "redis+cluster://localhost:7000?addr=localhost:7001", want: &redis.ClusterClient{}},
+		{url: "redis+sentinel://:pw@localhost:26379,localhost:26380/mymaster/2", want: &redis.Client{}},
+		{url: "redis+sentinel://localhost:26379", wantErr: true},
+		{url: "memcached://localhost", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			client, err := newRedisClient(tt.url)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.IsType(t, tt.want, client)
+			require.NoError(t, client.Close())
+		})
+	}
+}
+
+func TestParseSentinelURL(t *testing.T) {
+	opts, err := parseSentinelURL("rediss+sentinel://:pw@a:26379,b:26379/mymaster/2")
+	require.NoError(t, err)
+
+	require.Equal(t, "mymaster", opts.MasterName)
+	require.Equal(t, []string{"a:26379", "b:26379"}, opts.SentinelAddrs)
+	require.Equal(t, 2, opts.DB)
+	require.Equal(t, "pw", opts.Password)
+	require.NotNil(t, opts.TLSConfig)
+}
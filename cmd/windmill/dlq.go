@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/scmofeoluwa/windmill/internal/monitor"
+)
+
+func runDLQ(ctx context.Context, args []string, stdout io.Writer, getenv func(string) string) error {
+	if len(args) == 0 {
+		return errors.New("usage: windmill dlq <ls|requeue|scheduled|purge|export> [flags]")
+	}
+
+	c := newCLI("dlq "+args[0], stdout, getenv)
+	defer c.close()
+
+	switch args[0] {
+	case "ls":
+		return dlqList(ctx, c, args[1:])
+	case "requeue":
+		return dlqRequeue(ctx, c, args[1:])
+	case "scheduled":
+		return dlqScheduled(ctx, c, args[1:])
+	case "purge":
+		return dlqPurge(ctx, c, args[1:])
+	case "export":
+		return dlqExport(ctx, c, args[1:])
+	default:
+		return fmt.Errorf("unknown dlq command %q", args[0])
+	}
+}
+
+var dlqTable = table[monitor.DLQMessage]{
+	header: []string{"ID", "TIMESTAMP", "TOPIC", "ERROR"},
+	row: func(m monitor.DLQMessage) []string {
+		return []string{m.ID, m.Timestamp.UTC().Format(time.RFC3339), m.OriginalTopic, truncate(m.Error, 60)}
+	},
+}
+
+func dlqList(ctx context.Context, c *cli, args []string) error {
+	pagination := c.paginationFlags()
+	if _, err := c.parse(args); err != nil {
+		return err
+	}
+
+	opts, err := pagination.opts()
+	if err != nil {
+		return err
+	}
+
+	mon, err := c.dlqMonitor(ctx)
+	if err != nil {
+		return err
+	}
+
+	list, err := mon.DLQ().GetMessages(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	if *c.format == "json" {
+		return writeJSON(c.stdout, list)
+	}
+
+	return printList(c, list.Messages, dlqTable, pageFooter(len(list.Messages), list.TotalCount, list.NextCursor))
+}
+
+// dlqRequeue requeues messages now or, with -at or -delay, schedules them
+// to be requeued later by a running server.
+func dlqRequeue(ctx context.Context, c *cli, args []string) error {
+	all := c.fs.Bool("all", false, "requeue every message in the DLQ")
+	at := c.fs.String("at", "", "schedule the requeue for this RFC 3339 time")
+	delay := c.fs.Duration("delay", 0, "schedule the requeue after this duration (e.g. 15m)")
+	cancel := c.fs.Bool("cancel", false, "cancel the scheduled requeue of the messages")
+	positional, err := c.parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *all == (len(positional) > 0) {
+		return errors.New("usage: windmill dlq requeue (-all | [-at <time> | -delay <duration> | -cancel] <id>...)")
+	}
+
+	scheduled := *at != "" || *delay != 0
+	if (*at != "" && *delay != 0) || (scheduled && *cancel) {
+		return errors.New("-at, -delay and -cancel are mutually exclusive")
+	}
+	if *all && (scheduled || *cancel) {
+		return errors.New("-all cannot be scheduled")
+	}
+
+	due := time.Now().Add(*delay)
+	if *at != "" {
+		if due, err = time.Parse(time.RFC3339, *at); err != nil {
+			return fmt.Errorf("invalid -at %q: want an RFC 3339 time", *at)
+		}
+	}
+
+	mon, err := c.dlqMonitor(ctx)
+	if err != nil {
+		return err
+	}
+
+	if *all {
+		count, err := mon.DLQ().RequeueAll(ctx)
+		fmt.Fprintf(c.stdout, "requeued %d messages\n", count)
+		return err
+	}
+
+	for _, id := range positional {
+		switch {
+		case *cancel:
+			cancelled, err := mon.DLQ().CancelRequeue(ctx, id)
+			if err != nil {
+				return err
+			}
+			if !cancelled {
+				return fmt.Errorf("no requeue scheduled for %s", id)
+			}
+			fmt.Fprintf(c.stdout, "cancelled %s\n", id)
+		case scheduled:
+			s, err := mon.DLQ().ScheduleRequeue(ctx, id, due, nil)
+			if err != nil {
+				return fmt.Errorf("failed to schedule message %s: %w", id, err)
+			}
+			fmt.Fprintf(c.stdout, "scheduled %s for %s\n", id, s.DueAt.Format(time.RFC3339))
+		default:
+			if err := mon.DLQ().RequeueMessage(ctx, id, nil); err != nil {
+				return fmt.Errorf("failed to requeue message %s: %w", id, err)
+			}
+			fmt.Fprintf(c.stdout, "requeued %s\n", id)
+		}
+	}
+
+	return nil
+}
+
+var scheduledTable = table[monitor.ScheduledRequeue]{
+	header: []string{"ID", "DUE", "TOPIC"},
+	row: func(s monitor.ScheduledRequeue) []string {
+		return []string{s.ID, s.DueAt.Format(time.RFC3339), s.Topic}
+	},
+}
+
+func dlqScheduled(ctx context.Context, c *cli, args []string) error {
+	cursor := c.fs.String("cursor", "", "return schedules from this position")
+	limit := c.fs.Int64("limit", 50, "maximum number of schedules (at most 100)")
+	if _, err := c.parse(args); err != nil {
+		return err
+	}
+
+	mon, err := c.dlqMonitor(ctx)
+	if err != nil {
+		return err
+	}
+
+	opts := monitor.PaginationOpts{Cursor: *cursor, Limit: min(*limit, 100), Order: monitor.SortOrderAsc}
+	list, err := mon.DLQ().ScheduledRequeues(ctx, opts, nil)
+	if err != nil {
+		return err
+	}
+
+	if *c.format == "json" {
+		return writeJSON(c.stdout, list)
+	}
+
+	footer := ""
+	if list.NextCursor != "" {
+		footer = fmt.Sprintf("%d of %d schedules, next page: -cursor %s", len(list.Messages), list.TotalCount, list.NextCursor)
+	}
+	return printList(c, list.Messages, scheduledTable, footer)
+}
+
+// dlqPurge deletes the DLQ messages matching its flags. -yes stands in
+// for typing the DLQ name as confirmation.
+func dlqPurge(ctx context.Context, c *cli, args []string) error {
+	yes := c.fs.Bool("yes", false, "confirm the purge")
+	confirm := c.fs.String("confirm", "", "confirm the purge by repeating the DLQ name")
+	before := c.fs.String("before", "", "only purge messages older than this RFC 3339 time or duration (e.g. 72h)")
+	topic := c.fs.String("topic", "", "only purge messages poisoned from this topic")
+	errorContains := c.fs.String("error", "", "only purge messages whose error contains this text")
+	dryRun := c.fs.Bool("dry-run", false, "only report how many messages would be deleted")
+	file := c.fs.String("export", "", "export the purged messages to this file first")
+	format := c.fs.String("format", "ndjson", "export format: ndjson, csv or archive")
+	if _, err := c.parse(args); err != nil {
+		return err
+	}
+
+	if !*yes && *confirm == "" && !*dryRun {
+		return errors.New("refusing to purge the DLQ without -yes or -confirm <dlq>; use -dry-run to preview")
+	}
+
+	mon, err := c.dlqMonitor(ctx)
+	if err != nil {
+		return err
+	}
+
+	opts := monitor.PurgeOpts{
+		Topic:         *topic,
+		ErrorContains: *errorContains,
+		Confirm:       *confirm,
+		DryRun:        *dryRun,
+	}
+	if *yes {
+		opts.Confirm = mon.DLQ().Name()
+	}
+
+	if *before != "" {
+		opts.Before, err = parseBefore(*before, time.Now())
+		if err != nil {
+			return err
+		}
+	}
+
+	if *file != "" && !opts.DryRun {
+		opts.ExportFormat, err = monitor.ParseExportFormat(*format)
+		if err != nil {
+			return fmt.Errorf("invalid format %q", *format)
+		}
+
+		f, err := os.Create(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		opts.Export = f
+	}
+
+	result, err := mon.DLQ().Purge(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	if result.DryRun {
+		fmt.Fprintf(c.stdout, "would delete %d messages\n", result.Matched)
+		return nil
+	}
+
+	fmt.Fprintf(c.stdout, "deleted %d messages\n", result.Deleted)
+	if result.Exported {
+		fmt.Fprintf(c.stdout, "exported to %s\n", *file)
+	}
+	return nil
+}
+
+// parseBefore accepts an absolute RFC 3339 time or a duration counted back
+// from now.
+func parseBefore(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid -before %q: want an RFC 3339 time or a duration", s)
+	}
+	return t, nil
+}
+
+func dlqExport(ctx context.Context, c *cli, args []string) error {
+	file := c.fs.String("file", "", "write to this file instead of stdout")
+	format := c.fs.String("format", "ndjson", "export format: ndjson, csv or archive")
+	topic := c.fs.String("topic", "", "only export messages poisoned from this topic")
+	if _, err := c.parse(args); err != nil {
+		return err
+	}
+
+	exportFormat, err := monitor.ParseExportFormat(*format)
+	if err != nil {
+		return fmt.Errorf("invalid format %q", *format)
+	}
+
+	mon, err := c.dlqMonitor(ctx)
+	if err != nil {
+		return err
+	}
+
+	out := c.stdout
+	if *file != "" {
+		f, err := os.Create(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	opts := monitor.ExportOpts{Order: monitor.SortOrderAsc}
+	if *topic != "" {
+		opts.Filter = func(record monitor.ExportRecord) bool {
+			return record.Metadata[monitor.TopicPoisonedKey] == *topic
+		}
+	}
+
+	return mon.DLQ().Export(ctx, exportFormat, opts, out)
+}
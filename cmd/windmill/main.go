@@ -0,0 +1,56 @@
+// Command windmill serves the Windmill dashboard for a Redis instance and
+// offers the same stream and DLQ operations from the command line.
+//
+// Usage:
+//
+//	windmill [serve] [flags]
+//	windmill streams ls|show|trim <stream>
+//	windmill messages ls|get|rm <stream> [<id>...]
+//	windmill messages replay <file>
+//	windmill dlq ls|requeue|scheduled|purge|export
+//
+// Run any command with -h for the list of flags. Every config flag can
+// also be set through a WINDMILL_* environment variable or a YAML config
+// file.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	args := os.Args[1:]
+
+	cmd := "serve"
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		cmd, args = args[0], args[1:]
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var err error
+	switch cmd {
+	case "serve":
+		err = runServe(args)
+	case "streams":
+		err = runStreams(ctx, args, os.Stdout, os.Getenv)
+	case "messages":
+		err = runMessages(ctx, args, os.Stdout, os.Getenv)
+	case "dlq":
+		err = runDLQ(ctx, args, os.Stdout, os.Getenv)
+	default:
+		err = fmt.Errorf("unknown command %q", cmd)
+	}
+
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintln(os.Stderr, "windmill:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/scmofeoluwa/windmill/internal/monitor"
+)
+
+func runMessages(ctx context.Context, args []string, stdout io.Writer, getenv func(string) string) error {
+	if len(args) == 0 {
+		return errors.New("usage: windmill messages <ls|get|rm|replay> [flags]")
+	}
+
+	c := newCLI("messages "+args[0], stdout, getenv)
+	defer c.close()
+
+	switch args[0] {
+	case "ls":
+		return messagesList(ctx, c, args[1:])
+	case "get":
+		return messagesGet(ctx, c, args[1:])
+	case "rm":
+		return messagesRemove(ctx, c, args[1:])
+	case "replay":
+		return messagesReplay(ctx, c, args[1:])
+	default:
+		return fmt.Errorf("unknown messages command %q", args[0])
+	}
+}
+
+var messageTable = table[monitor.Message]{
+	header: []string{"ID", "TIMESTAMP", "PAYLOAD"},
+	row: func(m monitor.Message) []string {
+		return []string{m.ID, m.Timestamp.UTC().Format(time.RFC3339), truncate(compactJSON(m.Payload), 80)}
+	},
+}
+
+func messagesList(ctx context.Context, c *cli, args []string) error {
+	pagination := c.paginationFlags()
+	positional, err := c.parse(args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 1 {
+		return errors.New("usage: windmill messages ls <stream>")
+	}
+
+	opts, err := pagination.opts()
+	if err != nil {
+		return err
+	}
+
+	mon, err := c.monitor(ctx)
+	if err != nil {
+		return err
+	}
+
+	list, err := mon.Streams().GetStreamMessages(ctx, positional[0], opts)
+	if err != nil {
+		return err
+	}
+
+	if *c.format == "json" {
+		return writeJSON(c.stdout, list)
+	}
+
+	return printList(c, list.Messages, messageTable, pageFooter(len(list.Messages), list.TotalCount, list.NextCursor))
+}
+
+func messagesGet(ctx context.Context, c *cli, args []string) error {
+	positional, err := c.parse(args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 2 {
+		return errors.New("usage: windmill messages get <stream> <id>")
+	}
+
+	mon, err := c.monitor(ctx)
+	if err != nil {
+		return err
+	}
+
+	msg, err := mon.Streams().GetMessage(ctx, positional[0], positional[1])
+	if err != nil {
+		return err
+	}
+
+	if msg == nil {
+		return fmt.Errorf("message not found: %s", positional[1])
+	}
+
+	return printValue(c, msg, [][2]string{
+		{"ID", msg.ID},
+		{"Timestamp", msg.Timestamp.UTC().Format(time.RFC3339)},
+		{"Payload", compactJSON(msg.Payload)},
+	})
+}
+
+func messagesRemove(ctx context.Context, c *cli, args []string) error {
+	positional, err := c.parse(args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) < 2 {
+		return errors.New("usage: windmill messages rm <stream> <id>...")
+	}
+
+	mon, err := c.monitor(ctx)
+	if err != nil {
+		return err
+	}
+
+	stream := positional[0]
+	for _, id := range positional[1:] {
+		if err := mon.Streams().DeleteMessage(ctx, stream, id); err != nil {
+			return fmt.Errorf("failed to delete message %s: %w", id, err)
+		}
+		fmt.Fprintf(c.stdout, "deleted %s\n", id)
+	}
+
+	return nil
+}
+
+// messagesReplay writes the records of an NDJSON export back to Redis.
+// Use "-" to read the records from stdin.
+func messagesReplay(ctx context.Context, c *cli, args []string) error {
+	target := c.fs.String("target", "", "replay every record into this stream instead of its original topic")
+	dryRun := c.fs.Bool("dry-run", false, "validate the records without writing them")
+	rate := c.fs.Float64("rate", 0, "maximum messages written per second (0 for unlimited)")
+	positional, err := c.parse(args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 1 {
+		return errors.New("usage: windmill messages replay <file|->")
+	}
+
+	in := c.stdin
+	if positional[0] != "-" {
+		f, err := os.Open(positional[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	mon, err := c.monitor(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := mon.Streams().Replay(ctx, in, monitor.ReplayOpts{
+		Target: *target,
+		DryRun: *dryRun,
+		Rate:   *rate,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := printValue(c, result, [][2]string{
+		{"Dry run", fmt.Sprint(result.DryRun)},
+		{"Total", fmt.Sprint(result.Total)},
+		{"Replayed", fmt.Sprint(result.Replayed)},
+		{"Failed", fmt.Sprint(result.Failed)},
+	}); err != nil {
+		return err
+	}
+
+	if *c.format == "table" {
+		for _, e := range result.Errors {
+			fmt.Fprintf(c.stdout, "line %d: %s\n", e.Line, e.Error)
+		}
+	}
+
+	return nil
+}
+
+func pageFooter(shown int, total int64, nextCursor string) string {
+	footer := fmt.Sprintf("%d of %d messages", shown, total)
+	if nextCursor != "" {
+		footer += fmt.Sprintf(", next page: -cursor %s", nextCursor)
+	}
+	return footer
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// newRedisClient connects to Redis from a URL. Besides the standard
+// redis:// and rediss:// schemes it accepts:
+//
+//	redis+sentinel://[:password@]host1:26379,host2:26379/master[/db]
+//	redis+cluster://[user:password@]host1:6379?addr=host2:6379
+//
+// with rediss+ variants enabling TLS.
+func newRedisClient(rawURL string) (redis.UniversalClient, error) {
+	scheme, _, ok := strings.Cut(rawURL, "://")
+	if !ok {
+		return nil, fmt.Errorf("invalid redis url %q", rawURL)
+	}
+
+	switch scheme {
+	case "redis", "rediss":
+		opts, err := redis.ParseURL(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		return redis.NewClient(opts), nil
+	case "redis+cluster", "rediss+cluster":
+		opts, err := redis.ParseClusterURL(strings.Replace(rawURL, "+cluster", "", 1))
+		if err != nil {
+			return nil, err
+		}
+		return redis.NewClusterClient(opts), nil
+	case "redis+sentinel", "rediss+sentinel":
+		opts, err := parseSentinelURL(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		return redis.NewFailoverClient(opts), nil
+	default:
+		return nil, fmt.Errorf("unsupported redis url scheme %q", scheme)
+	}
+}
+
+func parseSentinelURL(rawURL string) (*redis.FailoverOptions, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if parts[0] == "" {
+		return nil, fmt.Errorf("sentinel url requires a master name")
+	}
+
+	opts := &redis.FailoverOptions{
+		MasterName:    parts[0],
+		SentinelAddrs: strings.Split(u.Host, ","),
+	}
+
+	if len(parts) > 1 {
+		db, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid database %q", parts[1])
+		}
+		opts.DB = db
+	}
+
+	if u.User != nil {
+		opts.Username = u.User.Username()
+		opts.Password, _ = u.User.Password()
+	}
+
+	if sentinelPassword := u.Query().Get("sentinel_password"); sentinelPassword != "" {
+		opts.SentinelPassword = sentinelPassword
+	}
+
+	if strings.HasPrefix(u.Scheme, "rediss") {
+		opts.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	return opts, nil
+}
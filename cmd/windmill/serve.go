@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/scmofeoluwa/windmill"
+)
+
+const shutdownTimeout = 15 * time.Second
+
+func runServe(args []string) error {
+	cfg, err := loadConfig(flag.NewFlagSet("serve", flag.ContinueOnError), args, os.Getenv)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var instances []windmill.Instance
+	for _, inst := range cfg.instances() {
+		rc, err := newRedisClient(inst.RedisURL)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+
+		if err := rc.Ping(ctx).Err(); err != nil {
+			if len(cfg.Instances) > 0 {
+				return fmt.Errorf("could not connect to redis instance %q: %w", inst.Name, err)
+			}
+			return fmt.Errorf("could not connect to redis: %w", err)
+		}
+
+		instances = append(instances, windmill.Instance{
+			Name:           inst.Name,
+			RedisClient:    rc,
+			DLQName:        inst.DLQ,
+			Streams:        inst.Streams,
+			ExcludeStreams: inst.ExcludeStreams,
+			Retention:      inst.Retention,
+			Retry:          inst.Retry,
+		})
+	}
+
+	auth, err := cfg.authenticator()
+	if err != nil {
+		return err
+	}
+
+	wm, err := windmill.New(windmill.Config{
+		Instances:      instances,
+		Auth:           auth,
+		AllowedOrigins: cfg.AllowedOrigins,
+		FrameAncestors: cfg.FrameAncestors,
+		ReadOnly:       cfg.ReadOnly,
+		BasePath:       cfg.BasePath,
+
+		StreamRefreshInterval: cfg.StreamRefreshInterval,
+
+		RetentionInterval: cfg.RetentionInterval,
+		RetryInterval:     cfg.RetryInterval,
+		ScheduleInterval:  cfg.ScheduleInterval,
+		InstanceID:        cfg.InstanceID,
+		LeaderLease:       cfg.LeaderLease,
+	})
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:              cfg.Listen,
+		Handler:           wm.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// Run is awaited on the way out so the leader releases its lease before
+	// the process exits, letting another replica take over right away.
+	runCtx, stopRun := context.WithCancel(ctx)
+	runDone := make(chan struct{})
+	go func() {
+		defer close(runDone)
+		wm.Run(runCtx)
+	}()
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Printf("windmill dashboard listening on %s", cfg.Listen)
+		if cfg.TLSCert != "" {
+			errCh <- srv.ListenAndServeTLS(cfg.TLSCert, cfg.TLSKey)
+		} else {
+			errCh <- srv.ListenAndServe()
+		}
+	}()
+
+	var serveErr error
+	select {
+	case serveErr = <-errCh:
+	case <-ctx.Done():
+	}
+
+	log.Println("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	stopRun()
+	err = srv.Shutdown(shutdownCtx)
+
+	select {
+	case <-runDone:
+	case <-shutdownCtx.Done():
+		log.Println("background workers did not stop in time")
+	}
+
+	return errors.Join(serveErr, err)
+}
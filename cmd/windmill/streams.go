@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/scmofeoluwa/windmill/internal/monitor"
+)
+
+func runStreams(ctx context.Context, args []string, stdout io.Writer, getenv func(string) string) error {
+	if len(args) == 0 {
+		return errors.New("usage: windmill streams <ls|show|trim> [flags]")
+	}
+
+	c := newCLI("streams "+args[0], stdout, getenv)
+	defer c.close()
+
+	switch args[0] {
+	case "ls":
+		return streamsList(ctx, c, args[1:])
+	case "show":
+		return streamsShow(ctx, c, args[1:])
+	case "trim":
+		return streamsTrim(ctx, c, args[1:])
+	default:
+		return fmt.Errorf("unknown streams command %q", args[0])
+	}
+}
+
+var streamTable = table[monitor.StreamInfo]{
+	header: []string{"NAME", "LENGTH", "MEMORY", "LAST ACTIVITY", "ERROR"},
+	row: func(s monitor.StreamInfo) []string {
+		return []string{s.Name, strconv.FormatInt(s.Length, 10), formatMemory(s.MemoryBytes), formatTime(s.LastActivity), truncate(s.Error, 60)}
+	},
+}
+
+func streamsList(ctx context.Context, c *cli, args []string) error {
+	query := c.fs.String("q", "", "only list streams whose name contains this text")
+	sortBy := c.fs.String("sort", "name", "sort by name, length, memory or last_activity")
+	order := c.fs.String("order", "asc", "sort order: asc or desc")
+	cursor := c.fs.String("cursor", "", "return streams from this position")
+	limit := c.fs.Int64("limit", 100, "maximum number of streams (at most 500)")
+	if _, err := c.parse(args); err != nil {
+		return err
+	}
+
+	opts := monitor.StreamListOpts{Query: *query, Cursor: *cursor, Limit: min(*limit, 500)}
+
+	var err error
+	if opts.Sort, err = monitor.ParseStreamSort(*sortBy); err != nil {
+		return fmt.Errorf("invalid sort")
+	}
+	if opts.Order, err = monitor.ParseSortOrder(*order); err != nil {
+		return fmt.Errorf("invalid order")
+	}
+
+	mon, err := c.monitor(ctx)
+	if err != nil {
+		return err
+	}
+
+	list, err := mon.Streams().ListStreams(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	footer := ""
+	if list.HasMore {
+		footer = fmt.Sprintf("%d of %d streams, next page: -cursor %s", len(list.Streams), list.TotalCount, list.NextCursor)
+	}
+	return printList(c, list.Streams, streamTable, footer)
+}
+
+// streamsShow prints the detail of a stream. With -full it also reads
+// XINFO STREAM FULL and summarizes the consumer groups.
+func streamsShow(ctx context.Context, c *cli, args []string) error {
+	full := c.fs.Bool("full", false, "include entries added, tombstones and consumer groups")
+	count := c.fs.Int("count", 10, "pending entries to list per group and consumer with -full")
+	positional, err := c.parse(args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 1 || *count < 1 {
+		return errors.New("usage: windmill streams show <stream> [-full [-count N]]")
+	}
+
+	mon, err := c.monitor(ctx)
+	if err != nil {
+		return err
+	}
+
+	var detail *monitor.StreamDetail
+	if *full {
+		detail, err = mon.Streams().GetStreamDetailFull(ctx, positional[0], *count)
+	} else {
+		detail, err = mon.Streams().GetStreamDetail(ctx, positional[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	rows := [][2]string{
+		{"Name", detail.Name},
+		{"Length", strconv.FormatInt(detail.Length, 10)},
+		{"Memory", formatMemory(detail.MemoryBytes)},
+		{"First entry", optional(detail.FirstEntryID)},
+		{"Last entry", optional(detail.LastEntryID)},
+		{"Last activity", formatTime(detail.LastActivity)},
+	}
+	if info := detail.Full; info != nil {
+		rows = append(rows,
+			[2]string{"Entries added", formatCount(info.EntriesAdded)},
+			[2]string{"Deleted entries", formatCount(info.DeletedEntries)},
+			[2]string{"Max deleted entry", orDash(info.MaxDeletedEntryID)},
+			[2]string{"Radix tree", fmt.Sprintf("%d keys, %d nodes", info.RadixTreeKeys, info.RadixTreeNodes)},
+		)
+		for _, g := range info.Groups {
+			rows = append(rows, [2]string{
+				"Group " + g.Name,
+				fmt.Sprintf("%d consumers, %d pending, lag %s, last delivered %s",
+					len(g.Consumers), g.PendingCount, formatCount(g.Lag), g.LastDeliveredID),
+			})
+		}
+	}
+	if detail.Error != "" {
+		rows = append(rows, [2]string{"Error", detail.Error})
+	}
+
+	return printValue(c, detail, rows)
+}
+
+// formatMemory prints unknown memory usage as "?".
+func formatMemory(bytes *int64) string {
+	if bytes == nil {
+		return "?"
+	}
+	return strconv.FormatInt(*bytes, 10)
+}
+
+// formatCount prints an unknown count as "?".
+func formatCount(n *int64) string {
+	if n == nil {
+		return "?"
+	}
+	return strconv.FormatInt(*n, 10)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// streamsTrim trims a stream by length or minimum ID. Without -yes it only
+// reports how many entries would be removed.
+func streamsTrim(ctx context.Context, c *cli, args []string) error {
+	maxLen := c.fs.Int64("maxlen", -1, "keep only the newest N entries")
+	minID := c.fs.String("minid", "", "remove entries older than this ID")
+	approx := c.fs.Bool("approx", false, "let Redis trim approximately, which is cheaper")
+	yes := c.fs.Bool("yes", false, "trim instead of only previewing")
+	positional, err := c.parse(args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 1 || (*maxLen < 0) == (*minID == "") {
+		return errors.New("usage: windmill streams trim <stream> -maxlen N|-minid ID [-approx] [-yes]")
+	}
+
+	opts := monitor.TrimOpts{
+		Strategy:    monitor.TrimStrategyMaxlen,
+		MaxLen:      *maxLen,
+		Approximate: *approx,
+		DryRun:      !*yes,
+	}
+	if *minID != "" {
+		opts.Strategy, opts.MinID = monitor.TrimStrategyMinid, *minID
+	}
+
+	mon, err := c.monitor(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := mon.Streams().Trim(ctx, positional[0], opts)
+	if err != nil {
+		return err
+	}
+
+	if result.DryRun {
+		about := ""
+		if result.Estimated {
+			about = "about "
+		}
+		fmt.Fprintf(c.stdout, "would remove %s%d entries, run again with -yes to trim\n", about, result.Removed)
+		return nil
+	}
+
+	fmt.Fprintf(c.stdout, "removed %d entries\n", result.Removed)
+	return nil
+}
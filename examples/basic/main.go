@@ -95,6 +95,9 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	if err := wm.RegisterRouter(router, windmill.RouterOpts{}); err != nil {
+		log.Fatal(err)
+	}
 
 	go produceMessages(ctx, publisher)
 
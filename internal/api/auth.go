@@ -0,0 +1,340 @@
+package api
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// Authenticator identifies the caller of a request.
+type Authenticator interface {
+	// Authenticate returns the principal making r, or ErrUnauthenticated
+	// when the request carries no valid credentials. A nil principal
+	// without an error is treated as ErrUnauthenticated.
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// Challenger is implemented by authenticators that respond to
+// unauthenticated requests with more than a plain 401, such as the
+// WWW-Authenticate header of Basic Auth.
+type Challenger interface {
+	Challenge(w http.ResponseWriter, r *http.Request)
+}
+
+// RouteProvider is implemented by authenticators that serve their own
+// endpoints, such as login callbacks. The routes are mounted under /auth
+// and bypass authentication.
+type RouteProvider interface {
+	Routes() http.Handler
+}
+
+// Validator is implemented by authenticators whose configuration can be
+// checked up front.
+type Validator interface {
+	Validate() error
+}
+
+type BasicAuthenticator struct {
+	users    []User
+	verified sync.Map
+
+	// unknown stands in for users that do not exist, so rejecting them
+	// costs as much as rejecting a wrong password.
+	unknown     User
+	unknownOnce sync.Once
+}
+
+func NewBasicAuthenticator(users []User) *BasicAuthenticator {
+	return &BasicAuthenticator{users: users}
+}
+
+func (b *BasicAuthenticator) Validate() error {
+	if len(b.users) == 0 {
+		return errors.New("basic auth requires at least one user")
+	}
+
+	seen := make(map[string]bool, len(b.users))
+	for _, u := range b.users {
+		if u.Username == "" {
+			return errors.New("basic auth users require a username")
+		}
+
+		if (u.Password == "") == (u.PasswordHash == "") {
+			return fmt.Errorf("user %q requires exactly one of password or password hash", u.Username)
+		}
+
+		if u.PasswordHash != "" {
+			if _, err := bcrypt.Cost([]byte(u.PasswordHash)); err != nil {
+				return fmt.Errorf("user %q has invalid password hash: %w", u.Username, err)
+			}
+		}
+
+		if seen[u.Username] {
+			return fmt.Errorf("duplicate user %q", u.Username)
+		}
+		seen[u.Username] = true
+
+		if err := validateGrant(u.Role, u.Streams); err != nil {
+			return fmt.Errorf("user %q: %w", u.Username, err)
+		}
+	}
+
+	return nil
+}
+
+func (b *BasicAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	user, pass, ok := r.BasicAuth()
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	// Compare against every username so the response time does not reveal
+	// which users exist.
+	var matched *User
+	for i := range b.users {
+		if subtle.ConstantTimeCompare([]byte(user), []byte(b.users[i].Username)) == 1 && matched == nil {
+			matched = &b.users[i]
+		}
+	}
+
+	if matched == nil {
+		b.checkPassword(b.unknownUser(), pass)
+		return nil, ErrUnauthenticated
+	}
+
+	if !b.checkPassword(matched, pass) {
+		return nil, ErrUnauthenticated
+	}
+
+	return &Principal{
+		Name:    matched.Username,
+		Role:    matched.Role,
+		Streams: matched.Streams,
+	}, nil
+}
+
+// checkPassword verifies pass for u. Successful bcrypt comparisons are
+// remembered so the dashboard's burst of API calls does not pay the hashing
+// cost on every request.
+func (b *BasicAuthenticator) checkPassword(u *User, pass string) bool {
+	if u.PasswordHash == "" {
+		return subtle.ConstantTimeCompare([]byte(pass), []byte(u.Password)) == 1
+	}
+
+	key := sha256.Sum256([]byte(u.Username + "\x00" + pass))
+	if _, ok := b.verified.Load(key); ok {
+		return true
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(pass)) != nil {
+		return false
+	}
+
+	b.verified.Store(key, struct{}{})
+	return true
+}
+
+// unknownUser returns a user no password matches, hashed at the highest
+// cost of the configured users, or with a plain password when none is
+// hashed.
+func (b *BasicAuthenticator) unknownUser() *User {
+	b.unknownOnce.Do(func() {
+		b.unknown.Password = randomToken()
+
+		cost := 0
+		for _, u := range b.users {
+			if c, err := bcrypt.Cost([]byte(u.PasswordHash)); err == nil {
+				cost = max(cost, c)
+			}
+		}
+		if cost == 0 {
+			return
+		}
+
+		if hash, err := bcrypt.GenerateFromPassword([]byte(b.unknown.Password), cost); err == nil {
+			b.unknown.PasswordHash = string(hash)
+		}
+	})
+	return &b.unknown
+}
+
+func (b *BasicAuthenticator) Challenge(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("WWW-Authenticate", `Basic realm="Windmill"`)
+	Error(w, http.StatusUnauthorized, "Unauthorized")
+}
+
+type TokenAuthenticator struct {
+	tokens []Token
+}
+
+func NewTokenAuthenticator(tokens []Token) *TokenAuthenticator {
+	return &TokenAuthenticator{tokens: tokens}
+}
+
+func (t *TokenAuthenticator) Validate() error {
+	if len(t.tokens) == 0 {
+		return errors.New("token auth requires at least one token")
+	}
+
+	for _, tok := range t.tokens {
+		if tok.Name == "" || tok.Token == "" {
+			return errors.New("tokens require a name and a value")
+		}
+
+		if err := validateGrant(tok.Role, tok.Streams); err != nil {
+			return fmt.Errorf("token %q: %w", tok.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (t *TokenAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	value, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || value == "" {
+		return nil, ErrUnauthenticated
+	}
+
+	var matched *Token
+	for i := range t.tokens {
+		if subtle.ConstantTimeCompare([]byte(value), []byte(t.tokens[i].Token)) == 1 && matched == nil {
+			matched = &t.tokens[i]
+		}
+	}
+
+	if matched == nil {
+		return nil, ErrUnauthenticated
+	}
+
+	return &Principal{
+		Name:    matched.Name,
+		Role:    matched.Role,
+		Streams: matched.Streams,
+	}, nil
+}
+
+func (t *TokenAuthenticator) Challenge(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="Windmill"`)
+	Error(w, http.StatusUnauthorized, "Unauthorized")
+}
+
+type ProxyAuthenticator struct {
+	config  ProxyAuthConfig
+	trusted []*net.IPNet
+}
+
+func NewProxyAuthenticator(config ProxyAuthConfig) *ProxyAuthenticator {
+	if config.UserHeader == "" {
+		config.UserHeader = "X-Forwarded-User"
+	}
+
+	p := &ProxyAuthenticator{config: config}
+	for _, cidr := range config.TrustedProxies {
+		if _, network, err := net.ParseCIDR(cidr); err == nil {
+			p.trusted = append(p.trusted, network)
+		}
+	}
+
+	return p
+}
+
+func (p *ProxyAuthenticator) Validate() error {
+	if len(p.config.TrustedProxies) == 0 {
+		return errors.New("proxy auth requires at least one trusted proxy")
+	}
+
+	for _, cidr := range p.config.TrustedProxies {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("invalid trusted proxy %q: %w", cidr, err)
+		}
+	}
+
+	if p.config.DefaultRole != "" && !p.config.DefaultRole.IsValid() {
+		return fmt.Errorf("invalid default role %q", p.config.DefaultRole)
+	}
+
+	if p.config.DefaultRole == "" && p.config.RoleHeader == "" {
+		return errors.New("proxy auth requires a default role or a role header")
+	}
+
+	return nil
+}
+
+func (p *ProxyAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if !p.fromTrustedProxy(r) {
+		return nil, ErrUnauthenticated
+	}
+
+	user := r.Header.Get(p.config.UserHeader)
+	if user == "" {
+		return nil, ErrUnauthenticated
+	}
+
+	role := p.config.DefaultRole
+	if p.config.RoleHeader != "" {
+		if value := r.Header.Get(p.config.RoleHeader); value != "" {
+			parsed, err := ParseRole(strings.ToLower(value))
+			if err != nil {
+				return nil, ErrUnauthenticated
+			}
+			role = parsed
+		}
+	}
+
+	if role == "" {
+		return nil, ErrUnauthenticated
+	}
+
+	return &Principal{Name: user, Role: role}, nil
+}
+
+func (p *ProxyAuthenticator) fromTrustedProxy(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range p.trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// NoAuthenticator treats every request as an unscoped admin. It is meant
+// for hosts that already authenticate requests before they reach Windmill.
+type NoAuthenticator struct{}
+
+func (NoAuthenticator) Authenticate(*http.Request) (*Principal, error) {
+	return &Principal{Name: "anonymous", Role: RoleAdmin}, nil
+}
+
+func validateGrant(role Role, streams []string) error {
+	if !role.IsValid() {
+		return fmt.Errorf("invalid role %q", role)
+	}
+
+	for _, pattern := range streams {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid stream pattern %q: %w", pattern, err)
+		}
+	}
+
+	return nil
+}
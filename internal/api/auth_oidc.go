@@ -0,0 +1,366 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/go-chi/chi/v5"
+	"golang.org/x/oauth2"
+)
+
+const (
+	sessionCookieName = "windmill_session"
+	oidcStateCookie   = "windmill_oidc_state"
+	oidcStateTTL      = 10 * time.Minute
+)
+
+type oidcSession struct {
+	Name    string `json:"name"`
+	Role    Role   `json:"role"`
+	Expires int64  `json:"exp"`
+}
+
+type oidcState struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	ReturnTo string `json:"return_to"`
+}
+
+// OIDCAuthenticator logs users in through an OpenID Connect provider and
+// keeps them authenticated with a signed session cookie.
+type OIDCAuthenticator struct {
+	config   OIDCConfig
+	signer   cookieSigner
+	basePath string
+	secure   bool
+
+	mu            sync.Mutex
+	provider      *oidc.Provider
+	endSessionURL string
+}
+
+func NewOIDCAuthenticator(config OIDCConfig) *OIDCAuthenticator {
+	if config.GroupsClaim == "" {
+		config.GroupsClaim = "groups"
+	}
+
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"profile", "email", "groups"}
+	}
+
+	if config.SessionTTL == 0 {
+		config.SessionTTL = 8 * time.Hour
+	}
+
+	o := &OIDCAuthenticator{
+		config: config,
+		signer: cookieSigner{key: config.SessionKey},
+	}
+
+	if u, err := url.Parse(config.RedirectURL); err == nil {
+		o.basePath = strings.TrimSuffix(u.Path, "/auth/callback")
+		o.secure = u.Scheme == "https"
+	}
+
+	return o
+}
+
+func (o *OIDCAuthenticator) Validate() error {
+	if o.config.IssuerURL == "" || o.config.ClientID == "" {
+		return errors.New("oidc requires an issuer url and client id")
+	}
+
+	u, err := url.Parse(o.config.RedirectURL)
+	if err != nil || !u.IsAbs() || !strings.HasSuffix(u.Path, "/auth/callback") {
+		return errors.New("oidc redirect url must be an absolute url ending in /auth/callback")
+	}
+
+	if len(o.config.SessionKey) < 32 {
+		return errors.New("oidc session key must be at least 32 bytes")
+	}
+
+	for group, role := range o.config.GroupRoles {
+		if !role.IsValid() {
+			return fmt.Errorf("group %q has invalid role %q", group, role)
+		}
+	}
+
+	if o.config.DefaultRole != "" && !o.config.DefaultRole.IsValid() {
+		return fmt.Errorf("invalid default role %q", o.config.DefaultRole)
+	}
+
+	return nil
+}
+
+func (o *OIDCAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	cookie, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		return nil, ErrUnauthenticated
+	}
+
+	var session oidcSession
+	if err := o.signer.decode(cookie.Value, &session); err != nil {
+		return nil, ErrUnauthenticated
+	}
+
+	if time.Now().Unix() > session.Expires {
+		return nil, ErrUnauthenticated
+	}
+
+	return &Principal{Name: session.Name, Role: session.Role}, nil
+}
+
+// Challenge redirects page loads to the login route and answers API calls
+// with a 401 so the UI can trigger a reload.
+func (o *OIDCAuthenticator) Challenge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet || strings.HasPrefix(strings.TrimPrefix(r.URL.Path, o.basePath), "/api/") {
+		Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// The base path may already have been stripped from the request, but
+	// the browser always sees it.
+	returnTo := r.URL.RequestURI()
+	if !strings.HasPrefix(returnTo, o.basePath+"/") {
+		returnTo = o.basePath + returnTo
+	}
+
+	login := o.basePath + "/auth/login?return_to=" + url.QueryEscape(returnTo)
+	http.Redirect(w, r, login, http.StatusFound)
+}
+
+func (o *OIDCAuthenticator) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/login", o.handleLogin)
+	r.Get("/callback", o.handleCallback)
+	r.Post("/logout", o.handleLogout)
+	return r
+}
+
+func (o *OIDCAuthenticator) handleLogin(w http.ResponseWriter, r *http.Request) {
+	oauthConfig, err := o.oauthConfig(r.Context())
+	if err != nil {
+		Error(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	state := oidcState{
+		State:    randomToken(),
+		Nonce:    randomToken(),
+		Verifier: oauth2.GenerateVerifier(),
+		ReturnTo: o.safeReturnTo(r.URL.Query().Get("return_to")),
+	}
+
+	value, err := o.signer.encode(state)
+	if err != nil {
+		Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	o.setCookie(w, oidcStateCookie, value, oidcStateTTL)
+
+	authURL := oauthConfig.AuthCodeURL(state.State,
+		oauth2.S256ChallengeOption(state.Verifier),
+		oidc.Nonce(state.Nonce),
+	)
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+func (o *OIDCAuthenticator) handleCallback(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil {
+		Error(w, http.StatusBadRequest, "missing login state")
+		return
+	}
+	o.setCookie(w, oidcStateCookie, "", -1)
+
+	var state oidcState
+	if err := o.signer.decode(cookie.Value, &state); err != nil {
+		Error(w, http.StatusBadRequest, "invalid login state")
+		return
+	}
+
+	query := r.URL.Query()
+	if subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(state.State)) != 1 {
+		Error(w, http.StatusBadRequest, "state mismatch")
+		return
+	}
+
+	if errCode := query.Get("error"); errCode != "" {
+		Error(w, http.StatusUnauthorized, fmt.Sprintf("login failed: %s", errCode))
+		return
+	}
+
+	ctx := r.Context()
+	oauthConfig, err := o.oauthConfig(ctx)
+	if err != nil {
+		Error(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	token, err := oauthConfig.Exchange(ctx, query.Get("code"), oauth2.VerifierOption(state.Verifier))
+	if err != nil {
+		Error(w, http.StatusUnauthorized, "failed to exchange code")
+		return
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		Error(w, http.StatusUnauthorized, "missing id token")
+		return
+	}
+
+	idToken, err := o.provider.Verifier(&oidc.Config{ClientID: o.config.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		Error(w, http.StatusUnauthorized, "invalid id token")
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(state.Nonce)) != 1 {
+		Error(w, http.StatusUnauthorized, "nonce mismatch")
+		return
+	}
+
+	var claims map[string]any
+	if err := idToken.Claims(&claims); err != nil {
+		Error(w, http.StatusUnauthorized, "invalid id token claims")
+		return
+	}
+
+	role := o.roleFor(claims)
+	if role == "" {
+		Error(w, http.StatusForbidden, "Forbidden")
+		return
+	}
+
+	session, err := o.signer.encode(oidcSession{
+		Name:    displayName(claims, idToken.Subject),
+		Role:    role,
+		Expires: time.Now().Add(o.config.SessionTTL).Unix(),
+	})
+	if err != nil {
+		Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	o.setCookie(w, sessionCookieName, session, o.config.SessionTTL)
+	http.Redirect(w, r, state.ReturnTo, http.StatusFound)
+}
+
+func (o *OIDCAuthenticator) handleLogout(w http.ResponseWriter, r *http.Request) {
+	o.setCookie(w, sessionCookieName, "", -1)
+
+	target := o.basePath + "/"
+	if _, err := o.oauthConfig(r.Context()); err == nil && o.endSessionURL != "" {
+		target = o.endSessionURL + "?client_id=" + url.QueryEscape(o.config.ClientID)
+	}
+
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// oauthConfig lazily discovers the provider so that an unreachable issuer
+// does not prevent the dashboard from starting.
+func (o *OIDCAuthenticator) oauthConfig(ctx context.Context) (*oauth2.Config, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.provider == nil {
+		provider, err := oidc.NewProvider(ctx, o.config.IssuerURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover oidc provider: %w", err)
+		}
+
+		var metadata struct {
+			EndSessionEndpoint string `json:"end_session_endpoint"`
+		}
+		_ = provider.Claims(&metadata)
+
+		o.provider = provider
+		o.endSessionURL = metadata.EndSessionEndpoint
+	}
+
+	return &oauth2.Config{
+		ClientID:     o.config.ClientID,
+		ClientSecret: o.config.ClientSecret,
+		RedirectURL:  o.config.RedirectURL,
+		Endpoint:     o.provider.Endpoint(),
+		Scopes:       append([]string{oidc.ScopeOpenID}, o.config.Scopes...),
+	}, nil
+}
+
+func (o *OIDCAuthenticator) roleFor(claims map[string]any) Role {
+	role := o.config.DefaultRole
+
+	var groups []string
+	switch v := claims[o.config.GroupsClaim].(type) {
+	case string:
+		groups = []string{v}
+	case []any:
+		for _, g := range v {
+			if s, ok := g.(string); ok {
+				groups = append(groups, s)
+			}
+		}
+	}
+
+	for _, group := range groups {
+		if mapped, ok := o.config.GroupRoles[group]; ok {
+			role = maxRole(role, mapped)
+		}
+	}
+
+	return role
+}
+
+// safeReturnTo only allows redirects back into the dashboard.
+func (o *OIDCAuthenticator) safeReturnTo(returnTo string) string {
+	if strings.HasPrefix(returnTo, o.basePath+"/") && !strings.HasPrefix(returnTo, "//") && !strings.Contains(returnTo, "\\") {
+		return returnTo
+	}
+	return o.basePath + "/"
+}
+
+func (o *OIDCAuthenticator) setCookie(w http.ResponseWriter, name, value string, ttl time.Duration) {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     o.basePath + "/",
+		HttpOnly: true,
+		Secure:   o.secure,
+		SameSite: http.SameSiteLaxMode,
+	}
+
+	if ttl < 0 {
+		cookie.MaxAge = -1
+	} else {
+		cookie.MaxAge = int(ttl.Seconds())
+	}
+
+	http.SetCookie(w, cookie)
+}
+
+func displayName(claims map[string]any, subject string) string {
+	for _, key := range []string{"preferred_username", "email", "name"} {
+		if v, ok := claims[key].(string); ok && v != "" {
+			return v
+		}
+	}
+	return subject
+}
+
+func randomToken() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
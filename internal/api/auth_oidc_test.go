@@ -0,0 +1,192 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeIssuer is a minimal OpenID provider that issues an ID token for a
+// single pre-configured user.
+type fakeIssuer struct {
+	t         *testing.T
+	server    *httptest.Server
+	key       *rsa.PrivateKey
+	groups    []string
+	nonce     string
+	challenge string
+}
+
+func newFakeIssuer(t *testing.T, groups ...string) *fakeIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	f := &fakeIssuer{t: t, key: key, groups: groups}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", f.handleDiscovery)
+	mux.HandleFunc("/keys", f.handleKeys)
+	mux.HandleFunc("/token", f.handleToken)
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+
+	return f
+}
+
+func (f *fakeIssuer) handleDiscovery(w http.ResponseWriter, _ *http.Request) {
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"issuer":                                f.server.URL,
+		"authorization_endpoint":                f.server.URL + "/authorize",
+		"token_endpoint":                        f.server.URL + "/token",
+		"jwks_uri":                              f.server.URL + "/keys",
+		"end_session_endpoint":                  f.server.URL + "/logout",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (f *fakeIssuer) handleKeys(w http.ResponseWriter, _ *http.Request) {
+	_ = json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: &f.key.PublicKey, KeyID: "test", Algorithm: string(jose.RS256), Use: "sig"},
+	}})
+}
+
+func (f *fakeIssuer) handleToken(w http.ResponseWriter, r *http.Request) {
+	require.NoError(f.t, r.ParseForm())
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != f.challenge {
+		http.Error(w, "invalid_grant", http.StatusBadRequest)
+		return
+	}
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: f.key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", "test"),
+	)
+	require.NoError(f.t, err)
+
+	claims, err := json.Marshal(map[string]any{
+		"iss":                f.server.URL,
+		"sub":                "user-1",
+		"aud":                "windmill",
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"iat":                time.Now().Unix(),
+		"nonce":              f.nonce,
+		"preferred_username": "alice",
+		"groups":             f.groups,
+	})
+	require.NoError(f.t, err)
+
+	signed, err := signer.Sign(claims)
+	require.NoError(f.t, err)
+	idToken, err := signed.CompactSerialize()
+	require.NoError(f.t, err)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func newTestOIDCAuthenticator(issuer *fakeIssuer) *OIDCAuthenticator {
+	return NewOIDCAuthenticator(OIDCConfig{
+		IssuerURL:   issuer.server.URL,
+		ClientID:    "windmill",
+		RedirectURL: "http://dashboard.local/windmill/auth/callback",
+		GroupRoles:  map[string]Role{"ops": RoleOperator, "platform": RoleAdmin},
+		SessionKey:  []byte("0123456789abcdef0123456789abcdef"),
+	})
+}
+
+// login runs the authorization code flow and returns the final response.
+func login(t *testing.T, auth *OIDCAuthenticator, issuer *fakeIssuer) *http.Response {
+	routes := auth.Routes()
+
+	rec := httptest.NewRecorder()
+	routes.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/login?return_to=/windmill/dlq", nil))
+	require.Equal(t, http.StatusFound, rec.Code)
+
+	location, err := url.Parse(rec.Header().Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, "S256", location.Query().Get("code_challenge_method"))
+
+	issuer.nonce = location.Query().Get("nonce")
+	issuer.challenge = location.Query().Get("code_challenge")
+
+	callback := httptest.NewRequest(http.MethodGet, "/callback?code=abc&state="+url.QueryEscape(location.Query().Get("state")), nil)
+	for _, c := range rec.Result().Cookies() {
+		callback.AddCookie(c)
+	}
+
+	rec = httptest.NewRecorder()
+	routes.ServeHTTP(rec, callback)
+	return rec.Result()
+}
+
+func TestOIDCAuthenticator_Login(t *testing.T) {
+	issuer := newFakeIssuer(t, "ops", "platform")
+	auth := newTestOIDCAuthenticator(issuer)
+	require.NoError(t, auth.Validate())
+
+	resp := login(t, auth, issuer)
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	require.Equal(t, "/windmill/dlq", resp.Header.Get("Location"))
+
+	req := httptest.NewRequest(http.MethodGet, "/windmill/api/streams", nil)
+	for _, c := range resp.Cookies() {
+		req.AddCookie(c)
+	}
+
+	p, err := auth.Authenticate(req)
+	require.NoError(t, err)
+	require.Equal(t, "alice", p.Name)
+	require.Equal(t, RoleAdmin, p.Role)
+}
+
+func TestOIDCAuthenticator_UnmappedGroup(t *testing.T) {
+	issuer := newFakeIssuer(t, "marketing")
+	auth := newTestOIDCAuthenticator(issuer)
+
+	resp := login(t, auth, issuer)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestOIDCAuthenticator_Challenge(t *testing.T) {
+	auth := newTestOIDCAuthenticator(newFakeIssuer(t))
+
+	rec := httptest.NewRecorder()
+	auth.Challenge(rec, httptest.NewRequest(http.MethodGet, "/windmill/streams", nil))
+	require.Equal(t, http.StatusFound, rec.Code)
+	require.Equal(t, "/windmill/auth/login?return_to=%2Fwindmill%2Fstreams", rec.Header().Get("Location"))
+
+	rec = httptest.NewRecorder()
+	auth.Challenge(rec, httptest.NewRequest(http.MethodGet, "/windmill/api/streams", nil))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestOIDCAuthenticator_Logout(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	auth := newTestOIDCAuthenticator(issuer)
+
+	rec := httptest.NewRecorder()
+	auth.Routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/logout", nil))
+	require.Equal(t, http.StatusFound, rec.Code)
+	require.Equal(t, issuer.server.URL+"/logout?client_id=windmill", rec.Header().Get("Location"))
+
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	require.Equal(t, sessionCookieName, cookies[0].Name)
+	require.Negative(t, cookies[0].MaxAge)
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestBasicAuthenticator(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("hashed-secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	auth := NewBasicAuthenticator([]User{
+		{Username: "plain", Password: "secret", Role: RoleViewer},
+		{Username: "hashed", PasswordHash: string(hash), Role: RoleAdmin},
+	})
+	require.NoError(t, auth.Validate())
+
+	tests := []struct {
+		name     string
+		user     string
+		pass     string
+		wantRole Role
+	}{
+		{"plain password", "plain", "secret", RoleViewer},
+		{"bcrypt hash", "hashed", "hashed-secret", RoleAdmin},
+		{"wrong password", "plain", "nope", ""},
+		{"unknown user", "ghost", "secret", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.SetBasicAuth(tt.user, tt.pass)
+
+			p, err := auth.Authenticate(req)
+			if tt.wantRole == "" {
+				require.ErrorIs(t, err, ErrUnauthenticated)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantRole, p.Role)
+		})
+	}
+}
+
+func TestBasicAuthenticator_UnknownUserHashed(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost+1)
+	require.NoError(t, err)
+
+	auth := NewBasicAuthenticator([]User{{Username: "hashed", PasswordHash: string(hash), Role: RoleAdmin}})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.SetBasicAuth("ghost", "secret")
+	_, err = auth.Authenticate(req)
+	require.ErrorIs(t, err, ErrUnauthenticated)
+
+	// Unknown users are checked against a hash as costly as the users'.
+	cost, err := bcrypt.Cost([]byte(auth.unknownUser().PasswordHash))
+	require.NoError(t, err)
+	require.Equal(t, bcrypt.MinCost+1, cost)
+}
+
+func TestBasicAuthenticator_Validate(t *testing.T) {
+	require.Error(t, NewBasicAuthenticator(nil).Validate())
+	require.Error(t, NewBasicAuthenticator([]User{{Username: "a", Password: "b", Role: "root"}}).Validate())
+	require.Error(t, NewBasicAuthenticator([]User{{Username: "a", PasswordHash: "not-bcrypt", Role: RoleAdmin}}).Validate())
+	require.Error(t, NewBasicAuthenticator([]User{{Username: "a", Password: "b", Role: RoleAdmin, Streams: []string{"["}}}).Validate())
+}
+
+func TestTokenAuthenticator(t *testing.T) {
+	auth := NewTokenAuthenticator([]Token{{Name: "ci", Token: "t0ken", Role: RoleOperator}})
+	require.NoError(t, auth.Validate())
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer t0ken")
+	p, err := auth.Authenticate(req)
+	require.NoError(t, err)
+	require.Equal(t, "ci", p.Name)
+
+	req.Header.Set("Authorization", "Bearer wrong")
+	_, err = auth.Authenticate(req)
+	require.ErrorIs(t, err, ErrUnauthenticated)
+}
+
+func TestProxyAuthenticator(t *testing.T) {
+	auth := NewProxyAuthenticator(ProxyAuthConfig{
+		RoleHeader:     "X-Forwarded-Role",
+		DefaultRole:    RoleViewer,
+		TrustedProxies: []string{"10.0.0.0/8"},
+	})
+	require.NoError(t, auth.Validate())
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.1.2.3:5000"
+	req.Header.Set("X-Forwarded-User", "alice")
+
+	p, err := auth.Authenticate(req)
+	require.NoError(t, err)
+	require.Equal(t, "alice", p.Name)
+	require.Equal(t, RoleViewer, p.Role)
+
+	req.Header.Set("X-Forwarded-Role", "Operator")
+	p, err = auth.Authenticate(req)
+	require.NoError(t, err)
+	require.Equal(t, RoleOperator, p.Role)
+
+	req.RemoteAddr = "192.168.1.1:5000"
+	_, err = auth.Authenticate(req)
+	require.ErrorIs(t, err, ErrUnauthenticated)
+}
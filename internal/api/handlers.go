@@ -2,17 +2,38 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
 	"github.com/scmofeoluwa/windmill/internal/monitor"
 )
 
+func (a *API) handleGetCapabilities(w http.ResponseWriter, r *http.Request) {
+	principal := PrincipalFromContext(r.Context())
+	writable := !a.config.ReadOnly
+
+	JSON(w, http.StatusOK, Capabilities{
+		ReadOnly:      a.config.ReadOnly,
+		User:          principal.Name,
+		Role:          principal.Role,
+		Streams:       principal.Streams,
+		CanRequeue:    writable && principal.Can(PermissionRequeue),
+		CanDelete:     writable && principal.Can(PermissionDelete),
+		CanRequeueAll: writable && principal.Can(PermissionRequeueAll) && principal.Unscoped(),
+		CanPublish:    writable && principal.Can(PermissionPublish),
+		CanPurge:      writable && principal.Can(PermissionPurge) && principal.Unscoped(),
+	})
+}
+
 func (a *API) handleGetOverview(w http.ResponseWriter, r *http.Request) {
-	overview, err := a.monitor.GetOverview(r.Context())
+	overview, err := a.monitor(r).GetOverview(r.Context(), overviewOpts(r))
 	if err != nil {
 		Error(w, http.StatusInternalServerError, err.Error())
 		return
@@ -21,19 +42,128 @@ func (a *API) handleGetOverview(w http.ResponseWriter, r *http.Request) {
 	JSON(w, http.StatusOK, overview)
 }
 
+func (a *API) handleGetLeader(w http.ResponseWriter, r *http.Request) {
+	status, err := a.monitor(r).Leader().Status(r.Context())
+	if err != nil {
+		Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	JSON(w, http.StatusOK, status)
+}
+
+func (a *API) handleGetDiagnostics(w http.ResponseWriter, r *http.Request) {
+	caps := a.monitor(r).Capabilities()
+
+	// Redis may have been unreachable when Windmill started.
+	if caps.ProbedAt == nil {
+		probed, err := a.monitor(r).ProbeCapabilities(r.Context())
+		if err != nil {
+			Error(w, http.StatusBadGateway, "failed to probe redis: "+err.Error())
+			return
+		}
+		caps = *probed
+	}
+
+	JSON(w, http.StatusOK, Diagnostics{Redis: caps})
+}
+
+func (a *API) handleGetRedisInfo(w http.ResponseWriter, r *http.Request) {
+	info, err := a.monitor(r).Server().GetInfo(r.Context())
+	if err != nil {
+		Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Scoped principals only see slow commands on streams they can access.
+	if principal := PrincipalFromContext(r.Context()); !principal.Unscoped() {
+		for i := range info.Nodes {
+			visible := info.Nodes[i].SlowLog[:0]
+			for _, entry := range info.Nodes[i].SlowLog {
+				if entry.Key != "" && principal.CanAccessStream(entry.Key) {
+					visible = append(visible, entry)
+				}
+			}
+			info.Nodes[i].SlowLog = visible
+		}
+	}
+
+	JSON(w, http.StatusOK, info)
+}
+
+// handleGetConsumerHealth reports the lag and stuck consumers of every
+// consumer group of the streams the principal may read. The idle query
+// parameter overrides how long a consumer with pending entries may stay
+// idle.
+func (a *API) handleGetConsumerHealth(w http.ResponseWriter, r *http.Request) {
+	opts := monitor.ConsumerHealthOpts{Allow: PrincipalFromContext(r.Context()).CanAccessStream}
+
+	if idleStr := r.URL.Query().Get("idle"); idleStr != "" {
+		idle, err := time.ParseDuration(idleStr)
+		if err != nil || idle <= 0 {
+			Error(w, http.StatusBadRequest, "invalid idle")
+			return
+		}
+		opts.IdleThreshold = idle
+	}
+
+	health, err := a.monitor(r).Consumers().GetHealth(r.Context(), opts)
+	if err != nil {
+		Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	JSON(w, http.StatusOK, health)
+}
+
+// handleGetTopology serves the topology graph. A principal scoped to some
+// streams only sees those topics and the handlers consuming them.
+func (a *API) handleGetTopology(w http.ResponseWriter, r *http.Request) {
+	var opts monitor.TopologyOpts
+	if principal := PrincipalFromContext(r.Context()); !principal.Unscoped() {
+		opts.Allow = principal.CanAccessStream
+	}
+
+	topology, err := a.monitor(r).Topology().GetTopology(r.Context(), opts)
+	if err != nil {
+		Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	JSON(w, http.StatusOK, topology)
+}
+
 func (a *API) handleGetStreams(w http.ResponseWriter, r *http.Request) {
-	streams, err := a.monitor.Streams().GetStreams(r.Context())
+	opts, err := parseStreamListOpts(r)
+	if err != nil {
+		Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	opts.Allow = PrincipalFromContext(r.Context()).CanAccessStream
+
+	list, err := a.monitor(r).Streams().ListStreams(r.Context(), opts)
 	if err != nil {
 		Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	JSON(w, http.StatusOK, streams)
+	JSON(w, http.StatusOK, list)
 }
 
 func (a *API) handleGetStream(w http.ResponseWriter, r *http.Request) {
 	name := chi.URLParam(r, "name")
-	stream, err := a.monitor.Streams().GetStreamDetail(r.Context(), name)
+	full, count, err := parseStreamDetailOpts(r)
+	if err != nil {
+		Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var stream *monitor.StreamDetail
+	if full {
+		stream, err = a.monitor(r).Streams().GetStreamDetailFull(r.Context(), name, count)
+	} else {
+		stream, err = a.monitor(r).Streams().GetStreamDetail(r.Context(), name)
+	}
 	if err != nil {
 		Error(w, http.StatusInternalServerError, err.Error())
 		return
@@ -55,7 +185,7 @@ func (a *API) handleGetStreamMessages(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	messages, err := a.monitor.Streams().GetStreamMessages(r.Context(), name, opts)
+	messages, err := a.monitor(r).Streams().GetStreamMessages(r.Context(), name, opts)
 	if err != nil {
 		Error(w, http.StatusInternalServerError, err.Error())
 		return
@@ -68,7 +198,7 @@ func (a *API) handleGetStreamMessage(w http.ResponseWriter, r *http.Request) {
 	name := chi.URLParam(r, "name")
 	id := chi.URLParam(r, "id")
 
-	message, err := a.monitor.Streams().GetMessage(r.Context(), name, id)
+	message, err := a.monitor(r).Streams().GetMessage(r.Context(), name, id)
 	if err != nil {
 		Error(w, http.StatusInternalServerError, err.Error())
 		return
@@ -82,11 +212,67 @@ func (a *API) handleGetStreamMessage(w http.ResponseWriter, r *http.Request) {
 	JSON(w, http.StatusOK, message)
 }
 
+func (a *API) handlePublishMessage(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	var req PublishRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.Payload == nil {
+		Error(w, http.StatusBadRequest, "payload is required")
+		return
+	}
+
+	message, err := a.monitor(r).Streams().Publish(r.Context(), name, req.Payload, req.Metadata)
+	if err != nil {
+		Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	JSON(w, http.StatusCreated, message)
+}
+
+func (a *API) handleTrimStream(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	var req TrimRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := a.monitor(r).Streams().Trim(r.Context(), name, monitor.TrimOpts{
+		Strategy:    req.Strategy,
+		MaxLen:      req.MaxLen,
+		MinID:       req.MinID,
+		Approximate: req.Approximate,
+		DryRun:      req.DryRun,
+	})
+
+	var pending *monitor.PendingEntriesError
+	switch {
+	case errors.As(err, &pending):
+		Error(w, http.StatusConflict, err.Error())
+		return
+	case errors.Is(err, monitor.ErrInvalidTrimOpts):
+		Error(w, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	JSON(w, http.StatusOK, result)
+}
+
 func (a *API) handleDeleteMessage(w http.ResponseWriter, r *http.Request) {
 	name := chi.URLParam(r, "name")
 	id := chi.URLParam(r, "id")
 
-	if err := a.monitor.Streams().DeleteMessage(r.Context(), name, id); err != nil {
+	if err := a.monitor(r).Streams().DeleteMessage(r.Context(), name, id); err != nil {
 		Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -94,8 +280,32 @@ func (a *API) handleDeleteMessage(w http.ResponseWriter, r *http.Request) {
 	NoContent(w)
 }
 
+func (a *API) handleExportStream(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	format, opts, err := parseExportOpts(r)
+	if err != nil {
+		Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeExportHeaders(w, name, format)
+	// Headers are already sent, so a failure is logged and the export ends
+	// with its error marker.
+	if err := a.monitor(r).Streams().Export(r.Context(), name, format, opts, w); err != nil {
+		a.config.Logger.Error("stream export failed", "stream", name, "error", err)
+	}
+}
+
 func (a *API) handleGetDLQStats(w http.ResponseWriter, r *http.Request) {
-	stats, err := a.monitor.DLQ().GetStats(r.Context())
+	var (
+		stats *monitor.StreamInfo
+		err   error
+	)
+	if principal := PrincipalFromContext(r.Context()); principal.Unscoped() {
+		stats, err = a.monitor(r).DLQ().GetStats(r.Context())
+	} else {
+		stats, err = a.monitor(r).DLQ().GetAllowedStats(r.Context(), principal.CanAccessStream)
+	}
 	if err != nil {
 		Error(w, http.StatusInternalServerError, err.Error())
 		return
@@ -111,7 +321,12 @@ func (a *API) handleGetDLQMessages(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	messages, err := a.monitor.DLQ().GetMessages(r.Context(), opts)
+	var messages *monitor.MessageList[monitor.DLQMessage]
+	if principal := PrincipalFromContext(r.Context()); principal.Unscoped() {
+		messages, err = a.monitor(r).DLQ().GetMessages(r.Context(), opts)
+	} else {
+		messages, err = a.monitor(r).DLQ().GetAllowedMessages(r.Context(), opts, principal.CanAccessStream)
+	}
 	if err != nil {
 		Error(w, http.StatusInternalServerError, err.Error())
 		return
@@ -120,10 +335,59 @@ func (a *API) handleGetDLQMessages(w http.ResponseWriter, r *http.Request) {
 	JSON(w, http.StatusOK, messages)
 }
 
+func (a *API) handleExportDLQ(w http.ResponseWriter, r *http.Request) {
+	format, opts, err := parseExportOpts(r)
+	if err != nil {
+		Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	principal := PrincipalFromContext(r.Context())
+	topic := r.URL.Query().Get("topic")
+	opts.Filter = func(record monitor.ExportRecord) bool {
+		original := record.Metadata[monitor.TopicPoisonedKey]
+		return (topic == "" || original == topic) && principal.CanAccessStream(original)
+	}
+
+	writeExportHeaders(w, "dlq", format)
+	if err := a.monitor(r).DLQ().Export(r.Context(), format, opts, w); err != nil {
+		a.config.Logger.Error("DLQ export failed", "dlq", a.monitor(r).DLQ().Name(), "error", err)
+	}
+}
+
+func (a *API) handleReplay(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseReplayOpts(r)
+	if err != nil {
+		Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	principal := PrincipalFromContext(r.Context())
+	if opts.Target != "" && !principal.CanAccessStream(opts.Target) {
+		Error(w, http.StatusForbidden, "Forbidden")
+		return
+	}
+	opts.Allow = principal.CanAccessStream
+
+	body, err := replayBody(w, r)
+	if err != nil {
+		Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := a.monitor(r).Streams().Replay(r.Context(), body, opts)
+	if err != nil {
+		Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	JSON(w, http.StatusOK, result)
+}
+
 func (a *API) handleGetDLQMessage(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
-	message, err := a.monitor.DLQ().GetMessage(r.Context(), id)
+	message, err := a.monitor(r).DLQ().GetMessage(r.Context(), id)
 	if err != nil {
 		Error(w, http.StatusInternalServerError, err.Error())
 		return
@@ -134,6 +398,11 @@ func (a *API) handleGetDLQMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !PrincipalFromContext(r.Context()).CanAccessStream(message.OriginalTopic) {
+		Error(w, http.StatusForbidden, "Forbidden")
+		return
+	}
+
 	JSON(w, http.StatusOK, message)
 }
 
@@ -141,6 +410,10 @@ func (a *API) handleRequeueMessage(w http.ResponseWriter, r *http.Request) {
 	var payload map[string]any
 	id := chi.URLParam(r, "id")
 
+	if !a.authorizeDLQMessage(w, r, id) {
+		return
+	}
+
 	if r.ContentLength > 0 {
 		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 			Error(w, http.StatusBadRequest, err.Error())
@@ -148,7 +421,7 @@ func (a *API) handleRequeueMessage(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if err := a.monitor.DLQ().RequeueMessage(r.Context(), id, payload); err != nil {
+	if err := a.monitor(r).DLQ().RequeueMessage(r.Context(), id, payload); err != nil {
 		Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -156,8 +429,94 @@ func (a *API) handleRequeueMessage(w http.ResponseWriter, r *http.Request) {
 	JSON(w, http.StatusOK, nil)
 }
 
+func (a *API) handleScheduleRequeue(w http.ResponseWriter, r *http.Request) {
+	var req ScheduleRequest
+	id := chi.URLParam(r, "id")
+
+	if !a.authorizeDLQMessage(w, r, id) {
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var at time.Time
+	switch {
+	case req.At != nil && req.Delay != "":
+		Error(w, http.StatusBadRequest, "at and delay are mutually exclusive")
+		return
+	case req.At != nil:
+		at = *req.At
+	case req.Delay != "":
+		delay, err := time.ParseDuration(req.Delay)
+		if err != nil || delay < 0 {
+			Error(w, http.StatusBadRequest, "invalid delay")
+			return
+		}
+		at = time.Now().Add(delay)
+	default:
+		Error(w, http.StatusBadRequest, "at or delay is required")
+		return
+	}
+
+	scheduled, err := a.monitor(r).DLQ().ScheduleRequeue(r.Context(), id, at, req.Payload)
+	if err != nil {
+		Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	JSON(w, http.StatusCreated, scheduled)
+}
+
+func (a *API) handleCancelRequeue(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if !a.authorizeDLQMessage(w, r, id) {
+		return
+	}
+
+	cancelled, err := a.monitor(r).DLQ().CancelRequeue(r.Context(), id)
+	if err != nil {
+		Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if !cancelled {
+		Error(w, http.StatusNotFound, "requeue not scheduled")
+		return
+	}
+
+	NoContent(w)
+}
+
+func (a *API) handleGetScheduledRequeues(w http.ResponseWriter, r *http.Request) {
+	opts, err := parsePaginationOpts(r)
+	if err != nil {
+		Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if r.URL.Query().Get("order") == "" {
+		opts.Order = monitor.SortOrderAsc
+	}
+
+	var allow func(topic string) bool
+	if principal := PrincipalFromContext(r.Context()); !principal.Unscoped() {
+		allow = principal.CanAccessStream
+	}
+
+	scheduled, err := a.monitor(r).DLQ().ScheduledRequeues(r.Context(), opts, allow)
+	if err != nil {
+		Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	JSON(w, http.StatusOK, scheduled)
+}
+
 func (a *API) handleRequeueAll(w http.ResponseWriter, r *http.Request) {
-	count, err := a.monitor.DLQ().RequeueAll(r.Context())
+	count, err := a.monitor(r).DLQ().RequeueAll(r.Context())
 	if err != nil {
 		Error(w, http.StatusInternalServerError, err.Error())
 		return
@@ -166,10 +525,74 @@ func (a *API) handleRequeueAll(w http.ResponseWriter, r *http.Request) {
 	JSON(w, http.StatusOK, map[string]int64{"requeued": count})
 }
 
+func (a *API) handlePurgeDLQ(w http.ResponseWriter, r *http.Request) {
+	var req PurgeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	opts := monitor.PurgeOpts{
+		Topic:         req.Topic,
+		ErrorContains: req.ErrorContains,
+		Confirm:       req.Confirm,
+		DryRun:        req.DryRun,
+	}
+	if req.Before != nil {
+		opts.Before = *req.Before
+	}
+
+	if req.Export == "" || req.DryRun {
+		result, err := a.monitor(r).DLQ().Purge(r.Context(), opts)
+		switch {
+		case errors.Is(err, monitor.ErrPurgeNotConfirmed):
+			Error(w, http.StatusBadRequest, err.Error())
+		case err != nil:
+			Error(w, http.StatusInternalServerError, err.Error())
+		default:
+			JSON(w, http.StatusOK, result)
+		}
+		return
+	}
+
+	// The confirmation is checked up front so a mistyped token fails with
+	// an error rather than an empty download.
+	if req.Confirm != a.monitor(r).DLQ().Name() {
+		Error(w, http.StatusBadRequest, monitor.ErrPurgeNotConfirmed.Error())
+		return
+	}
+
+	opts.Export, opts.ExportFormat = w, req.Export
+	w.Header().Set("Trailer", "Windmill-Purge-Result")
+	writeExportHeaders(w, "dlq-purge", req.Export)
+
+	result, err := a.monitor(r).DLQ().Purge(r.Context(), opts)
+	if result == nil {
+		result = &monitor.PurgeResult{DLQ: a.monitor(r).DLQ().Name()}
+	}
+	if summary, merr := json.Marshal(struct {
+		*monitor.PurgeResult
+		Error string `json:"error,omitempty"`
+	}{result, errorString(err)}); merr == nil {
+		w.Header().Set("Windmill-Purge-Result", string(summary))
+	}
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
 func (a *API) handleDeleteDLQMessage(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
-	if err := a.monitor.DLQ().DeleteMessage(r.Context(), id); err != nil {
+	if !a.authorizeDLQMessage(w, r, id) {
+		return
+	}
+
+	if err := a.monitor(r).DLQ().DeleteMessage(r.Context(), id); err != nil {
 		Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -177,6 +600,208 @@ func (a *API) handleDeleteDLQMessage(w http.ResponseWriter, r *http.Request) {
 	NoContent(w)
 }
 
+// authorizeDLQMessage checks that a scoped principal may act on the DLQ
+// message id, based on the topic it was poisoned from. It writes the error
+// response and returns false when the request must not proceed.
+func (a *API) authorizeDLQMessage(w http.ResponseWriter, r *http.Request, id string) bool {
+	principal := PrincipalFromContext(r.Context())
+	if principal.Unscoped() {
+		return true
+	}
+
+	message, err := a.monitor(r).DLQ().GetMessage(r.Context(), id)
+	if err != nil {
+		Error(w, http.StatusInternalServerError, err.Error())
+		return false
+	}
+
+	if message == nil {
+		Error(w, http.StatusNotFound, "message not found")
+		return false
+	}
+
+	if !principal.CanAccessStream(message.OriginalTopic) {
+		Error(w, http.StatusForbidden, "Forbidden")
+		return false
+	}
+
+	return true
+}
+
+// overviewOpts limits an overview to the streams a scoped principal may
+// access.
+func overviewOpts(r *http.Request) monitor.OverviewOpts {
+	var opts monitor.OverviewOpts
+	if principal := PrincipalFromContext(r.Context()); !principal.Unscoped() {
+		opts.Allow = principal.CanAccessStream
+	}
+	return opts
+}
+
+func parseExportOpts(r *http.Request) (monitor.ExportFormat, monitor.ExportOpts, error) {
+	opts := monitor.ExportOpts{
+		Order:  monitor.SortOrderAsc,
+		Cursor: r.URL.Query().Get("cursor"),
+	}
+
+	format := monitor.ExportFormatNdjson
+	if formatStr := r.URL.Query().Get("format"); formatStr != "" {
+		parsed, err := monitor.ParseExportFormat(formatStr)
+		if err != nil {
+			return format, opts, fmt.Errorf("invalid format")
+		}
+		format = parsed
+	}
+
+	if orderStr := r.URL.Query().Get("order"); orderStr != "" {
+		order, err := monitor.ParseSortOrder(orderStr)
+		if err != nil {
+			return format, opts, fmt.Errorf("invalid order")
+		}
+		opts.Order = order
+	}
+
+	return format, opts, nil
+}
+
+func parseReplayOpts(r *http.Request) (monitor.ReplayOpts, error) {
+	query := r.URL.Query()
+	opts := monitor.ReplayOpts{Target: query.Get("target")}
+
+	if dryRun := query.Get("dry_run"); dryRun != "" {
+		parsed, err := strconv.ParseBool(dryRun)
+		if err != nil {
+			return opts, fmt.Errorf("invalid dry_run")
+		}
+		opts.DryRun = parsed
+	}
+
+	if rateStr := query.Get("rate"); rateStr != "" {
+		rate, err := strconv.ParseFloat(rateStr, 64)
+		if err != nil || rate < 0 {
+			return opts, fmt.Errorf("invalid rate")
+		}
+		opts.Rate = rate
+	}
+
+	return opts, nil
+}
+
+// replayBody returns the NDJSON records of a replay request, read either
+// from the "file" field of a multipart upload or from the raw body.
+func replayBody(w http.ResponseWriter, r *http.Request) (io.Reader, error) {
+	const MaxBodySize = 256 << 20
+	r.Body = http.MaxBytesReader(w, r.Body, MaxBodySize)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return r.Body, nil
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, fmt.Errorf("missing file")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == "file" {
+			return part, nil
+		}
+	}
+}
+
+func writeExportHeaders(w http.ResponseWriter, name string, format monitor.ExportFormat) {
+	contentType, ext := "application/x-ndjson", "ndjson"
+	switch format {
+	case monitor.ExportFormatCsv:
+		contentType, ext = "text/csv; charset=utf-8", "csv"
+	case monitor.ExportFormatArchive:
+		contentType, ext = "application/gzip", "json.gz"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": name + "." + ext,
+	}))
+	w.WriteHeader(http.StatusOK)
+}
+
+// parseStreamDetailOpts reads whether the full stream detail is asked for
+// and how many entries of each pending entries list it may include.
+func parseStreamDetailOpts(r *http.Request) (bool, int, error) {
+	const DefaultCount, MaxCount = 10, 1000
+	query := r.URL.Query()
+
+	full := false
+	if fullStr := query.Get("full"); fullStr != "" {
+		parsed, err := strconv.ParseBool(fullStr)
+		if err != nil {
+			return false, 0, fmt.Errorf("invalid full")
+		}
+		full = parsed
+	}
+
+	count := DefaultCount
+	if countStr := query.Get("count"); countStr != "" {
+		parsed, err := strconv.Atoi(countStr)
+		if err != nil || parsed < 1 {
+			return false, 0, fmt.Errorf("invalid count")
+		}
+		count = min(parsed, MaxCount)
+	}
+
+	return full, count, nil
+}
+
+func parseStreamListOpts(r *http.Request) (monitor.StreamListOpts, error) {
+	const MaxLimit = 500
+	query := r.URL.Query()
+
+	opts := monitor.StreamListOpts{
+		Query:  query.Get("q"),
+		Cursor: query.Get("cursor"),
+	}
+
+	if cursorStr := opts.Cursor; cursorStr != "" {
+		if n, err := strconv.Atoi(cursorStr); err != nil || n < 0 {
+			return opts, fmt.Errorf("invalid cursor")
+		}
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || limit < 0 {
+			return opts, fmt.Errorf("invalid limit")
+		}
+		opts.Limit = min(limit, MaxLimit)
+	}
+
+	if sortStr := query.Get("sort"); sortStr != "" {
+		sort, err := monitor.ParseStreamSort(sortStr)
+		if err != nil {
+			return opts, fmt.Errorf("invalid sort")
+		}
+		opts.Sort = sort
+	}
+
+	if orderStr := query.Get("order"); orderStr != "" {
+		order, err := monitor.ParseSortOrder(orderStr)
+		if err != nil {
+			return opts, fmt.Errorf("invalid order")
+		}
+		opts.Order = order
+	}
+
+	return opts, nil
+}
+
 func parsePaginationOpts(r *http.Request) (monitor.PaginationOpts, error) {
 	const MaxLimit = 100
 	var opts monitor.PaginationOpts
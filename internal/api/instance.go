@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/scmofeoluwa/windmill/internal/monitor"
+)
+
+// DefaultInstance names the only instance of an API built with New.
+const DefaultInstance = "default"
+
+// Instance is a Redis deployment served under /api/instances/{name}.
+type Instance struct {
+	Name    string
+	Monitor *monitor.Monitor
+}
+
+type instanceKey struct{}
+
+// useInstance selects the instance named by the {instance} URL parameter
+// for the handlers below it.
+func (a *API) useInstance(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		instance, ok := a.instances[chi.URLParam(r, "instance")]
+		if !ok {
+			Error(w, http.StatusNotFound, "instance not found")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), instanceKey{}, instance)))
+	})
+}
+
+// useDefaultInstance selects the first instance, so the unscoped /api
+// routes keep working for single-instance clients.
+func (a *API) useDefaultInstance(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		instance := a.instances[a.order[0]]
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), instanceKey{}, instance)))
+	})
+}
+
+// monitor returns the monitor of the instance selected for r.
+func (a *API) monitor(r *http.Request) *monitor.Monitor {
+	return r.Context().Value(instanceKey{}).(*Instance).Monitor
+}
+
+func (a *API) handleGetInstances(w http.ResponseWriter, r *http.Request) {
+	overviews := make([]InstanceOverview, len(a.order))
+
+	var wg sync.WaitGroup
+	for i, name := range a.order {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			instance := a.instances[name]
+			overviews[i] = InstanceOverview{Name: name, DLQ: instance.Monitor.DLQ().Name()}
+
+			overview, err := instance.Monitor.GetOverview(r.Context(), overviewOpts(r))
+			if err != nil {
+				overviews[i].Error = err.Error()
+				return
+			}
+			overviews[i].Overview = overview
+		}()
+	}
+	wg.Wait()
+
+	// Instances that failed are left out of the totals but still listed.
+	resp := InstancesOverview{Instances: overviews}
+	for _, o := range overviews {
+		if o.Overview == nil {
+			continue
+		}
+		resp.Total.TotalStreams += o.Overview.TotalStreams
+		resp.Total.TotalMessages += o.Overview.TotalMessages
+		resp.Total.TotalDLQMessages += o.Overview.TotalDLQMessages
+	}
+
+	JSON(w, http.StatusOK, resp)
+}
@@ -2,29 +2,119 @@ package api
 
 import (
 	"crypto/subtle"
+	"errors"
 	"net/http"
-	"os"
+	"net/url"
+	"strings"
 )
 
-func BasicAuth() func(http.Handler) http.Handler {
-	username := os.Getenv("WINDMILL_USERNAME")
-	password := os.Getenv("WINDMILL_PASSWORD")
-
+// Authenticate resolves the caller of every request with auth and stores
+// the resulting Principal in the request context.
+func Authenticate(auth Authenticator) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			user, pass, ok := r.BasicAuth()
-			if !ok {
-				w.Header().Set("WWW-Authenticate", `Basic realm="Windmill"`)
+			// An authenticator returning no principal and no error is
+			// treated as rejecting the request.
+			principal, err := auth.Authenticate(r)
+			if errors.Is(err, ErrUnauthenticated) || (err == nil && principal == nil) {
+				if c, ok := auth.(Challenger); ok {
+					c.Challenge(w, r)
+					return
+				}
+
 				Error(w, http.StatusUnauthorized, "Unauthorized")
 				return
 			}
 
-			userMatch := subtle.ConstantTimeCompare([]byte(user), []byte(username)) == 1
-			passwordMatch := subtle.ConstantTimeCompare([]byte(pass), []byte(password)) == 1
+			if err != nil {
+				Error(w, http.StatusInternalServerError, err.Error())
+				return
+			}
 
-			if !userMatch || !passwordMatch {
-				w.Header().Set("WWW-Authenticate", `Basic realm="Windmill"`)
-				Error(w, http.StatusUnauthorized, "Unauthorized")
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+// StripBasePath removes basePath from the request path when present. This
+// lets the dashboard be served both by routers that forward the full path
+// and by proxies that rewrite the prefix away before forwarding.
+func StripBasePath(basePath string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if basePath == "" {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != basePath && !strings.HasPrefix(r.URL.Path, basePath+"/") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			r2 := r.Clone(r.Context())
+			r2.URL.Path = strings.TrimPrefix(r.URL.Path, basePath)
+			r2.URL.RawPath = strings.TrimPrefix(r.URL.RawPath, basePath)
+			if r2.URL.Path == "" {
+				r2.URL.Path = "/"
+			}
+
+			next.ServeHTTP(w, r2)
+		})
+	}
+}
+
+const (
+	csrfCookieName = "windmill_csrf"
+	csrfHeaderName = "X-CSRF-Token"
+)
+
+// CSRF protects mutating requests against cross-site request forgery. It
+// issues a random token in a cookie that the UI echoes back in the
+// X-CSRF-Token header (double-submit), and rejects requests from origins
+// other than the dashboard's own or allowedOrigins.
+//
+// Requests with a bearer token and requests without any browser origin
+// signal (scripts using Basic Auth) carry no ambient credentials a foreign
+// page could abuse, so they are exempt from the token check.
+func CSRF(allowedOrigins []string) func(http.Handler) http.Handler {
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		allowed[strings.TrimSuffix(origin, "/")] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var token string
+			if cookie, err := r.Cookie(csrfCookieName); err == nil && cookie.Value != "" {
+				token = cookie.Value
+			} else {
+				token = randomToken()
+				http.SetCookie(w, &http.Cookie{
+					Name:     csrfCookieName,
+					Value:    token,
+					Path:     "/",
+					Secure:   isTLS(r),
+					SameSite: http.SameSiteStrictMode,
+				})
+			}
+
+			if isSafeMethod(r.Method) || strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !fromBrowser(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !sameOrigin(r, allowed) {
+				Error(w, http.StatusForbidden, "origin not allowed")
+				return
+			}
+
+			if subtle.ConstantTimeCompare([]byte(r.Header.Get(csrfHeaderName)), []byte(token)) != 1 {
+				Error(w, http.StatusForbidden, "invalid csrf token")
 				return
 			}
 
@@ -32,3 +122,85 @@ func BasicAuth() func(http.Handler) http.Handler {
 		})
 	}
 }
+
+// SecurityHeaders sets a restrictive content security policy and related
+// headers on every response. frameAncestors lists the origins allowed to
+// embed the dashboard; by default it cannot be framed.
+func SecurityHeaders(frameAncestors []string) func(http.Handler) http.Handler {
+	ancestors := "'none'"
+	if len(frameAncestors) > 0 {
+		ancestors = strings.Join(frameAncestors, " ")
+	}
+
+	csp := strings.Join([]string{
+		"default-src 'self'",
+		"script-src 'self'",
+		"style-src 'self' 'unsafe-inline'",
+		"img-src 'self' data:",
+		"connect-src 'self'",
+		"object-src 'none'",
+		"base-uri 'self'",
+		"form-action 'self'",
+		"frame-ancestors " + ancestors,
+	}, "; ")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("Content-Security-Policy", csp)
+			h.Set("X-Content-Type-Options", "nosniff")
+			h.Set("Referrer-Policy", "same-origin")
+			if len(frameAncestors) == 0 {
+				h.Set("X-Frame-Options", "DENY")
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+func isTLS(r *http.Request) bool {
+	return r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+}
+
+func fromBrowser(r *http.Request) bool {
+	return r.Header.Get("Origin") != "" || r.Header.Get("Sec-Fetch-Site") != "" || r.Header.Get("Referer") != ""
+}
+
+func sameOrigin(r *http.Request, allowed map[string]bool) bool {
+	if site := r.Header.Get("Sec-Fetch-Site"); site == "same-origin" || site == "none" {
+		return true
+	}
+
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		if ref, err := url.Parse(r.Header.Get("Referer")); err == nil && ref.Host != "" {
+			origin = ref.Scheme + "://" + ref.Host
+		}
+	}
+
+	if allowed[origin] {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+
+	host := r.Host
+	if forwarded := r.Header.Get("X-Forwarded-Host"); forwarded != "" {
+		host = forwarded
+	}
+
+	return u.Host == host
+}
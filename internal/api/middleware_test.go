@@ -0,0 +1,142 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSRF(t *testing.T) {
+	handler := CSRF([]string{"https://portal.example.com"})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	withToken := func(req *http.Request, token string) *http.Request {
+		req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: "tok"})
+		if token != "" {
+			req.Header.Set(csrfHeaderName, token)
+		}
+		return req
+	}
+
+	tests := []struct {
+		name   string
+		req    func() *http.Request
+		status int
+	}{
+		{
+			name:   "safe method",
+			req:    func() *http.Request { return httptest.NewRequest(http.MethodGet, "/api/streams", nil) },
+			status: http.StatusNoContent,
+		},
+		{
+			name: "same origin with token",
+			req: func() *http.Request {
+				req := withToken(httptest.NewRequest(http.MethodPost, "http://dash.local/api/dlq/requeue-all", nil), "tok")
+				req.Header.Set("Origin", "http://dash.local")
+				return req
+			},
+			status: http.StatusNoContent,
+		},
+		{
+			name: "same origin without token",
+			req: func() *http.Request {
+				req := withToken(httptest.NewRequest(http.MethodPost, "http://dash.local/api/dlq/requeue-all", nil), "")
+				req.Header.Set("Origin", "http://dash.local")
+				return req
+			},
+			status: http.StatusForbidden,
+		},
+		{
+			name: "cross origin",
+			req: func() *http.Request {
+				req := withToken(httptest.NewRequest(http.MethodPost, "http://dash.local/api/dlq/requeue-all", nil), "tok")
+				req.Header.Set("Origin", "https://evil.example.com")
+				return req
+			},
+			status: http.StatusForbidden,
+		},
+		{
+			name: "allowed origin",
+			req: func() *http.Request {
+				req := withToken(httptest.NewRequest(http.MethodDelete, "http://dash.local/api/dlq/messages/1-0", nil), "tok")
+				req.Header.Set("Origin", "https://portal.example.com")
+				return req
+			},
+			status: http.StatusNoContent,
+		},
+		{
+			name: "bearer token",
+			req: func() *http.Request {
+				req := httptest.NewRequest(http.MethodPost, "http://dash.local/api/dlq/requeue-all", nil)
+				req.Header.Set("Authorization", "Bearer abc")
+				req.Header.Set("Origin", "https://evil.example.com")
+				return req
+			},
+			status: http.StatusNoContent,
+		},
+		{
+			name: "non-browser client",
+			req: func() *http.Request {
+				req := httptest.NewRequest(http.MethodPost, "http://dash.local/api/dlq/requeue-all", nil)
+				req.SetBasicAuth("admin", "secret")
+				return req
+			},
+			status: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, tt.req())
+			require.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
+
+func TestCSRF_IssuesCookie(t *testing.T) {
+	handler := CSRF(nil)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	require.Equal(t, csrfCookieName, cookies[0].Name)
+	require.NotEmpty(t, cookies[0].Value)
+	require.Equal(t, http.SameSiteStrictMode, cookies[0].SameSite)
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	noop := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	SecurityHeaders(nil)(noop).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Contains(t, rec.Header().Get("Content-Security-Policy"), "frame-ancestors 'none'")
+	require.Equal(t, "DENY", rec.Header().Get("X-Frame-Options"))
+
+	rec = httptest.NewRecorder()
+	SecurityHeaders([]string{"https://portal.example.com"})(noop).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Contains(t, rec.Header().Get("Content-Security-Policy"), "frame-ancestors https://portal.example.com")
+	require.Empty(t, rec.Header().Get("X-Frame-Options"))
+}
+
+// nilAuthenticator returns neither a principal nor an error.
+type nilAuthenticator struct{}
+
+func (nilAuthenticator) Authenticate(*http.Request) (*Principal, error) {
+	return nil, nil
+}
+
+func TestAuthenticate_NilPrincipal(t *testing.T) {
+	handler := Authenticate(nilAuthenticator{})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/capabilities", nil))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"path"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type principalKey struct{}
+
+var rolePermissions = map[Role][]Permission{
+	RoleViewer:   {PermissionRead},
+	RoleOperator: {PermissionRead, PermissionRequeue, PermissionDelete, PermissionPublish},
+	RoleAdmin:    {PermissionRead, PermissionRequeue, PermissionDelete, PermissionRequeueAll, PermissionPublish, PermissionPurge},
+}
+
+// roleRank orders roles from least to most privileged.
+var roleRank = map[Role]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// maxRole returns the more privileged of a and b.
+func maxRole(a, b Role) Role {
+	if roleRank[b] > roleRank[a] {
+		return b
+	}
+	return a
+}
+
+// Can reports whether the principal's role grants perm.
+func (p *Principal) Can(perm Permission) bool {
+	for _, granted := range rolePermissions[p.Role] {
+		if granted == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// Unscoped reports whether the principal has access to every stream.
+func (p *Principal) Unscoped() bool {
+	return len(p.Streams) == 0
+}
+
+// CanAccessStream reports whether stream matches one of the principal's
+// stream patterns.
+func (p *Principal) CanAccessStream(stream string) bool {
+	if p.Unscoped() {
+		return true
+	}
+
+	for _, pattern := range p.Streams {
+		if ok, _ := path.Match(pattern, stream); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+func PrincipalFromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// RequirePermission rejects requests whose principal lacks perm.
+func RequirePermission(perm Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p := PrincipalFromContext(r.Context())
+			if p == nil {
+				Error(w, http.StatusUnauthorized, "Unauthorized")
+				return
+			}
+
+			if !p.Can(perm) {
+				Error(w, http.StatusForbidden, "Forbidden")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireUnscoped rejects requests from principals restricted to a subset
+// of streams. It guards operations that span every stream.
+func RequireUnscoped(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := PrincipalFromContext(r.Context())
+		if p == nil || !p.Unscoped() {
+			Error(w, http.StatusForbidden, "Forbidden")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireStreamAccess rejects requests for a {name} stream outside the
+// principal's stream patterns. It must be attached with chi's With so the
+// URL parameters are resolved before it runs.
+func RequireStreamAccess(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := PrincipalFromContext(r.Context())
+		if p == nil || !p.CanAccessStream(chi.URLParam(r, "name")) {
+			Error(w, http.StatusForbidden, "Forbidden")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+
+	"github.com/scmofeoluwa/windmill/internal/monitor"
+)
+
+func TestPrincipal_Can(t *testing.T) {
+	tests := []struct {
+		role Role
+		perm Permission
+		want bool
+	}{
+		{RoleViewer, PermissionRead, true},
+		{RoleViewer, PermissionRequeue, false},
+		{RoleViewer, PermissionDelete, false},
+		{RoleOperator, PermissionRequeue, true},
+		{RoleOperator, PermissionDelete, true},
+		{RoleOperator, PermissionRequeueAll, false},
+		{RoleAdmin, PermissionRequeueAll, true},
+		{RoleViewer, PermissionPublish, false},
+		{RoleOperator, PermissionPublish, true},
+		{RoleOperator, PermissionPurge, false},
+		{RoleAdmin, PermissionPurge, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.role)+"/"+string(tt.perm), func(t *testing.T) {
+			p := &Principal{Role: tt.role}
+			require.Equal(t, tt.want, p.Can(tt.perm))
+		})
+	}
+}
+
+func TestPrincipal_CanAccessStream(t *testing.T) {
+	p := &Principal{Streams: []string{"payments.*"}}
+
+	require.True(t, p.CanAccessStream("payments.processed"))
+	require.False(t, p.CanAccessStream("orders.created"))
+	require.True(t, (&Principal{}).CanAccessStream("orders.created"))
+}
+
+func TestRoutes_Authorization(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	a := New(monitor.New(client, "test_dlq"), Config{
+		Auth: NewBasicAuthenticator([]User{
+			{Username: "viewer", Password: "secret", Role: RoleViewer},
+			{Username: "payments", Password: "secret", Role: RoleAdmin, Streams: []string{"payments.*"}},
+		}),
+	})
+
+	tests := []struct {
+		name   string
+		user   string
+		method string
+		path   string
+		want   int
+	}{
+		{"unauthenticated", "", http.MethodGet, "/api/streams", http.StatusUnauthorized},
+		{"viewer can read", "viewer", http.MethodGet, "/api/streams", http.StatusOK},
+		{"viewer cannot delete", "viewer", http.MethodDelete, "/api/streams/orders.created/messages/1-0", http.StatusForbidden},
+		{"viewer cannot requeue all", "viewer", http.MethodPost, "/api/dlq/requeue-all", http.StatusForbidden},
+		{"viewer cannot schedule requeue", "viewer", http.MethodPost, "/api/dlq/messages/1-0/schedule", http.StatusForbidden},
+		{"scoped admin outside scope", "payments", http.MethodGet, "/api/streams/orders.created/messages", http.StatusForbidden},
+		{"scoped admin inside scope", "payments", http.MethodGet, "/api/streams/payments.processed/messages", http.StatusOK},
+		{"scoped admin cannot requeue all", "payments", http.MethodPost, "/api/dlq/requeue-all", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.user != "" {
+				req.SetBasicAuth(tt.user, "secret")
+			}
+
+			rec := httptest.NewRecorder()
+			a.Handler().ServeHTTP(rec, req)
+			require.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
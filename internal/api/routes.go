@@ -1,6 +1,7 @@
 package api
 
 import (
+	"log/slog"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -10,15 +11,47 @@ import (
 	"github.com/scmofeoluwa/windmill/ui"
 )
 
+type Config struct {
+	Auth           Authenticator
+	AllowedOrigins []string
+	FrameAncestors []string
+	ReadOnly       bool
+	BasePath       string
+	// Logger receives errors that cannot be reported in the response, such
+	// as exports failing after the download started. Defaults to
+	// slog.Default().
+	Logger *slog.Logger
+}
+
 type API struct {
-	monitor *monitor.Monitor
-	router  chi.Router
+	instances map[string]*Instance
+	order     []string
+	config    Config
+	router    chi.Router
+}
+
+// New returns an API serving a single instance named DefaultInstance.
+func New(monitor *monitor.Monitor, config Config) *API {
+	return NewWithInstances([]Instance{{Name: DefaultInstance, Monitor: monitor}}, config)
 }
 
-func New(monitor *monitor.Monitor) *API {
+// NewWithInstances returns an API serving every instance under
+// /api/instances/{name}. The unscoped /api routes serve the first one.
+// Instance names must be unique and there must be at least one instance.
+func NewWithInstances(instances []Instance, config Config) *API {
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+
 	api := &API{
-		monitor: monitor,
-		router:  chi.NewRouter(),
+		instances: make(map[string]*Instance, len(instances)),
+		config:    config,
+		router:    chi.NewRouter(),
+	}
+
+	for _, instance := range instances {
+		api.instances[instance.Name] = &instance
+		api.order = append(api.order, instance.Name)
 	}
 
 	api.setupRoutes()
@@ -30,23 +63,80 @@ func (a *API) Handler() http.Handler {
 }
 
 func (a *API) setupRoutes() {
-	a.router.Use(BasicAuth())
+	read := RequirePermission(PermissionRead)
+
+	a.router.Use(StripBasePath(a.config.BasePath))
 	a.router.Use(middleware.Recoverer)
-	a.router.Route("/api", func(r chi.Router) {
-		r.Get("/overview", a.handleGetOverview)
-		r.Get("/streams", a.handleGetStreams)
-		r.Get("/streams/{name}", a.handleGetStream)
-		r.Get("/streams/{name}/messages", a.handleGetStreamMessages)
-		r.Get("/streams/{name}/messages/{id}", a.handleGetStreamMessage)
-		r.Delete("/streams/{name}/messages/{id}", a.handleDeleteMessage)
-
-		r.Get("/dlq", a.handleGetDLQStats)
-		r.Get("/dlq/messages", a.handleGetDLQMessages)
-		r.Get("/dlq/messages/{id}", a.handleGetDLQMessage)
-		r.Post("/dlq/messages/{id}/requeue", a.handleRequeueMessage)
-		r.Post("/dlq/requeue-all", a.handleRequeueAll)
-		r.Delete("/dlq/messages/{id}", a.handleDeleteDLQMessage)
+	a.router.Use(SecurityHeaders(a.config.FrameAncestors))
+	a.router.Use(CSRF(a.config.AllowedOrigins))
+
+	if provider, ok := a.config.Auth.(RouteProvider); ok {
+		a.router.Mount("/auth", provider.Routes())
+	}
+
+	a.router.Group(func(r chi.Router) {
+		r.Use(Authenticate(a.config.Auth))
+
+		r.Route("/api", func(r chi.Router) {
+			r.With(read).Get("/capabilities", a.handleGetCapabilities)
+			r.With(read).Get("/instances", a.handleGetInstances)
+
+			r.Route("/instances/{instance}", func(r chi.Router) {
+				r.Use(a.useInstance)
+				a.instanceRoutes(r)
+			})
+
+			r.Group(func(r chi.Router) {
+				r.Use(a.useDefaultInstance)
+				a.instanceRoutes(r)
+			})
+		})
+
+		r.Mount("/", ui.HandlerWithBasePath(a.config.BasePath))
 	})
+}
+
+// instanceRoutes registers the routes acting on a single instance, selected
+// by the middleware of r.
+func (a *API) instanceRoutes(r chi.Router) {
+	read := RequirePermission(PermissionRead)
+	requeue := RequirePermission(PermissionRequeue)
+	remove := RequirePermission(PermissionDelete)
+	requeueAll := RequirePermission(PermissionRequeueAll)
+	publish := RequirePermission(PermissionPublish)
+	purge := RequirePermission(PermissionPurge)
+
+	r.With(read).Get("/overview", a.handleGetOverview)
+	r.With(read).Get("/leader", a.handleGetLeader)
+	r.With(read).Get("/diagnostics", a.handleGetDiagnostics)
+	r.With(read).Get("/redis/info", a.handleGetRedisInfo)
+	r.With(read).Get("/health/consumers", a.handleGetConsumerHealth)
+	r.With(read).Get("/topology", a.handleGetTopology)
+	r.With(read).Get("/streams", a.handleGetStreams)
+	r.With(read, RequireStreamAccess).Get("/streams/{name}", a.handleGetStream)
+	r.With(read, RequireStreamAccess).Get("/streams/{name}/messages", a.handleGetStreamMessages)
+	r.With(read, RequireStreamAccess).Get("/streams/{name}/messages/{id}", a.handleGetStreamMessage)
+	r.With(read, RequireStreamAccess).Get("/streams/{name}/export", a.handleExportStream)
+
+	r.With(read).Get("/dlq", a.handleGetDLQStats)
+	r.With(read).Get("/dlq/messages", a.handleGetDLQMessages)
+	r.With(read).Get("/dlq/messages/{id}", a.handleGetDLQMessage)
+	r.With(read).Get("/dlq/export", a.handleExportDLQ)
+	r.With(read).Get("/dlq/scheduled", a.handleGetScheduledRequeues)
+
+	if a.config.ReadOnly {
+		return
+	}
+
+	r.With(remove, RequireStreamAccess).Delete("/streams/{name}/messages/{id}", a.handleDeleteMessage)
+	r.With(remove, RequireStreamAccess).Post("/streams/{name}/trim", a.handleTrimStream)
+	r.With(publish, RequireStreamAccess).Post("/streams/{name}/messages", a.handlePublishMessage)
+	r.With(publish).Post("/replay", a.handleReplay)
 
-	a.router.Mount("/", ui.Handler())
+	r.With(requeue).Post("/dlq/messages/{id}/requeue", a.handleRequeueMessage)
+	r.With(requeue).Post("/dlq/messages/{id}/schedule", a.handleScheduleRequeue)
+	r.With(requeue).Delete("/dlq/messages/{id}/schedule", a.handleCancelRequeue)
+	r.With(requeueAll, RequireUnscoped).Post("/dlq/requeue-all", a.handleRequeueAll)
+	r.With(purge, RequireUnscoped).Post("/dlq/purge", a.handlePurgeDLQ)
+	r.With(remove).Delete("/dlq/messages/{id}", a.handleDeleteDLQMessage)
 }
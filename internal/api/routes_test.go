@@ -0,0 +1,791 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack"
+
+	"github.com/scmofeoluwa/windmill/internal/monitor"
+)
+
+func TestRoutes_ReadOnly(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	a := New(monitor.New(client, "test_dlq"), Config{
+		Auth:     NoAuthenticator{},
+		ReadOnly: true,
+	})
+
+	rec := httptest.NewRecorder()
+	a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/dlq/requeue-all", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/dlq/messages/1-0", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	rec = httptest.NewRecorder()
+	a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/capabilities", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp struct {
+		Data Capabilities `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.True(t, resp.Data.ReadOnly)
+	require.Equal(t, RoleAdmin, resp.Data.Role)
+	require.False(t, resp.Data.CanRequeue)
+	require.False(t, resp.Data.CanDelete)
+	require.False(t, resp.Data.CanRequeueAll)
+	require.False(t, resp.Data.CanPublish)
+	require.False(t, resp.Data.CanPurge)
+}
+
+func TestRoutes_BasePath(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	a := New(monitor.New(client, "test_dlq"), Config{
+		Auth:     NoAuthenticator{},
+		BasePath: "/windmill",
+	})
+
+	for _, path := range []string{"/windmill/api/capabilities", "/api/capabilities"} {
+		rec := httptest.NewRecorder()
+		a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, rec.Code, path)
+	}
+
+	rec := httptest.NewRecorder()
+	a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/windmill/streams/orders", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `<base href="/windmill/">`)
+	require.Contains(t, rec.Body.String(), `<meta name="windmill-base-path" content="/windmill">`)
+}
+
+func addDLQMessage(t *testing.T, client redis.UniversalClient, topic string) {
+	metadata, err := msgpack.Marshal(map[string]string{monitor.TopicPoisonedKey: topic})
+	require.NoError(t, err)
+
+	err = client.XAdd(context.Background(), &redis.XAddArgs{
+		Stream: "test_dlq",
+		Values: map[string]any{
+			monitor.WatermillUUIDKey:     "test-uuid",
+			monitor.WatermillPayloadKey:  `{"id":1}`,
+			monitor.WatermillMetadataKey: string(metadata),
+		},
+	}).Err()
+	require.NoError(t, err)
+}
+
+func TestRoutes_ExportDLQ(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	addDLQMessage(t, client, "orders.created")
+	addDLQMessage(t, client, "payments.processed")
+	addDLQMessage(t, client, "payments.refunded")
+
+	a := New(monitor.New(client, "test_dlq"), Config{
+		Auth: NewBasicAuthenticator([]User{
+			{Username: "payments", Password: "secret", Role: RoleViewer, Streams: []string{"payments.*"}},
+		}),
+	})
+
+	export := func(query string) (*httptest.ResponseRecorder, []string) {
+		req := httptest.NewRequest(http.MethodGet, "/api/dlq/export"+query, nil)
+		req.SetBasicAuth("payments", "secret")
+
+		rec := httptest.NewRecorder()
+		a.Handler().ServeHTTP(rec, req)
+		return rec, strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	}
+
+	rec, lines := export("")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+	require.Contains(t, rec.Header().Get("Content-Disposition"), "dlq.ndjson")
+	require.Len(t, lines, 2)
+
+	_, lines = export("?topic=payments.refunded")
+	require.Len(t, lines, 1)
+
+	rec, _ = export("?format=xml")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestRoutes_ScopedDLQ(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	addDLQMessage(t, client, "orders.created")
+	addDLQMessage(t, client, "payments.processed")
+	require.NoError(t, client.XAdd(context.Background(), &redis.XAddArgs{Stream: "orders.created", Values: map[string]any{"k": "v"}}).Err())
+	require.NoError(t, client.XAdd(context.Background(), &redis.XAddArgs{Stream: "payments.processed", Values: map[string]any{"k": "v"}}).Err())
+
+	a := New(monitor.New(client, "test_dlq"), Config{
+		Auth: NewBasicAuthenticator([]User{
+			{Username: "payments", Password: "secret", Role: RoleViewer, Streams: []string{"payments.*"}},
+		}),
+	})
+
+	messages, err := client.XRange(context.Background(), "test_dlq", "-", "+").Result()
+	require.NoError(t, err)
+	ordersID, paymentsID := messages[0].ID, messages[1].ID
+
+	get := func(path string, v any) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.SetBasicAuth("payments", "secret")
+
+		rec := httptest.NewRecorder()
+		a.Handler().ServeHTTP(rec, req)
+		if v != nil && rec.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(v))
+		}
+		return rec.Code
+	}
+
+	var list struct {
+		Data monitor.MessageList[monitor.DLQMessage] `json:"data"`
+	}
+	require.Equal(t, http.StatusOK, get("/api/dlq/messages", &list))
+	require.Len(t, list.Data.Messages, 1)
+	require.Equal(t, paymentsID, list.Data.Messages[0].ID)
+	require.Equal(t, int64(1), list.Data.TotalCount)
+
+	require.Equal(t, http.StatusOK, get("/api/dlq/messages/"+paymentsID, nil))
+	require.Equal(t, http.StatusForbidden, get("/api/dlq/messages/"+ordersID, nil))
+
+	var overview struct {
+		Data monitor.StatsOverview `json:"data"`
+	}
+	require.Equal(t, http.StatusOK, get("/api/overview", &overview))
+	require.Equal(t, monitor.StatsOverview{TotalStreams: 1, TotalMessages: 1, TotalDLQMessages: 1}, overview.Data)
+
+	var stats struct {
+		Data monitor.StreamInfo `json:"data"`
+	}
+	require.Equal(t, http.StatusOK, get("/api/dlq", &stats))
+	require.Equal(t, int64(1), stats.Data.Length)
+	require.Equal(t, paymentsID, *stats.Data.LastEntryID)
+
+	rec := httptest.NewRecorder()
+	a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/capabilities", nil))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestRoutes_Replay(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	a := New(monitor.New(client, "test_dlq"), Config{
+		Auth: NewBasicAuthenticator([]User{
+			{Username: "payments", Password: "secret", Role: RoleOperator, Streams: []string{"payments.*"}},
+			{Username: "viewer", Password: "secret", Role: RoleViewer},
+		}),
+	})
+
+	records := strings.Join([]string{
+		`{"id":"1-0","stream":"payments.processed","payload":{"id":1},"metadata":{}}`,
+		`{"id":"2-0","stream":"orders.created","payload":{"id":2},"metadata":{}}`,
+	}, "\n")
+
+	replay := func(user, query string, body io.Reader, contentType string) (*httptest.ResponseRecorder, monitor.ReplayResult) {
+		req := httptest.NewRequest(http.MethodPost, "/api/replay"+query, body)
+		req.SetBasicAuth(user, "secret")
+		req.Header.Set("Content-Type", contentType)
+
+		rec := httptest.NewRecorder()
+		a.Handler().ServeHTTP(rec, req)
+
+		var resp struct {
+			Data monitor.ReplayResult `json:"data"`
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec, resp.Data
+	}
+
+	rec, _ := replay("viewer", "", strings.NewReader(records), "application/x-ndjson")
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec, _ = replay("payments", "?target=orders.created", strings.NewReader(records), "application/x-ndjson")
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec, result := replay("payments", "?dry_run=true", strings.NewReader(records), "application/x-ndjson")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.True(t, result.DryRun)
+	require.Equal(t, 1, result.Replayed)
+	require.Equal(t, 1, result.Failed)
+	require.False(t, mr.Exists("payments.processed"))
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("file", "dlq.ndjson")
+	require.NoError(t, err)
+	_, err = io.WriteString(part, records)
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	rec, result = replay("payments", "", &body, mw.FormDataContentType())
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, 1, result.Replayed)
+	require.True(t, mr.Exists("payments.processed"))
+	require.False(t, mr.Exists("orders.created"))
+
+	rec, _ = replay("payments", "?rate=fast", strings.NewReader(records), "application/x-ndjson")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestRoutes_PublishMessage(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	a := New(monitor.New(client, "test_dlq"), Config{
+		Auth: NewBasicAuthenticator([]User{
+			{Username: "payments", Password: "secret", Role: RoleOperator, Streams: []string{"payments.*"}},
+		}),
+	})
+
+	publish := func(stream, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/streams/"+stream+"/messages", strings.NewReader(body))
+		req.SetBasicAuth("payments", "secret")
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+		a.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := publish("payments.processed", `{"payload":{"id":1},"metadata":{"trace_id":"abc"}}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	var resp struct {
+		Data monitor.Message `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.NotEmpty(t, resp.Data.UUID)
+
+	msg, err := monitor.New(client, "test_dlq").Streams().GetMessage(context.Background(), "payments.processed", resp.Data.ID)
+	require.NoError(t, err)
+	require.Equal(t, "abc", msg.Metadata["trace_id"])
+	require.Equal(t, float64(1), msg.Payload["id"])
+
+	require.Equal(t, http.StatusForbidden, publish("orders.created", `{"payload":{"id":1}}`).Code)
+	require.Equal(t, http.StatusBadRequest, publish("payments.processed", `{"payload":[1]}`).Code)
+	require.Equal(t, http.StatusBadRequest, publish("payments.processed", `{}`).Code)
+}
+
+func TestRoutes_TrimStream(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	for range 5 {
+		require.NoError(t, client.XAdd(context.Background(), &redis.XAddArgs{
+			Stream: "orders.created",
+			Values: map[string]any{"payload": "{}"},
+		}).Err())
+	}
+
+	a := New(monitor.New(client, "test_dlq"), Config{Auth: NoAuthenticator{}})
+
+	trim := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/streams/orders.created/trim", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+		a.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := trim(`{"strategy":"maxlen","max_len":2,"dry_run":true}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `"removed":3`)
+	require.Equal(t, int64(5), client.XLen(context.Background(), "orders.created").Val())
+
+	require.NoError(t, client.XGroupCreate(context.Background(), "orders.created", "billing", "0").Err())
+	require.Equal(t, http.StatusConflict, trim(`{"strategy":"maxlen","max_len":2}`).Code)
+	require.Equal(t, http.StatusBadRequest, trim(`{"strategy":"minid","min_id":"soon"}`).Code)
+	require.Equal(t, http.StatusBadRequest, trim(`{"strategy":"everything"}`).Code)
+}
+
+func TestRoutes_PurgeDLQ(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	addDLQMessage(t, client, "orders.created")
+	addDLQMessage(t, client, "payments.processed")
+
+	a := New(monitor.New(client, "test_dlq"), Config{Auth: NoAuthenticator{}})
+
+	purge := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/dlq/purge", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+		a.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := purge(`{"confirm":"dlq"}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = purge(`{"dry_run":true}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `"matched":2`)
+
+	rec = purge(`{"confirm":"test_dlq","topic":"orders.created","export":"ndjson"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+	require.Contains(t, rec.Body.String(), "orders.created")
+
+	var result monitor.PurgeResult
+	require.NoError(t, json.Unmarshal([]byte(rec.Result().Trailer.Get("Windmill-Purge-Result")), &result))
+	require.Equal(t, int64(1), result.Deleted)
+	require.True(t, result.Exported)
+	require.Equal(t, int64(1), client.XLen(context.Background(), "test_dlq").Val())
+
+	rec = purge(`{"confirm":"test_dlq"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Zero(t, client.XLen(context.Background(), "test_dlq").Val())
+}
+
+func TestRoutes_ScheduleRequeue(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	addDLQMessage(t, client, "orders.created")
+	addDLQMessage(t, client, "payments.processed")
+
+	a := New(monitor.New(client, "test_dlq"), Config{
+		Auth: NewBasicAuthenticator([]User{
+			{Username: "admin", Password: "secret", Role: RoleAdmin},
+			{Username: "payments", Password: "secret", Role: RoleOperator, Streams: []string{"payments.*"}},
+		}),
+	})
+
+	messages, err := client.XRange(context.Background(), "test_dlq", "-", "+").Result()
+	require.NoError(t, err)
+	ordersID, paymentsID := messages[0].ID, messages[1].ID
+
+	do := func(user, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.SetBasicAuth(user, "secret")
+
+		rec := httptest.NewRecorder()
+		a.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do("admin", http.MethodPost, "/api/dlq/messages/"+ordersID+"/schedule", `{}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = do("admin", http.MethodPost, "/api/dlq/messages/"+ordersID+"/schedule", `{"delay":"soon"}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = do("admin", http.MethodPost, "/api/dlq/messages/"+ordersID+"/schedule", `{"delay":"1h"}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	require.Contains(t, rec.Body.String(), `"topic":"orders.created"`)
+
+	rec = do("payments", http.MethodPost, "/api/dlq/messages/"+ordersID+"/schedule", `{"delay":"1h"}`)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = do("payments", http.MethodPost, "/api/dlq/messages/"+paymentsID+"/schedule", `{"at":"2030-01-01T00:00:00Z","payload":{"id":2}}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	var resp struct {
+		Data monitor.MessageList[monitor.ScheduledRequeue] `json:"data"`
+	}
+	rec = do("payments", http.MethodGet, "/api/dlq/scheduled", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.Len(t, resp.Data.Messages, 1)
+	require.Equal(t, int64(1), resp.Data.TotalCount)
+	require.Equal(t, paymentsID, resp.Data.Messages[0].ID)
+	require.Equal(t, map[string]any{"id": float64(2)}, resp.Data.Messages[0].Payload)
+
+	rec = do("admin", http.MethodGet, "/api/dlq/scheduled?limit=1", "")
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.Len(t, resp.Data.Messages, 1)
+	require.Equal(t, int64(2), resp.Data.TotalCount)
+	require.True(t, resp.Data.HasMore)
+
+	rec = do("payments", http.MethodDelete, "/api/dlq/messages/"+paymentsID+"/schedule", "")
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = do("payments", http.MethodDelete, "/api/dlq/messages/"+paymentsID+"/schedule", "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestRoutes_Leader(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	require.NoError(t, client.Set(context.Background(), "windmill:{test_dlq}:leader", "3:replica-1", time.Minute).Err())
+
+	a := New(monitor.NewWithOptions(client, "test_dlq", monitor.Options{Leader: monitor.LeaderOpts{ID: "replica-2"}}), Config{Auth: NoAuthenticator{}})
+
+	rec := httptest.NewRecorder()
+	a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/leader", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp struct {
+		Data monitor.LeaderStatus `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.True(t, strings.HasPrefix(resp.Data.ID, "replica-2-"))
+	require.Equal(t, "replica-1", resp.Data.Leader)
+	require.Equal(t, int64(3), resp.Data.Token)
+	require.False(t, resp.Data.IsLeader)
+}
+
+func TestRoutes_Instances(t *testing.T) {
+	ctx := context.Background()
+	clients := map[string]*redis.Client{}
+	for _, name := range []string{"us", "eu", "ap"} {
+		mr := miniredis.RunT(t)
+		clients[name] = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { clients[name].Close() })
+		if name == "ap" {
+			mr.Close()
+		}
+	}
+
+	require.NoError(t, clients["us"].XAdd(ctx, &redis.XAddArgs{Stream: "orders", Values: map[string]any{"k": "v"}}).Err())
+	require.NoError(t, clients["eu"].XAdd(ctx, &redis.XAddArgs{Stream: "payments", Values: map[string]any{"k": "v"}}).Err())
+	require.NoError(t, clients["eu"].XAdd(ctx, &redis.XAddArgs{Stream: "payments", Values: map[string]any{"k": "v"}}).Err())
+	require.NoError(t, clients["us"].XAdd(ctx, &redis.XAddArgs{Stream: "us_dlq", Values: map[string]any{"k": "v"}}).Err())
+	require.NoError(t, clients["eu"].XAdd(ctx, &redis.XAddArgs{Stream: "eu_dlq", Values: map[string]any{"k": "v"}}).Err())
+
+	a := NewWithInstances([]Instance{
+		{Name: "us", Monitor: monitor.New(clients["us"], "us_dlq")},
+		{Name: "eu", Monitor: monitor.New(clients["eu"], "eu_dlq")},
+		{Name: "ap", Monitor: monitor.New(clients["ap"], "ap_dlq")},
+	}, Config{Auth: NoAuthenticator{}})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := get("/api/instances")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var instances struct {
+		Data InstancesOverview `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&instances))
+	require.Len(t, instances.Data.Instances, 3)
+	require.Equal(t, "us", instances.Data.Instances[0].Name)
+	require.Equal(t, "eu_dlq", instances.Data.Instances[1].DLQ)
+	require.Equal(t, int64(2), instances.Data.Instances[1].Overview.TotalMessages)
+	require.Nil(t, instances.Data.Instances[2].Overview)
+	require.NotEmpty(t, instances.Data.Instances[2].Error)
+	require.Equal(t, 2, instances.Data.Total.TotalStreams)
+	require.Equal(t, int64(3), instances.Data.Total.TotalMessages)
+	require.Equal(t, int64(2), instances.Data.Total.TotalDLQMessages)
+
+	streams := func(path string) []string {
+		rec := get(path)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp struct {
+			Data monitor.StreamList `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+
+		var names []string
+		for _, stream := range resp.Data.Streams {
+			names = append(names, stream.Name)
+		}
+		return names
+	}
+
+	require.Equal(t, []string{"payments"}, streams("/api/instances/eu/streams"))
+	require.Equal(t, []string{"orders"}, streams("/api/instances/us/streams"))
+	require.Equal(t, []string{"orders"}, streams("/api/streams"))
+
+	require.Equal(t, http.StatusNotFound, get("/api/instances/mars/streams").Code)
+}
+
+func TestRoutes_Diagnostics(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	a := New(monitor.New(client, "test_dlq"), Config{Auth: NoAuthenticator{}})
+
+	rec := httptest.NewRecorder()
+	a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/diagnostics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp struct {
+		Data Diagnostics `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.NotNil(t, resp.Data.Redis.ProbedAt)
+	require.True(t, resp.Data.Redis.ScanType)
+	require.True(t, resp.Data.Redis.MemoryUsage)
+	require.True(t, resp.Data.Redis.XInfo)
+}
+
+func TestRoutes_RedisInfo(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	a := New(monitor.New(client, "test_dlq"), Config{Auth: NoAuthenticator{}})
+
+	rec := httptest.NewRecorder()
+	a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/redis/info", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp struct {
+		Data monitor.RedisInfo `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.Len(t, resp.Data.Nodes, 1)
+	require.Equal(t, int64(1), resp.Data.Nodes[0].ConnectedClients)
+
+	// miniredis has no SLOWLOG, which is reported like a denied command.
+	require.NotEmpty(t, resp.Data.Nodes[0].SlowLogError)
+}
+
+// slowLogHook answers SLOWLOG GET, which miniredis lacks, with entries.
+type slowLogHook struct {
+	entries []redis.SlowLog
+}
+
+func (slowLogHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h slowLogHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if cmd, ok := cmd.(*redis.SlowLogCmd); ok {
+			cmd.SetVal(h.entries)
+			return nil
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (slowLogHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func TestRoutes_RedisInfoScopedSlowLog(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	client.AddHook(slowLogHook{entries: []redis.SlowLog{
+		{ID: 2, Args: []string{"XADD", "orders.created", "*", "payload", "{}"}},
+		{ID: 1, Args: []string{"XADD", "payments.processed", "*", "payload", "{}"}},
+	}})
+
+	a := New(monitor.New(client, "test_dlq"), Config{
+		Auth: NewBasicAuthenticator([]User{
+			{Username: "payments", Password: "secret", Role: RoleViewer, Streams: []string{"payments.*"}},
+		}),
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/redis/info", nil)
+	req.SetBasicAuth("payments", "secret")
+	rec := httptest.NewRecorder()
+	a.Handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp struct {
+		Data monitor.RedisInfo `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.Len(t, resp.Data.Nodes[0].SlowLog, 1)
+	require.Equal(t, "payments.processed", resp.Data.Nodes[0].SlowLog[0].Key)
+	require.Equal(t, []string{"payments.processed"}, resp.Data.Nodes[0].SlowLog[0].Args)
+}
+
+func TestRoutes_ListStreams(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	for _, name := range []string{"orders.created", "payments.processed", "payments.refunded"} {
+		require.NoError(t, client.XAdd(context.Background(), &redis.XAddArgs{Stream: name, Values: map[string]any{"k": "v"}}).Err())
+	}
+
+	a := New(monitor.New(client, "test_dlq"), Config{
+		Auth: NewBasicAuthenticator([]User{
+			{Username: "payments", Password: "secret", Role: RoleViewer, Streams: []string{"payments.*"}},
+		}),
+	})
+
+	list := func(query string) (*httptest.ResponseRecorder, monitor.StreamList) {
+		req := httptest.NewRequest(http.MethodGet, "/api/streams"+query, nil)
+		req.SetBasicAuth("payments", "secret")
+
+		rec := httptest.NewRecorder()
+		a.Handler().ServeHTTP(rec, req)
+
+		var resp struct {
+			Data monitor.StreamList `json:"data"`
+		}
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		}
+		return rec, resp.Data
+	}
+
+	rec, page := list("?limit=1&sort=name&order=desc")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, int64(2), page.TotalCount)
+	require.Len(t, page.Streams, 1)
+	require.Equal(t, "payments.refunded", page.Streams[0].Name)
+	require.Equal(t, "1", page.NextCursor)
+
+	_, page = list("?limit=1&sort=name&order=desc&cursor=1")
+	require.Equal(t, "payments.processed", page.Streams[0].Name)
+	require.False(t, page.HasMore)
+
+	rec, _ = list("?sort=size")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestRoutes_StreamDetailFull(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	require.NoError(t, client.XAdd(context.Background(), &redis.XAddArgs{Stream: "orders.created", Values: map[string]any{"k": "v"}}).Err())
+
+	a := New(monitor.New(client, "test_dlq"), Config{Auth: NoAuthenticator{}})
+
+	get := func(query string) (*httptest.ResponseRecorder, monitor.StreamDetail) {
+		rec := httptest.NewRecorder()
+		a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/streams/orders.created"+query, nil))
+
+		var resp struct {
+			Data monitor.StreamDetail `json:"data"`
+		}
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		}
+		return rec, resp.Data
+	}
+
+	rec, detail := get("")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Nil(t, detail.Full)
+
+	rec, detail = get("?full=true&count=5")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NotNil(t, detail.Full)
+	require.Equal(t, int64(1), detail.Length)
+
+	rec, _ = get("?full=maybe")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec, _ = get("?full=true&count=0")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestRoutes_ConsumerHealth(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	ctx := context.Background()
+	require.NoError(t, client.XAdd(ctx, &redis.XAddArgs{Stream: "orders.created", Values: map[string]any{"k": "v"}}).Err())
+	require.NoError(t, client.XGroupCreate(ctx, "orders.created", "billing", "0").Err())
+
+	a := New(monitor.New(client, "test_dlq"), Config{Auth: NoAuthenticator{}})
+
+	rec := httptest.NewRecorder()
+	a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/health/consumers?idle=1m", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp struct {
+		Data monitor.ConsumerHealth `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.True(t, resp.Data.Healthy)
+	require.Equal(t, int64(60000), resp.Data.IdleThresholdMS)
+	require.Len(t, resp.Data.Groups, 1)
+	require.Equal(t, int64(1), *resp.Data.Groups[0].Lag)
+
+	rec = httptest.NewRecorder()
+	a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/health/consumers?idle=soon", nil))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestRoutes_Topology(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	ctx := context.Background()
+	for _, name := range []string{"orders.created", "payments.processed"} {
+		require.NoError(t, client.XAdd(ctx, &redis.XAddArgs{Stream: name, Values: map[string]any{"k": "v"}}).Err())
+		require.NoError(t, client.XGroupCreate(ctx, name, "workers", "0").Err())
+	}
+
+	mon := monitor.New(client, "test_dlq")
+	mon.Topology().RegisterHandler(monitor.HandlerRegistration{Name: "charge", SubscribeTopic: "payments.processed"})
+	mon.Topology().RegisterHandler(monitor.HandlerRegistration{Name: "ship", SubscribeTopic: "orders.created"})
+
+	a := New(mon, Config{
+		Auth: NewBasicAuthenticator([]User{
+			{Username: "admin", Password: "secret", Role: RoleAdmin},
+			{Username: "payments", Password: "secret", Role: RoleViewer, Streams: []string{"payments.*"}},
+		}),
+	})
+
+	nodes := func(username string) []string {
+		req := httptest.NewRequest(http.MethodGet, "/api/topology", nil)
+		req.SetBasicAuth(username, "secret")
+
+		rec := httptest.NewRecorder()
+		a.Handler().ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp struct {
+			Data monitor.Topology `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+
+		ids := make([]string, len(resp.Data.Nodes))
+		for i, node := range resp.Data.Nodes {
+			ids[i] = node.ID
+		}
+		return ids
+	}
+
+	require.Len(t, nodes("admin"), 6)
+	require.Equal(t, []string{
+		"group:payments.processed/workers",
+		"handler:charge",
+		"topic:payments.processed",
+	}, nodes("payments"))
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var errInvalidCookie = errors.New("invalid cookie")
+
+// cookieSigner encodes values as tamper-proof cookie strings. Values are
+// signed, not encrypted, so they must not hold secrets.
+type cookieSigner struct {
+	key []byte
+}
+
+func (s cookieSigner) encode(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + s.sign(payload), nil
+}
+
+func (s cookieSigner) decode(value string, v any) error {
+	payload, sig, ok := strings.Cut(value, ".")
+	if !ok {
+		return errInvalidCookie
+	}
+
+	if !hmac.Equal([]byte(sig), []byte(s.sign(payload))) {
+		return errInvalidCookie
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return errInvalidCookie
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+func (s cookieSigner) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
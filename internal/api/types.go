@@ -0,0 +1,168 @@
+//go:generate go-enum --marshal
+package api
+
+import (
+	"time"
+
+	"github.com/scmofeoluwa/windmill/internal/monitor"
+)
+
+// ENUM(viewer, operator, admin)
+type Role string
+
+type Permission string
+
+const (
+	PermissionRead       Permission = "read"
+	PermissionRequeue    Permission = "requeue"
+	PermissionDelete     Permission = "delete"
+	PermissionRequeueAll Permission = "requeue_all"
+	PermissionPublish    Permission = "publish"
+	PermissionPurge      Permission = "purge"
+)
+
+// Capabilities describes what the current caller may do, so the UI can
+// hide actions that would be rejected.
+type Capabilities struct {
+	ReadOnly      bool     `json:"read_only"`
+	User          string   `json:"user"`
+	Role          Role     `json:"role"`
+	Streams       []string `json:"streams,omitempty"`
+	CanRequeue    bool     `json:"can_requeue"`
+	CanDelete     bool     `json:"can_delete"`
+	CanRequeueAll bool     `json:"can_requeue_all"`
+	CanPublish    bool     `json:"can_publish"`
+	CanPurge      bool     `json:"can_purge"`
+}
+
+// Diagnostics reports what Windmill detected about its Redis server.
+type Diagnostics struct {
+	Redis monitor.RedisCapabilities `json:"redis"`
+}
+
+// InstanceOverview is the overview of one instance, or why it could not be
+// read.
+type InstanceOverview struct {
+	Name     string                 `json:"name"`
+	DLQ      string                 `json:"dlq"`
+	Overview *monitor.StatsOverview `json:"overview,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+}
+
+// InstancesOverview lists every instance, with totals across those whose
+// overview could be read.
+type InstancesOverview struct {
+	Instances []InstanceOverview    `json:"instances"`
+	Total     monitor.StatsOverview `json:"total"`
+}
+
+// PublishRequest is the body of a request publishing a new message. The
+// payload must be a JSON object; metadata is optional.
+type PublishRequest struct {
+	Payload  map[string]any    `json:"payload"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// TrimRequest is the body of a request trimming a stream. See
+// monitor.TrimOpts for the meaning of each field.
+type TrimRequest struct {
+	Strategy    monitor.TrimStrategy `json:"strategy"`
+	MaxLen      int64                `json:"max_len"`
+	MinID       string               `json:"min_id"`
+	Approximate bool                 `json:"approximate"`
+	DryRun      bool                 `json:"dry_run"`
+}
+
+// ScheduleRequest is the body of a request scheduling a DLQ requeue, either
+// at an absolute time or after a delay such as "15m". Payload, when set,
+// replaces the message payload on requeue.
+type ScheduleRequest struct {
+	At      *time.Time     `json:"at,omitempty"`
+	Delay   string         `json:"delay,omitempty"`
+	Payload map[string]any `json:"payload,omitempty"`
+}
+
+// PurgeRequest is the body of a request purging the DLQ. Confirm must be
+// the DLQ name. When Export is set, the response is the export of the
+// purged messages and the purge result is sent in the
+// Windmill-Purge-Result trailer.
+type PurgeRequest struct {
+	Before        *time.Time           `json:"before,omitempty"`
+	Topic         string               `json:"topic,omitempty"`
+	ErrorContains string               `json:"error_contains,omitempty"`
+	Confirm       string               `json:"confirm"`
+	DryRun        bool                 `json:"dry_run"`
+	Export        monitor.ExportFormat `json:"export,omitempty"`
+}
+
+// User is a set of Basic Auth credentials allowed to access the dashboard.
+// Either Password or a bcrypt PasswordHash must be set. Streams restricts
+// the user to streams matching any of the given path.Match patterns
+// (e.g. "payments.*"). An empty list grants access to every stream.
+type User struct {
+	Username     string
+	Password     string
+	PasswordHash string
+	Role         Role
+	Streams      []string
+}
+
+// Token is a static bearer token allowed to access the API.
+type Token struct {
+	Name    string
+	Token   string
+	Role    Role
+	Streams []string
+}
+
+// ProxyAuthConfig configures authentication via headers set by a trusted
+// reverse proxy.
+type ProxyAuthConfig struct {
+	// UserHeader carries the authenticated username. Defaults to
+	// X-Forwarded-User.
+	UserHeader string
+	// RoleHeader optionally carries the user's role. When absent or empty,
+	// DefaultRole is used.
+	RoleHeader  string
+	DefaultRole Role
+	// TrustedProxies lists the CIDRs requests must originate from for the
+	// headers to be honoured.
+	TrustedProxies []string
+}
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Name    string
+	Role    Role
+	Streams []string
+}
+
+// OIDCConfig configures dashboard login through an OpenID Connect
+// provider using the authorization code flow with PKCE.
+type OIDCConfig struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the absolute URL of the callback route, i.e. the
+	// dashboard's mount point followed by /auth/callback.
+	RedirectURL string
+	// Scopes requested in addition to "openid". Defaults to profile, email
+	// and groups.
+	Scopes []string
+
+	// GroupsClaim names the ID token claim listing the user's groups.
+	// Defaults to "groups".
+	GroupsClaim string
+	// GroupRoles maps group names to roles. A user in several groups gets
+	// the most privileged role.
+	GroupRoles map[string]Role
+	// DefaultRole is given to users in none of the mapped groups. When
+	// empty, such users are denied access.
+	DefaultRole Role
+
+	// SessionKey signs session cookies. It must be at least 32 bytes and
+	// shared between replicas.
+	SessionKey []byte
+	// SessionTTL bounds how long a login lasts. Defaults to 8 hours.
+	SessionTTL time.Duration
+}
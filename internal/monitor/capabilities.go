@@ -0,0 +1,146 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const capabilityProbeTimeout = 5 * time.Second
+
+// capabilityProbeKey is never written; commands are probed against it so
+// they fail on permissions rather than on the data they would read.
+const capabilityProbeKey = "windmill:capability-probe"
+
+// RedisCapabilities describes the commands the Redis server lets Windmill
+// run, as detected by ProbeCapabilities. Managed providers often deny or
+// rename some of them; RedisStream then falls back to slower or less
+// detailed alternatives instead of failing.
+type RedisCapabilities struct {
+	// Version is the server version reported by INFO, empty when INFO is
+	// denied.
+	Version string `json:"version,omitempty"`
+
+	// ScanType reports whether SCAN accepts TYPE (Redis 6 and later).
+	// Without it, keys are scanned and then checked with TYPE one by one.
+	ScanType bool `json:"scan_type"`
+
+	// MemoryUsage reports whether MEMORY USAGE is allowed. Without it, the
+	// memory of streams is left unknown.
+	MemoryUsage bool `json:"memory_usage"`
+
+	// XInfo reports whether XINFO is allowed. Without it, stream info is
+	// read with XLEN and XRANGE, and consumer groups cannot be listed.
+	XInfo bool `json:"xinfo"`
+
+	// Unavailable maps each command found unusable to the error Redis gave.
+	Unavailable map[string]string `json:"unavailable,omitempty"`
+
+	// ProbedAt is when the capabilities were detected, nil when they are
+	// assumed because no probe succeeded yet.
+	ProbedAt *time.Time `json:"probed_at,omitempty"`
+}
+
+// allCapabilities is assumed until a probe tells otherwise.
+var allCapabilities = RedisCapabilities{ScanType: true, MemoryUsage: true, XInfo: true}
+
+// VersionAtLeast reports whether the server version is at least
+// major.minor. An unknown version is never at least anything.
+func (c RedisCapabilities) VersionAtLeast(major, minor int) bool {
+	parts := strings.SplitN(c.Version, ".", 3)
+	if len(parts) < 2 {
+		return false
+	}
+
+	gotMajor, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return false
+	}
+	gotMinor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return false
+	}
+
+	return gotMajor > major || gotMajor == major && gotMinor >= minor
+}
+
+// Capabilities returns the capabilities found by the last successful
+// probe, or all of them when none has run.
+func (r *RedisStream) Capabilities() RedisCapabilities {
+	if caps := r.caps.Load(); caps != nil {
+		return *caps
+	}
+	return allCapabilities
+}
+
+// ProbeCapabilities runs each command RedisStream depends on against a key
+// that does not exist, and adapts RedisStream to those Redis refuses. Only
+// errors reaching Redis, such as a lost connection, fail the probe; the
+// previous capabilities are kept then.
+func (r *RedisStream) ProbeCapabilities(ctx context.Context) (*RedisCapabilities, error) {
+	caps := RedisCapabilities{Unavailable: map[string]string{}}
+
+	var err error
+	if caps.Version, err = r.probeVersion(ctx); err != nil {
+		if !unavailable(err) {
+			return nil, err
+		}
+		caps.Unavailable["INFO"] = err.Error()
+	}
+
+	probes := []struct {
+		command string
+		allowed *bool
+		run     func() error
+	}{
+		{"SCAN TYPE", &caps.ScanType, func() error {
+			return r.client.ScanType(ctx, 0, capabilityProbeKey, 1, "stream").Err()
+		}},
+		{"MEMORY USAGE", &caps.MemoryUsage, func() error {
+			return r.client.MemoryUsage(ctx, capabilityProbeKey).Err()
+		}},
+		{"XINFO", &caps.XInfo, func() error {
+			return r.client.XInfoStream(ctx, capabilityProbeKey).Err()
+		}},
+	}
+	for _, probe := range probes {
+		err := probe.run()
+		switch {
+		case err == nil, errors.Is(err, redis.Nil), isNoSuchKey(err):
+			*probe.allowed = true
+		case unavailable(err):
+			caps.Unavailable[probe.command] = err.Error()
+		default:
+			return nil, err
+		}
+	}
+
+	if len(caps.Unavailable) == 0 {
+		caps.Unavailable = nil
+	}
+	now := time.Now().UTC()
+	caps.ProbedAt = &now
+
+	r.caps.Store(&caps)
+	return &caps, nil
+}
+
+func (r *RedisStream) probeVersion(ctx context.Context) (string, error) {
+	info, err := r.client.Info(ctx, "server").Result()
+	if err != nil {
+		return "", err
+	}
+	return parseInfo(info)["redis_version"], nil
+}
+
+// unavailable reports whether err is Redis refusing a command, because an
+// ACL denies it, it was renamed away or the server is too old to support
+// it, as opposed to failing to reach Redis at all.
+func unavailable(err error) bool {
+	var redisErr redis.Error
+	return errors.As(err, &redisErr)
+}
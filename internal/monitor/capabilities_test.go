@@ -0,0 +1,163 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/suite"
+)
+
+type CapabilitiesTestSuite struct {
+	suite.Suite
+	mr     *miniredis.Miniredis
+	client *redis.Client
+}
+
+func (s *CapabilitiesTestSuite) SetupTest() {
+	s.mr = miniredis.RunT(s.T())
+	s.client = redis.NewClient(&redis.Options{Addr: s.mr.Addr()})
+}
+
+func (s *CapabilitiesTestSuite) TearDownTest() {
+	s.client.Close()
+	s.mr.Close()
+}
+
+func (s *CapabilitiesTestSuite) TestProbe_AllAllowed() {
+	redisStream := NewRedisStream(s.client)
+	s.Nil(redisStream.Capabilities().ProbedAt)
+
+	caps, err := redisStream.ProbeCapabilities(context.Background())
+	s.Require().NoError(err)
+	s.True(caps.ScanType)
+	s.True(caps.MemoryUsage)
+	s.True(caps.XInfo)
+	s.NotNil(caps.ProbedAt)
+	s.Equal(*caps, redisStream.Capabilities())
+}
+
+func (s *CapabilitiesTestSuite) TestProbe_Denied() {
+	s.client.AddHook(denyHook{deny: map[string]bool{"memory": true, "xinfo": true, "scan type": true}})
+
+	caps, err := NewRedisStream(s.client).ProbeCapabilities(context.Background())
+	s.Require().NoError(err)
+	s.False(caps.ScanType)
+	s.False(caps.MemoryUsage)
+	s.False(caps.XInfo)
+	s.Contains(caps.Unavailable["MEMORY USAGE"], "NOPERM")
+	s.Contains(caps.Unavailable["XINFO"], "NOPERM")
+	s.Contains(caps.Unavailable["SCAN TYPE"], "NOPERM")
+}
+
+func (s *CapabilitiesTestSuite) TestProbe_Unreachable() {
+	redisStream := NewRedisStream(s.client)
+	s.mr.Close()
+
+	_, err := redisStream.ProbeCapabilities(context.Background())
+	s.Error(err)
+	s.Equal(allCapabilities, redisStream.Capabilities())
+}
+
+// failFirstHook fails the first n commands as if Redis were unreachable.
+type failFirstHook struct {
+	n *int
+}
+
+func (failFirstHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h failFirstHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if *h.n > 0 {
+			*h.n--
+			cmd.SetErr(errors.New("dial tcp: connection refused"))
+			return cmd.Err()
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h failFirstHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func (s *CapabilitiesTestSuite) TestCapabilitiesWorker_RetriesFailedProbe() {
+	ctx := context.Background()
+	failures := 1
+	s.client.AddHook(failFirstHook{n: &failures})
+	s.client.AddHook(denyHook{deny: map[string]bool{"memory": true}})
+
+	mon := New(s.client, "test_dlq")
+	var probes int
+	worker := mon.CapabilitiesWorker(time.Second, func(caps *RedisCapabilities) {
+		probes++
+		s.False(caps.MemoryUsage)
+	})
+
+	s.Error(worker.Run(ctx))
+	s.Nil(mon.Capabilities().ProbedAt)
+	s.Zero(probes)
+
+	s.Require().NoError(worker.Run(ctx))
+	s.NotNil(mon.Capabilities().ProbedAt)
+	s.False(mon.Capabilities().MemoryUsage)
+
+	// Once probed, later runs leave the capabilities alone.
+	s.Require().NoError(worker.Run(ctx))
+	s.Equal(1, probes)
+}
+
+func (s *CapabilitiesTestSuite) TestFallbacks() {
+	ctx := context.Background()
+	addTestMessage(s.T(), s.client, "orders.created", map[string]any{"id": 1})
+	addTestMessage(s.T(), s.client, "orders.created", map[string]any{"id": 2})
+	addTestMessage(s.T(), s.client, "test_dlq", map[string]any{"id": 3})
+	s.Require().NoError(s.client.Set(ctx, "orders.lock", "1", 0).Err())
+
+	s.client.AddHook(denyHook{deny: map[string]bool{"memory": true, "xinfo": true, "scan type": true}})
+	redisStream := NewRedisStream(s.client)
+	_, err := redisStream.ProbeCapabilities(ctx)
+	s.Require().NoError(err)
+
+	keys, err := redisStream.ScanStreams(ctx, "orders.*")
+	s.Require().NoError(err)
+	s.Equal([]StreamKey{{Name: "orders.created"}}, keys)
+
+	service := NewStreamService(redisStream, "test_dlq")
+	streams, err := service.GetStreams(ctx)
+	s.Require().NoError(err)
+	s.Require().Len(streams, 1)
+	s.Equal(int64(2), streams[0].Length)
+	s.Nil(streams[0].MemoryBytes)
+	s.NotNil(streams[0].LastEntryID)
+	s.NotNil(streams[0].LastActivity)
+	s.Empty(streams[0].Error)
+
+	detail, err := service.GetStreamDetail(ctx, "orders.created")
+	s.Require().NoError(err)
+	s.Equal(int64(2), detail.Length)
+	s.NotNil(detail.FirstEntryID)
+	s.Empty(detail.Error)
+
+	_, err = service.GetStreamDetail(ctx, "orders.missing")
+	s.Error(err)
+
+	stats, err := NewDLQService(redisStream, "test_dlq").GetStats(ctx)
+	s.Require().NoError(err)
+	s.Equal(int64(1), stats.Length)
+}
+
+func (s *CapabilitiesTestSuite) TestVersionAtLeast() {
+	caps := RedisCapabilities{Version: "7.2.4"}
+	s.True(caps.VersionAtLeast(7, 0))
+	s.True(caps.VersionAtLeast(6, 2))
+	s.False(caps.VersionAtLeast(7, 4))
+	s.False(RedisCapabilities{}.VersionAtLeast(5, 0))
+}
+
+func TestCapabilitiesTestSuite(t *testing.T) {
+	suite.Run(t, new(CapabilitiesTestSuite))
+}
@@ -0,0 +1,361 @@
+package monitor
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultCatalogMaxAge = 30 * time.Second
+	catalogBatchSize     = 100
+)
+
+// CatalogOpts selects the streams listed by a StreamService and how long
+// the cached list is served.
+type CatalogOpts struct {
+	// Include lists path.Match patterns of the streams to list. Empty
+	// includes every stream. A single pattern is also passed to SCAN as
+	// MATCH, so Redis skips other keys itself.
+	Include []string
+
+	// Exclude lists path.Match patterns of streams never listed, even when
+	// they match Include.
+	Exclude []string
+
+	// MaxAge is how old the cached list may get before a request refreshes
+	// it itself (30 seconds by default). Refreshing it in the background
+	// more often keeps requests from ever waiting on Redis.
+	MaxAge time.Duration
+}
+
+// Validate reports malformed patterns.
+func (o CatalogOpts) Validate() error {
+	for _, pattern := range slices.Concat(o.Include, o.Exclude) {
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return fmt.Errorf("invalid stream pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+// Allows reports whether the stream name is listed.
+func (o CatalogOpts) Allows(name string) bool {
+	for _, pattern := range o.Exclude {
+		if ok, _ := path.Match(pattern, name); ok {
+			return false
+		}
+	}
+
+	if len(o.Include) == 0 {
+		return true
+	}
+	for _, pattern := range o.Include {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (o CatalogOpts) scanMatch() string {
+	if len(o.Include) == 1 {
+		return o.Include[0]
+	}
+	return "*"
+}
+
+// streamCatalog caches the info of every listed stream, so listing them
+// does not cost a keyspace scan and two commands per stream each time.
+type streamCatalog struct {
+	monitor *RedisStream
+	dlqName string
+	opts    CatalogOpts
+	now     func() time.Time
+
+	refreshMu sync.Mutex
+
+	mu          sync.RWMutex
+	streams     []StreamInfo
+	refreshedAt time.Time
+}
+
+func newStreamCatalog(monitor *RedisStream, dlqName string, opts CatalogOpts) *streamCatalog {
+	if opts.MaxAge <= 0 {
+		opts.MaxAge = defaultCatalogMaxAge
+	}
+
+	return &streamCatalog{
+		monitor: monitor,
+		dlqName: dlqName,
+		opts:    opts,
+		now:     time.Now,
+	}
+}
+
+// get returns the cached streams, refreshing them first when they are
+// older than MaxAge.
+func (c *streamCatalog) get(ctx context.Context) ([]StreamInfo, time.Time, error) {
+	if streams, refreshedAt, ok := c.cached(); ok {
+		return streams, refreshedAt, nil
+	}
+
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	// Another request may have refreshed it while this one waited.
+	if streams, refreshedAt, ok := c.cached(); ok {
+		return streams, refreshedAt, nil
+	}
+
+	if err := c.refreshLocked(ctx); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	streams, refreshedAt, _ := c.cached()
+	return streams, refreshedAt, nil
+}
+
+func (c *streamCatalog) cached() ([]StreamInfo, time.Time, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	fresh := !c.refreshedAt.IsZero() && c.now().Sub(c.refreshedAt) < c.opts.MaxAge
+	return c.streams, c.refreshedAt, fresh
+}
+
+func (c *streamCatalog) refresh(ctx context.Context) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	return c.refreshLocked(ctx)
+}
+
+func (c *streamCatalog) refreshLocked(ctx context.Context) error {
+	keys, err := c.monitor.ScanStreams(ctx, c.opts.scanMatch())
+	if err != nil {
+		return err
+	}
+
+	keys = slices.DeleteFunc(keys, func(key StreamKey) bool {
+		return key.Name == c.dlqName || !c.opts.Allows(key.Name)
+	})
+
+	streams := make([]StreamInfo, 0, len(keys))
+	for batch := range slices.Chunk(keys, catalogBatchSize) {
+		infos, err := c.monitor.streamInfos(ctx, batch)
+		if err != nil {
+			return err
+		}
+		streams = append(streams, infos...)
+	}
+
+	c.mu.Lock()
+	c.streams = streams
+	c.refreshedAt = c.now()
+	c.mu.Unlock()
+
+	return nil
+}
+
+// streamInfos fetches the info of keys in one pipeline. A stream whose info
+// cannot be fully read is still returned, with the metrics that could be
+// read and the reasons for the others in Error; only streams deleted since
+// they were scanned are left out. Commands a probe found unavailable are
+// replaced or skipped as GetStreamInfo and memoryUsage do.
+func (r *RedisStream) streamInfos(ctx context.Context, keys []StreamKey) ([]StreamInfo, error) {
+	caps := r.Capabilities()
+	infoCommand := "XINFO STREAM"
+	if !caps.XInfo {
+		infoCommand = "XRANGE"
+	}
+
+	infoCmds := make([]func() (*redis.XInfoStream, error), len(keys))
+	lenCmds := make([]*redis.IntCmd, len(keys))
+	memoryCmds := make([]*redis.IntCmd, len(keys))
+
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			if caps.XInfo {
+				infoCmds[i] = pipe.XInfoStream(ctx, key.Name).Result
+				lenCmds[i] = pipe.XLen(ctx, key.Name)
+			} else {
+				infoCmds[i] = queueStreamInfoFallback(ctx, pipe, key.Name).Result
+			}
+			if caps.MemoryUsage {
+				memoryCmds[i] = pipe.MemoryUsage(ctx, key.Name)
+			}
+		}
+		return nil
+	})
+	// Commands failing on their own are reported per stream below; anything
+	// else, such as a lost connection, fails the whole batch.
+	var redisErr redis.Error
+	if err != nil && !errors.As(err, &redisErr) {
+		return nil, err
+	}
+
+	infos := make([]StreamInfo, 0, len(keys))
+	for i, key := range keys {
+		info := StreamInfo{
+			Name: key.Name,
+			Node: key.Node,
+			Slot: key.Slot,
+		}
+
+		var errs []string
+		meta, err := infoCmds[i]()
+		switch {
+		case isNoSuchKey(err):
+			continue
+		case err != nil:
+			errs = append(errs, infoCommand+": "+err.Error())
+			if lenCmds[i] == nil {
+				break
+			}
+			if length, err := lenCmds[i].Result(); err == nil {
+				info.Length = length
+			}
+		default:
+			info.Length = meta.Length
+			info.LastEntryID, info.LastActivity = lastEntry(meta)
+		}
+
+		if memoryCmds[i] != nil {
+			if memory, err := memoryCmds[i].Result(); err == nil {
+				info.MemoryBytes = &memory
+			} else if !errors.Is(err, redis.Nil) {
+				errs = append(errs, "MEMORY USAGE: "+err.Error())
+			}
+		}
+
+		info.Error = strings.Join(errs, "; ")
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+// lastEntry returns the ID of the newest entry of a stream and the time it
+// was added.
+func lastEntry(meta *redis.XInfoStream) (*string, *time.Time) {
+	if meta.LastEntry.ID == "" {
+		return nil, nil
+	}
+
+	id := meta.LastEntry.ID
+	ts, err := ParseStreamTimestamp(id)
+	if err != nil {
+		return &id, nil
+	}
+	return &id, ts
+}
+
+// errNoSuchKey is returned like the error of XINFO STREAM for a stream that
+// does not exist.
+var errNoSuchKey = errors.New("ERR no such key")
+
+func isNoSuchKey(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "no such key")
+}
+
+// listStreams filters, sorts and pages streams according to opts.
+func listStreams(streams []StreamInfo, opts StreamListOpts) (*StreamList, error) {
+	offset := 0
+	if opts.Cursor != "" {
+		n, err := strconv.Atoi(opts.Cursor)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid cursor: %q", opts.Cursor)
+		}
+		offset = n
+	}
+
+	if opts.Sort == "" {
+		opts.Sort = StreamSortName
+	}
+	if opts.Order == "" {
+		opts.Order = SortOrderAsc
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = 50
+	}
+
+	matched := make([]StreamInfo, 0, len(streams))
+	for _, stream := range streams {
+		if strings.Contains(stream.Name, opts.Query) && (opts.Allow == nil || opts.Allow(stream.Name)) {
+			matched = append(matched, stream)
+		}
+	}
+
+	slices.SortStableFunc(matched, func(a, b StreamInfo) int {
+		var c int
+		switch opts.Sort {
+		case StreamSortLength:
+			c = cmp.Compare(a.Length, b.Length)
+		case StreamSortMemory:
+			c = compareMemory(a.MemoryBytes, b.MemoryBytes)
+		case StreamSortLastActivity:
+			c = compareActivity(a.LastActivity, b.LastActivity)
+		}
+		if c == 0 {
+			c = strings.Compare(a.Name, b.Name)
+		}
+		if opts.Order == SortOrderDesc {
+			c = -c
+		}
+		return c
+	})
+
+	list := &StreamList{
+		Streams:    []StreamInfo{},
+		TotalCount: int64(len(matched)),
+	}
+
+	if offset < len(matched) {
+		end := min(offset+int(opts.Limit), len(matched))
+		list.Streams = matched[offset:end]
+		if end < len(matched) {
+			list.HasMore = true
+			list.NextCursor = strconv.Itoa(end)
+		}
+	}
+
+	return list, nil
+}
+
+// compareMemory orders streams of unknown size first.
+func compareMemory(a, b *int64) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	default:
+		return cmp.Compare(*a, *b)
+	}
+}
+
+// compareActivity orders streams that never had an entry first.
+func compareActivity(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	default:
+		return a.Compare(*b)
+	}
+}
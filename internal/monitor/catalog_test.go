@@ -0,0 +1,232 @@
+package monitor
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/suite"
+)
+
+type CatalogTestSuite struct {
+	suite.Suite
+	mr      *miniredis.Miniredis
+	client  redis.UniversalClient
+	dlqName string
+}
+
+func (s *CatalogTestSuite) SetupTest() {
+	s.mr = miniredis.RunT(s.T())
+	s.client = redis.NewClient(&redis.Options{Addr: s.mr.Addr()})
+	s.dlqName = "test_dlq"
+}
+
+func (s *CatalogTestSuite) TearDownTest() {
+	s.client.Close()
+	s.mr.Close()
+}
+
+func (s *CatalogTestSuite) service(opts CatalogOpts) *StreamService {
+	return NewStreamServiceWithCatalog(NewRedisStream(s.client), s.dlqName, opts)
+}
+
+func (s *CatalogTestSuite) names(streams []StreamInfo) []string {
+	names := make([]string, len(streams))
+	for i, stream := range streams {
+		names[i] = stream.Name
+	}
+	return names
+}
+
+func (s *CatalogTestSuite) TestCatalogOpts() {
+	opts := CatalogOpts{Include: []string{"orders.*", "payments.*"}, Exclude: []string{"*.tmp"}}
+	s.NoError(opts.Validate())
+	s.True(opts.Allows("orders.created"))
+	s.False(opts.Allows("orders.tmp"))
+	s.False(opts.Allows("users.created"))
+	s.True(CatalogOpts{}.Allows("anything"))
+
+	s.Error(CatalogOpts{Include: []string{"["}}.Validate())
+	s.Error(CatalogOpts{Exclude: []string{""}}.Validate())
+}
+
+func (s *CatalogTestSuite) TestGetStreams_Filters() {
+	ctx := context.Background()
+	for _, name := range []string{"orders.created", "orders.tmp", "payments.processed", "users.created"} {
+		addTestMessage(s.T(), s.client, name, map[string]any{"id": 1})
+	}
+
+	streams, err := s.service(CatalogOpts{Include: []string{"orders.*"}, Exclude: []string{"*.tmp"}}).GetStreams(ctx)
+	s.Require().NoError(err)
+	s.Equal([]string{"orders.created"}, s.names(streams))
+
+	streams, err = s.service(CatalogOpts{Include: []string{"orders.*", "payments.*"}}).GetStreams(ctx)
+	s.Require().NoError(err)
+	s.Equal([]string{"orders.created", "orders.tmp", "payments.processed"}, s.names(streams))
+}
+
+func (s *CatalogTestSuite) TestGetStreams_Cached() {
+	ctx := context.Background()
+	service := s.service(CatalogOpts{MaxAge: time.Minute})
+	now := time.Now()
+	service.catalog.now = func() time.Time { return now }
+
+	addTestMessage(s.T(), s.client, "orders.created", map[string]any{"id": 1})
+
+	streams, err := service.GetStreams(ctx)
+	s.Require().NoError(err)
+	s.Len(streams, 1)
+
+	addTestMessage(s.T(), s.client, "payments.processed", map[string]any{"id": 2})
+
+	streams, err = service.GetStreams(ctx)
+	s.Require().NoError(err)
+	s.Len(streams, 1)
+
+	now = now.Add(time.Minute)
+	streams, err = service.GetStreams(ctx)
+	s.Require().NoError(err)
+	s.Len(streams, 2)
+
+	addTestMessage(s.T(), s.client, "users.created", map[string]any{"id": 3})
+	s.Require().NoError(service.RefreshCatalog(ctx))
+
+	streams, err = service.GetStreams(ctx)
+	s.Require().NoError(err)
+	s.Len(streams, 3)
+}
+
+func (s *CatalogTestSuite) TestListStreams() {
+	ctx := context.Background()
+	service := s.service(CatalogOpts{})
+
+	for i, name := range []string{"b.stream", "a.stream", "c.stream"} {
+		for range i + 1 {
+			addTestMessage(s.T(), s.client, name, map[string]any{"id": i})
+		}
+	}
+
+	list, err := service.ListStreams(ctx, StreamListOpts{})
+	s.Require().NoError(err)
+	s.Equal([]string{"a.stream", "b.stream", "c.stream"}, s.names(list.Streams))
+	s.Equal(int64(3), list.TotalCount)
+	s.False(list.HasMore)
+	s.False(list.RefreshedAt.IsZero())
+
+	list, err = service.ListStreams(ctx, StreamListOpts{Sort: StreamSortLength, Order: SortOrderDesc, Limit: 2})
+	s.Require().NoError(err)
+	s.Equal([]string{"c.stream", "a.stream"}, s.names(list.Streams))
+	s.True(list.HasMore)
+	s.Equal("2", list.NextCursor)
+
+	list, err = service.ListStreams(ctx, StreamListOpts{Sort: StreamSortLength, Order: SortOrderDesc, Limit: 2, Cursor: list.NextCursor})
+	s.Require().NoError(err)
+	s.Equal([]string{"b.stream"}, s.names(list.Streams))
+	s.False(list.HasMore)
+
+	list, err = service.ListStreams(ctx, StreamListOpts{Sort: StreamSortLastActivity, Order: SortOrderDesc})
+	s.Require().NoError(err)
+	s.Equal("c.stream", list.Streams[0].Name)
+
+	list, err = service.ListStreams(ctx, StreamListOpts{
+		Query: ".stream",
+		Allow: func(name string) bool { return !strings.HasPrefix(name, "a.") },
+	})
+	s.Require().NoError(err)
+	s.Equal([]string{"b.stream", "c.stream"}, s.names(list.Streams))
+	s.Equal(int64(2), list.TotalCount)
+
+	list, err = service.ListStreams(ctx, StreamListOpts{Cursor: "10"})
+	s.Require().NoError(err)
+	s.Empty(list.Streams)
+
+	_, err = service.ListStreams(ctx, StreamListOpts{Cursor: "x"})
+	s.Error(err)
+}
+
+// noPermError mimics the error Redis returns for a command an ACL forbids.
+type noPermError string
+
+func (e noPermError) Error() string { return string(e) }
+func (noPermError) RedisError()     {}
+
+// denyHook fails the commands named in deny as an ACL would. "scan type"
+// only denies SCAN with a TYPE, as servers older than Redis 6 do.
+type denyHook struct {
+	deny map[string]bool
+}
+
+func (h denyHook) denies(cmd redis.Cmder) bool {
+	if cmd.Name() == "scan" && slices.Contains(cmd.Args(), any("type")) {
+		return h.deny["scan type"]
+	}
+	return h.deny[cmd.Name()]
+}
+
+func (denyHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h denyHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if h.denies(cmd) {
+			cmd.SetErr(noPermError("NOPERM this user has no permissions to run the '" + cmd.Name() + "' command"))
+			return cmd.Err()
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h denyHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		err := next(ctx, cmds)
+		for _, cmd := range cmds {
+			if h.denies(cmd) {
+				cmd.SetErr(noPermError("NOPERM this user has no permissions to run the '" + cmd.Name() + "' command"))
+				err = cmd.Err()
+			}
+		}
+		return err
+	}
+}
+
+func (s *CatalogTestSuite) TestGetStreams_Degraded() {
+	ctx := context.Background()
+	addTestMessage(s.T(), s.client, "orders.created", map[string]any{"id": 1})
+	addTestMessage(s.T(), s.client, "orders.created", map[string]any{"id": 2})
+
+	s.client.AddHook(denyHook{deny: map[string]bool{"memory": true}})
+
+	streams, err := s.service(CatalogOpts{}).GetStreams(ctx)
+	s.Require().NoError(err)
+	s.Require().Len(streams, 1)
+	s.Nil(streams[0].MemoryBytes)
+	s.Equal(int64(2), streams[0].Length)
+	s.Contains(streams[0].Error, "MEMORY USAGE: NOPERM")
+
+	detail, err := s.service(CatalogOpts{}).GetStreamDetail(ctx, "orders.created")
+	s.Require().NoError(err)
+	s.Nil(detail.MemoryBytes)
+	s.Contains(detail.Error, "MEMORY USAGE: NOPERM")
+}
+
+func (s *CatalogTestSuite) TestGetStreams_InfoDenied() {
+	ctx := context.Background()
+	addTestMessage(s.T(), s.client, "orders.created", map[string]any{"id": 1})
+
+	s.client.AddHook(denyHook{deny: map[string]bool{"xinfo": true}})
+
+	streams, err := s.service(CatalogOpts{}).GetStreams(ctx)
+	s.Require().NoError(err)
+	s.Require().Len(streams, 1)
+	s.Equal(int64(1), streams[0].Length)
+	s.NotNil(streams[0].MemoryBytes)
+	s.Nil(streams[0].LastActivity)
+	s.Contains(streams[0].Error, "XINFO STREAM: NOPERM")
+}
+
+func TestCatalogTestSuite(t *testing.T) {
+	suite.Run(t, new(CatalogTestSuite))
+}
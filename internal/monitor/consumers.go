@@ -0,0 +1,285 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultIdleThreshold is how long a consumer holding pending entries may
+// stay idle before it is reported as stuck.
+const DefaultIdleThreshold = 5 * time.Minute
+
+// maxCountedLag bounds how many undelivered entries are counted with
+// XRANGE when Redis does not report the lag itself.
+const maxCountedLag = 1000
+
+// Where the lag of a group comes from: reported by Redis 7 and later, or
+// counted with XRANGE from the last delivered ID.
+const (
+	LagSourceRedis = "redis"
+	LagSourceRange = "range"
+)
+
+// ConsumerHealthOpts configures GetHealth. A zero IdleThreshold uses
+// DefaultIdleThreshold. Allow, when set, keeps only the streams it accepts.
+type ConsumerHealthOpts struct {
+	IdleThreshold time.Duration
+	Allow         func(stream string) bool
+}
+
+// ConsumerHealth summarizes every consumer group of the listed streams.
+// It is healthy when no consumer is stuck and every group could be read.
+type ConsumerHealth struct {
+	Healthy         bool          `json:"healthy"`
+	TotalLag        int64         `json:"total_lag"`
+	TotalPending    int64         `json:"total_pending"`
+	StuckConsumers  int           `json:"stuck_consumers"`
+	IdleThresholdMS int64         `json:"idle_threshold_ms"`
+	CheckedAt       time.Time     `json:"checked_at"`
+	Groups          []GroupHealth `json:"groups"`
+}
+
+// GroupHealth is how far behind a consumer group is. Lag counts the
+// entries not yet delivered to it, and is nil when unknown; when
+// LagCapped is set, it is only a lower bound. LagSeconds is the age of the
+// oldest of those entries. Error is set, with Group empty when the groups
+// of the stream could not be listed, instead of the other fields when the
+// group could not be read.
+type GroupHealth struct {
+	Stream          string           `json:"stream"`
+	Group           string           `json:"group,omitempty"`
+	LastDeliveredID string           `json:"last_delivered_id,omitempty"`
+	EntriesRead     *int64           `json:"entries_read,omitempty"`
+	Lag             *int64           `json:"lag"`
+	LagSource       string           `json:"lag_source,omitempty"`
+	LagCapped       bool             `json:"lag_capped,omitempty"`
+	LagSeconds      *int64           `json:"lag_seconds,omitempty"`
+	Pending         int64            `json:"pending"`
+	Consumers       []ConsumerStatus `json:"consumers,omitempty"`
+	Error           string           `json:"error,omitempty"`
+}
+
+// ConsumerStatus is a consumer of a group. IdleMS is the time since it
+// last interacted with the group, or since it last did so successfully on
+// Redis 7.2 and later. A consumer is stuck when it holds pending entries
+// and has been idle longer than the threshold.
+type ConsumerStatus struct {
+	Name    string `json:"name"`
+	Pending int64  `json:"pending"`
+	IdleMS  int64  `json:"idle_ms"`
+	Stuck   bool   `json:"stuck"`
+}
+
+// ConsumerService reads the progress of the consumer groups of the listed
+// streams.
+type ConsumerService struct {
+	monitor *RedisStream
+	streams *StreamService
+}
+
+func NewConsumerService(monitor *RedisStream, streams *StreamService) *ConsumerService {
+	return &ConsumerService{monitor: monitor, streams: streams}
+}
+
+// GetHealth reads the lag and consumers of every group of the streams in
+// the catalog. Redis 7 reports the lag of most groups; it is counted with
+// XRANGE for the others, up to a bound.
+func (s *ConsumerService) GetHealth(ctx context.Context, opts ConsumerHealthOpts) (*ConsumerHealth, error) {
+	if opts.IdleThreshold <= 0 {
+		opts.IdleThreshold = DefaultIdleThreshold
+	}
+
+	streams, err := s.streams.GetStreams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	caps := s.monitor.Capabilities()
+	results := make([][]GroupHealth, len(streams))
+
+	errG, grpCtx := errgroup.WithContext(ctx)
+	errG.SetLimit(10)
+	for i, stream := range streams {
+		if opts.Allow != nil && !opts.Allow(stream.Name) {
+			continue
+		}
+		errG.Go(func() error {
+			groups, err := s.streamHealth(grpCtx, stream.Name, caps, opts.IdleThreshold)
+			results[i] = groups
+			return err
+		})
+	}
+	if err := errG.Wait(); err != nil {
+		return nil, err
+	}
+
+	health := &ConsumerHealth{
+		Healthy:         true,
+		IdleThresholdMS: opts.IdleThreshold.Milliseconds(),
+		CheckedAt:       time.Now().UTC(),
+		Groups:          []GroupHealth{},
+	}
+	for _, groups := range results {
+		for _, group := range groups {
+			if group.Error != "" {
+				health.Healthy = false
+			}
+			if group.Lag != nil {
+				health.TotalLag += *group.Lag
+			}
+			health.TotalPending += group.Pending
+			for _, consumer := range group.Consumers {
+				if consumer.Stuck {
+					health.StuckConsumers++
+					health.Healthy = false
+				}
+			}
+			health.Groups = append(health.Groups, group)
+		}
+	}
+
+	sort.Slice(health.Groups, func(i, j int) bool {
+		a, b := health.Groups[i], health.Groups[j]
+		if a.Stream != b.Stream {
+			return a.Stream < b.Stream
+		}
+		return a.Group < b.Group
+	})
+
+	return health, nil
+}
+
+// streamHealth reads the groups of stream. Commands Redis refuses are
+// reported in the result; other errors fail it.
+func (s *ConsumerService) streamHealth(ctx context.Context, stream string, caps RedisCapabilities, idleThreshold time.Duration) ([]GroupHealth, error) {
+	groups, err := s.monitor.GetGroups(ctx, stream)
+	switch {
+	case err == nil:
+	case isNoSuchKey(err):
+		// Deleted since the catalog was refreshed.
+		return nil, nil
+	case unavailable(err):
+		return []GroupHealth{{Stream: stream, Error: "XINFO GROUPS: " + err.Error()}}, nil
+	default:
+		return nil, err
+	}
+
+	result := make([]GroupHealth, 0, len(groups))
+	for _, group := range groups {
+		health, err := s.groupHealth(ctx, stream, group, caps, idleThreshold)
+		if err != nil {
+			if !unavailable(err) {
+				return nil, err
+			}
+			health = GroupHealth{Stream: stream, Group: group.Name, Error: err.Error()}
+		}
+		result = append(result, health)
+	}
+
+	return result, nil
+}
+
+func (s *ConsumerService) groupHealth(ctx context.Context, stream string, group redis.XInfoGroup, caps RedisCapabilities, idleThreshold time.Duration) (GroupHealth, error) {
+	health := GroupHealth{
+		Stream:          stream,
+		Group:           group.Name,
+		LastDeliveredID: group.LastDeliveredID,
+		Pending:         group.Pending,
+	}
+
+	// Before Redis 7 there is no lag to read, and a null one is reported
+	// as -1 when Redis 7 cannot determine it, e.g. after entries were
+	// deleted.
+	native := caps.VersionAtLeast(7, 0) && group.Lag >= 0
+	if native {
+		lag, entriesRead := group.Lag, group.EntriesRead
+		health.Lag = &lag
+		health.EntriesRead = &entriesRead
+		health.LagSource = LagSourceRedis
+	}
+
+	if !native || group.Lag > 0 {
+		if err := s.countLag(ctx, &health, native); err != nil {
+			return health, fmt.Errorf("XRANGE: %w", err)
+		}
+	}
+
+	consumers, err := s.monitor.GetConsumers(ctx, stream, group.Name)
+	if err != nil {
+		return health, fmt.Errorf("XINFO CONSUMERS: %w", err)
+	}
+
+	health.Consumers = make([]ConsumerStatus, len(consumers))
+	for i, c := range consumers {
+		idle := c.Idle
+		if caps.VersionAtLeast(7, 2) && c.Inactive >= 0 {
+			idle = c.Inactive
+		}
+		health.Consumers[i] = ConsumerStatus{
+			Name:    c.Name,
+			Pending: c.Pending,
+			IdleMS:  idle.Milliseconds(),
+			Stuck:   c.Pending > 0 && idle > idleThreshold,
+		}
+	}
+
+	return health, nil
+}
+
+// countLag reads the entries after the last delivered ID of the group to
+// find how old the oldest undelivered one is and, unless Redis reported
+// the lag, how many there are, up to maxCountedLag. When there are more
+// and they include the first entry of the stream, every entry is
+// undelivered and the lag is its length.
+func (s *ConsumerService) countLag(ctx context.Context, health *GroupHealth, native bool) error {
+	limit := int64(1)
+	if !native {
+		limit = maxCountedLag + 1
+	}
+
+	undelivered, err := s.monitor.ReadMessages(ctx, health.Stream, PaginationOpts{
+		Cursor: health.LastDeliveredID,
+		Limit:  limit,
+		Order:  SortOrderAsc,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(undelivered) > 0 {
+		if t, err := ParseStreamTimestamp(undelivered[0].ID); err == nil {
+			seconds := int64(max(time.Since(*t), 0) / time.Second)
+			health.LagSeconds = &seconds
+		}
+	}
+
+	if native {
+		return nil
+	}
+
+	lag := int64(len(undelivered))
+	if lag > maxCountedLag {
+		lag = maxCountedLag
+		health.LagCapped = true
+
+		first, err := s.monitor.ReadMessages(ctx, health.Stream, PaginationOpts{Limit: 1, Order: SortOrderAsc})
+		if err != nil {
+			return err
+		}
+		if len(first) > 0 && first[0].ID == undelivered[0].ID {
+			if lag, err = s.monitor.GetStreamLength(ctx, health.Stream); err != nil {
+				return err
+			}
+			health.LagCapped = false
+		}
+	}
+
+	health.Lag = &lag
+	health.LagSource = LagSourceRange
+	return nil
+}
@@ -0,0 +1,190 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/suite"
+)
+
+type ConsumersTestSuite struct {
+	suite.Suite
+	mr      *miniredis.Miniredis
+	client  redis.UniversalClient
+	redis   *RedisStream
+	service *ConsumerService
+}
+
+func (s *ConsumersTestSuite) SetupTest() {
+	s.mr = miniredis.RunT(s.T())
+	s.client = redis.NewClient(&redis.Options{Addr: s.mr.Addr()})
+	s.redis = NewRedisStream(s.client)
+	s.service = NewConsumerService(s.redis, NewStreamService(s.redis, "test_dlq"))
+}
+
+func (s *ConsumersTestSuite) TearDownTest() {
+	s.client.Close()
+	s.mr.Close()
+}
+
+// readGroup creates group at start and has consumer read count entries
+// without acknowledging them.
+func (s *ConsumersTestSuite) readGroup(stream, group, start, consumer string, count int64) {
+	ctx := context.Background()
+	s.Require().NoError(s.client.XGroupCreate(ctx, stream, group, start).Err())
+	if count == 0 {
+		return
+	}
+
+	read, err := s.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  []string{stream, ">"},
+		Count:    count,
+	}).Result()
+	s.Require().NoError(err)
+
+	// miniredis only tracks when a consumer was last seen on XCLAIM.
+	ids := make([]string, len(read[0].Messages))
+	for i, msg := range read[0].Messages {
+		ids[i] = msg.ID
+	}
+	s.Require().NoError(s.client.XClaim(ctx, &redis.XClaimArgs{
+		Stream:   stream,
+		Group:    group,
+		Consumer: consumer,
+		Messages: ids,
+	}).Err())
+}
+
+func (s *ConsumersTestSuite) group(health *ConsumerHealth, stream, group string) GroupHealth {
+	for _, g := range health.Groups {
+		if g.Stream == stream && g.Group == group {
+			return g
+		}
+	}
+	s.FailNow("group not found", "%s/%s", stream, group)
+	return GroupHealth{}
+}
+
+func (s *ConsumersTestSuite) TestGetHealth_RangeLag() {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	s.mr.SetTime(now)
+
+	for i := range 3 {
+		addTestMessage(s.T(), s.client, "orders.created", map[string]any{"id": i})
+	}
+	s.readGroup("orders.created", "billing", "0", "worker-1", 1)
+	s.readGroup("orders.created", "audit", "$", "", 0)
+
+	health, err := s.service.GetHealth(ctx, ConsumerHealthOpts{})
+	s.Require().NoError(err)
+	s.True(health.Healthy)
+	s.Equal(DefaultIdleThreshold.Milliseconds(), health.IdleThresholdMS)
+	s.Equal(int64(2), health.TotalLag)
+	s.Equal(int64(1), health.TotalPending)
+
+	billing := s.group(health, "orders.created", "billing")
+	s.Equal(LagSourceRange, billing.LagSource)
+	s.Require().NotNil(billing.Lag)
+	s.Equal(int64(2), *billing.Lag)
+	s.False(billing.LagCapped)
+	s.NotNil(billing.LagSeconds)
+	s.Nil(billing.EntriesRead)
+	s.Require().Len(billing.Consumers, 1)
+	s.False(billing.Consumers[0].Stuck)
+
+	audit := s.group(health, "orders.created", "audit")
+	s.Equal(int64(0), *audit.Lag)
+	s.Nil(audit.LagSeconds)
+
+	s.mr.SetTime(now.Add(10 * time.Minute))
+
+	health, err = s.service.GetHealth(ctx, ConsumerHealthOpts{})
+	s.Require().NoError(err)
+	s.False(health.Healthy)
+	s.Equal(1, health.StuckConsumers)
+	s.True(s.group(health, "orders.created", "billing").Consumers[0].Stuck)
+
+	health, err = s.service.GetHealth(ctx, ConsumerHealthOpts{IdleThreshold: time.Hour})
+	s.Require().NoError(err)
+	s.True(health.Healthy)
+}
+
+func (s *ConsumersTestSuite) TestGetHealth_NativeLag() {
+	ctx := context.Background()
+	for i := range 3 {
+		addTestMessage(s.T(), s.client, "orders.created", map[string]any{"id": i})
+	}
+	s.readGroup("orders.created", "billing", "0", "", 0)
+
+	s.redis.caps.Store(&RedisCapabilities{Version: "7.2.4", ScanType: true, MemoryUsage: true, XInfo: true})
+
+	health, err := s.service.GetHealth(ctx, ConsumerHealthOpts{})
+	s.Require().NoError(err)
+
+	billing := s.group(health, "orders.created", "billing")
+	s.Equal(LagSourceRedis, billing.LagSource)
+	s.Equal(int64(3), *billing.Lag)
+	s.NotNil(billing.EntriesRead)
+	s.NotNil(billing.LagSeconds)
+}
+
+func (s *ConsumersTestSuite) TestGetHealth_CappedLag() {
+	ctx := context.Background()
+	for i := range maxCountedLag + 2 {
+		addTestMessage(s.T(), s.client, "orders.created", map[string]any{"id": i})
+	}
+	s.readGroup("orders.created", "billing", "0", "", 0)
+	s.readGroup("orders.created", "audit", "0", "worker-1", 1)
+
+	health, err := s.service.GetHealth(ctx, ConsumerHealthOpts{})
+	s.Require().NoError(err)
+
+	// Nothing was delivered, so the lag is the whole stream.
+	billing := s.group(health, "orders.created", "billing")
+	s.Equal(int64(maxCountedLag+2), *billing.Lag)
+	s.False(billing.LagCapped)
+
+	audit := s.group(health, "orders.created", "audit")
+	s.Equal(int64(maxCountedLag), *audit.Lag)
+	s.True(audit.LagCapped)
+}
+
+func (s *ConsumersTestSuite) TestGetHealth_Filtered() {
+	ctx := context.Background()
+	addTestMessage(s.T(), s.client, "orders.created", map[string]any{"id": 1})
+	addTestMessage(s.T(), s.client, "payments.processed", map[string]any{"id": 2})
+	s.readGroup("orders.created", "billing", "0", "", 0)
+	s.readGroup("payments.processed", "billing", "0", "", 0)
+
+	health, err := s.service.GetHealth(ctx, ConsumerHealthOpts{
+		Allow: func(stream string) bool { return stream == "payments.processed" },
+	})
+	s.Require().NoError(err)
+	s.Require().Len(health.Groups, 1)
+	s.Equal("payments.processed", health.Groups[0].Stream)
+}
+
+func (s *ConsumersTestSuite) TestGetHealth_Denied() {
+	ctx := context.Background()
+	addTestMessage(s.T(), s.client, "orders.created", map[string]any{"id": 1})
+	s.readGroup("orders.created", "billing", "0", "", 0)
+
+	s.client.AddHook(denyHook{deny: map[string]bool{"xinfo": true}})
+
+	health, err := s.service.GetHealth(ctx, ConsumerHealthOpts{})
+	s.Require().NoError(err)
+	s.False(health.Healthy)
+	s.Require().Len(health.Groups, 1)
+	s.Empty(health.Groups[0].Group)
+	s.Contains(health.Groups[0].Error, "XINFO GROUPS: NOPERM")
+}
+
+func TestConsumersTestSuite(t *testing.T) {
+	suite.Run(t, new(ConsumersTestSuite))
+}
@@ -4,14 +4,18 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"sync/atomic"
-	"time"
 
 	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
 	"github.com/vmihailenco/msgpack"
 	"golang.org/x/sync/errgroup"
 )
 
+// dlqScanPageSize is how many messages a filtered pass reads at a time.
+const dlqScanPageSize = 500
+
 type DLQService struct {
 	monitor *RedisStream
 	dlqName string
@@ -24,30 +28,19 @@ func NewDLQService(monitor *RedisStream, dlqName string) *DLQService {
 	}
 }
 
+// Name returns the name of the DLQ stream.
+func (d *DLQService) Name() string {
+	return d.dlqName
+}
+
 func (d *DLQService) GetStats(ctx context.Context) (*StreamInfo, error) {
 	meta, err := d.monitor.GetStreamInfo(ctx, d.dlqName)
 	if err != nil {
 		return nil, err
 	}
 
-	memory, err := d.monitor.GetMemoryUsage(ctx, d.dlqName)
-	if err != nil {
-		return nil, err
-	}
-
-	var (
-		lastEntryID  *string
-		lastActivity *time.Time
-	)
-
-	if meta.LastEntry.ID != "" {
-		id := meta.LastEntry.ID
-		lastEntryID = &id
-
-		if ts, err := ParseStreamTimestamp(id); err == nil {
-			lastActivity = ts
-		}
-	}
+	memory, memoryErr := d.monitor.memoryUsage(ctx, d.dlqName)
+	lastEntryID, lastActivity := lastEntry(meta)
 
 	return &StreamInfo{
 		Name:         d.dlqName,
@@ -55,6 +48,7 @@ func (d *DLQService) GetStats(ctx context.Context) (*StreamInfo, error) {
 		MemoryBytes:  memory,
 		LastEntryID:  lastEntryID,
 		LastActivity: lastActivity,
+		Error:        memoryErr,
 	}, nil
 }
 
@@ -92,6 +86,98 @@ func (d *DLQService) GetMessages(ctx context.Context, opts PaginationOpts) (*Mes
 	}, nil
 }
 
+// GetAllowedMessages is like GetMessages but keeps only the messages whose
+// original topic allow accepts. TotalCount counts the allowed messages of
+// the whole DLQ, read in the same pass as the page.
+func (d *DLQService) GetAllowedMessages(ctx context.Context, opts PaginationOpts, allow func(topic string) bool) (*MessageList[DLQMessage], error) {
+	opts = opts.WithDefaults()
+
+	var (
+		result     = make([]DLQMessage, 0, opts.Limit)
+		totalCount int64
+		remaining  int64
+	)
+	err := d.monitor.ScanMessages(ctx, d.dlqName, PaginationOpts{Limit: dlqScanPageSize, Order: opts.Order}, func(msg redis.XMessage) error {
+		dlqMsg, err := d.parseMessage(msg.ID, msg.Values)
+		if err != nil {
+			return err
+		}
+		if !allow(dlqMsg.OriginalTopic) {
+			return nil
+		}
+		totalCount++
+
+		if opts.Cursor != "" && !pastCursor(msg.ID, opts.Cursor, opts.Order) {
+			return nil
+		}
+		remaining++
+		if len(result) < int(opts.Limit) {
+			result = append(result, *dlqMsg)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hasMore := remaining > int64(len(result))
+	var nextCursor string
+	if hasMore {
+		nextCursor = result[len(result)-1].ID
+	}
+
+	return &MessageList[DLQMessage]{
+		Messages:   result,
+		TotalCount: totalCount,
+		HasMore:    hasMore,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+// GetAllowedStats is like GetStats but only counts the messages whose
+// original topic allow accepts. Their memory is not known.
+func (d *DLQService) GetAllowedStats(ctx context.Context, allow func(topic string) bool) (*StreamInfo, error) {
+	stats := &StreamInfo{Name: d.dlqName}
+	err := d.monitor.ScanMessages(ctx, d.dlqName, PaginationOpts{Limit: dlqScanPageSize, Order: SortOrderDesc}, func(msg redis.XMessage) error {
+		dlqMsg, err := d.parseMessage(msg.ID, msg.Values)
+		if err != nil {
+			return err
+		}
+		if !allow(dlqMsg.OriginalTopic) {
+			return nil
+		}
+
+		if stats.LastEntryID == nil {
+			id := msg.ID
+			stats.LastEntryID = &id
+			stats.LastActivity = &dlqMsg.Timestamp
+		}
+		stats.Length++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// CountMessages counts the DLQ messages whose original topic allow accepts.
+func (d *DLQService) CountMessages(ctx context.Context, allow func(topic string) bool) (int64, error) {
+	stats, err := d.GetAllowedStats(ctx, allow)
+	if err != nil {
+		return 0, err
+	}
+	return stats.Length, nil
+}
+
+// pastCursor reports whether id comes after cursor in order.
+func pastCursor(id, cursor string, order SortOrder) bool {
+	if order == SortOrderAsc {
+		return compareStreamIDs(id, cursor) > 0
+	}
+	return compareStreamIDs(id, cursor) < 0
+}
+
 func (d *DLQService) GetMessage(ctx context.Context, id string) (*DLQMessage, error) {
 	msg, err := d.monitor.ReadMessage(ctx, d.dlqName, id)
 	if err != nil {
@@ -164,24 +250,19 @@ func (d *DLQService) RequeueAll(ctx context.Context) (int64, error) {
 }
 
 func (d *DLQService) requeue(ctx context.Context, msg *DLQMessage) error {
+	return d.requeueWithMetadata(ctx, msg, nil)
+}
+
+// requeueWithMetadata publishes msg back to its original topic with the
+// given metadata and removes it from the DLQ.
+func (d *DLQService) requeueWithMetadata(ctx context.Context, msg *DLQMessage, metadata map[string]string) error {
 	if msg.OriginalTopic == "" {
 		return fmt.Errorf("original topic not found in message metadata")
 	}
 
-	payloadBytes, err := json.Marshal(msg.Payload)
+	newMsg, err := watermillFields("", msg.Payload, metadata)
 	if err != nil {
-		return fmt.Errorf("failed to marshal payload: %w", err)
-	}
-
-	metadataBytes, err := msgpack.Marshal(map[string]string{})
-	if err != nil {
-		return fmt.Errorf("failed to marshal metadata: %w", err)
-	}
-
-	newMsg := map[string]any{
-		WatermillUUIDKey:     generateUUID(),
-		WatermillPayloadKey:  string(payloadBytes),
-		WatermillMetadataKey: string(metadataBytes),
+		return err
 	}
 
 	_, err = d.monitor.AddMessage(ctx, msg.OriginalTopic, newMsg)
@@ -207,15 +288,60 @@ func (d *DLQService) parseMessage(id string, values map[string]any) (*DLQMessage
 		return nil, err
 	}
 
+	attempts, _ := strconv.Atoi(wmMsg.Metadata[RetryAttemptKey])
+
 	return &DLQMessage{
 		ID:            id,
 		Payload:       wmMsg.Payload,
+		Metadata:      wmMsg.Metadata,
 		Timestamp:     *ts,
 		OriginalTopic: wmMsg.Metadata[TopicPoisonedKey],
 		Error:         wmMsg.Metadata[ReasonPoisonedKey],
+		Attempts:      attempts,
 	}, nil
 }
 
+// withoutPoisonMetadata returns a copy of metadata without the keys the
+// poison queue adds.
+func withoutPoisonMetadata(metadata map[string]string) map[string]string {
+	clean := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		clean[k] = v
+	}
+	for _, key := range []string{ReasonPoisonedKey, TopicPoisonedKey, HandlerPoisonedKey, SubscriberPoisonedKey} {
+		delete(clean, key)
+	}
+	return clean
+}
+
 func generateUUID() string {
 	return uuid.New().String()
 }
+
+// watermillFields encodes a message the way Watermill's Redis Streams
+// publisher does, generating a UUID when none is given.
+func watermillFields(uuid string, payload map[string]any, metadata map[string]string) (map[string]any, error) {
+	if uuid == "" {
+		uuid = generateUUID()
+	}
+
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	metadataBytes, err := msgpack.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	return map[string]any{
+		WatermillUUIDKey:     uuid,
+		WatermillPayloadKey:  string(payloadBytes),
+		WatermillMetadataKey: string(metadataBytes),
+	}, nil
+}
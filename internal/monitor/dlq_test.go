@@ -59,6 +59,52 @@ func (s *DLQTestSuite) TestGetMessages() {
 	s.Contains(topics, "payments.processed")
 }
 
+func (s *DLQTestSuite) TestGetAllowedMessages() {
+	ctx := context.Background()
+
+	for i := range 3 {
+		addDLQMessage(s.T(), s.client, s.dlqName, "orders.created", map[string]any{"id": i})
+		addDLQMessage(s.T(), s.client, s.dlqName, "payments.processed", map[string]any{"id": i})
+	}
+	allow := func(topic string) bool { return topic == "payments.processed" }
+
+	// Each page of two skips the orders in between.
+	msgs, err := s.service.GetAllowedMessages(ctx, PaginationOpts{Limit: 2, Order: SortOrderAsc}, allow)
+	s.Require().NoError(err)
+	s.Require().Len(msgs.Messages, 2)
+	s.Equal(int64(3), msgs.TotalCount)
+	s.True(msgs.HasMore)
+	for _, msg := range msgs.Messages {
+		s.Equal("payments.processed", msg.OriginalTopic)
+	}
+
+	msgs, err = s.service.GetAllowedMessages(ctx, PaginationOpts{Limit: 2, Order: SortOrderAsc, Cursor: msgs.NextCursor}, allow)
+	s.Require().NoError(err)
+	s.Require().Len(msgs.Messages, 1)
+	s.Equal("payments.processed", msgs.Messages[0].OriginalTopic)
+	s.Equal(int64(3), msgs.TotalCount)
+	s.False(msgs.HasMore)
+
+	newest, err := s.service.GetAllowedMessages(ctx, PaginationOpts{Limit: 1, Order: SortOrderDesc}, allow)
+	s.Require().NoError(err)
+	s.Equal(msgs.Messages[0].ID, newest.Messages[0].ID)
+	s.True(newest.HasMore)
+}
+
+func (s *DLQTestSuite) TestGetAllowedStats() {
+	ctx := context.Background()
+
+	addDLQMessage(s.T(), s.client, s.dlqName, "payments.processed", map[string]any{"id": 1})
+	paymentsID := addDLQMessage(s.T(), s.client, s.dlqName, "payments.processed", map[string]any{"id": 2})
+	addDLQMessage(s.T(), s.client, s.dlqName, "orders.created", map[string]any{"id": 3})
+
+	stats, err := s.service.GetAllowedStats(ctx, func(topic string) bool { return topic == "payments.processed" })
+	s.Require().NoError(err)
+	s.Equal(int64(2), stats.Length)
+	s.Equal(paymentsID, *stats.LastEntryID)
+	s.Nil(stats.MemoryBytes)
+}
+
 func (s *DLQTestSuite) TestRequeueMessage() {
 	ctx := context.Background()
 
@@ -0,0 +1,301 @@
+package monitor
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const exportPageSize = 100
+
+// Export writes every message of stream selected by opts to w in format,
+// reading the stream one page at a time.
+func (s *StreamService) Export(ctx context.Context, stream string, format ExportFormat, opts ExportOpts, w io.Writer) error {
+	return exportStream(ctx, s.monitor, stream, format, opts, w)
+}
+
+// Export writes every DLQ message selected by opts to w in format.
+func (d *DLQService) Export(ctx context.Context, format ExportFormat, opts ExportOpts, w io.Writer) error {
+	return exportStream(ctx, d.monitor, d.dlqName, format, opts, w)
+}
+
+func exportStream(ctx context.Context, r *RedisStream, stream string, format ExportFormat, opts ExportOpts, w io.Writer) error {
+	switch format {
+	case ExportFormatNdjson:
+		return exportNDJSON(ctx, r, stream, opts, w)
+	case ExportFormatCsv:
+		return exportCSV(ctx, r, stream, opts, w)
+	case ExportFormatArchive:
+		return exportArchive(ctx, r, stream, opts, w)
+	default:
+		return fmt.Errorf("invalid export format: %s", format)
+	}
+}
+
+// eachRecord calls fn for every record selected by opts.
+func eachRecord(ctx context.Context, r *RedisStream, stream string, opts ExportOpts, fn func(ExportRecord) error) error {
+	pagination := PaginationOpts{
+		Cursor: opts.Cursor,
+		Limit:  exportPageSize,
+		Order:  opts.Order,
+	}
+
+	return r.ScanMessages(ctx, stream, pagination, func(msg redis.XMessage) error {
+		record, err := newExportRecord(stream, msg)
+		if err != nil {
+			return err
+		}
+
+		if opts.Filter != nil && !opts.Filter(*record) {
+			return nil
+		}
+
+		return fn(*record)
+	})
+}
+
+func newExportRecord(stream string, msg redis.XMessage) (*ExportRecord, error) {
+	ts, err := ParseStreamTimestamp(msg.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &ExportRecord{
+		ID:        msg.ID,
+		Stream:    stream,
+		Timestamp: *ts,
+	}
+	record.Fields, record.Base64Fields = encodeFields(msg.Values)
+
+	// Entries that are not valid Watermill messages are still exported
+	// through their raw fields.
+	if wmMsg, err := ParseWatermillMessage(msg.Values); err == nil {
+		record.UUID = wmMsg.UUID
+		record.Payload = wmMsg.Payload
+		record.Metadata = wmMsg.Metadata
+	}
+
+	return record, nil
+}
+
+// encodeFields returns a copy of the raw fields of an entry that survives
+// JSON encoding: values that are not valid UTF-8 are base64 encoded, and
+// their names returned sorted.
+func encodeFields(values map[string]any) (map[string]any, []string) {
+	fields := make(map[string]any, len(values))
+	var encoded []string
+	for k, v := range values {
+		if str, ok := v.(string); ok && !utf8.ValidString(str) {
+			v = base64.StdEncoding.EncodeToString([]byte(str))
+			encoded = append(encoded, k)
+		}
+		fields[k] = v
+	}
+	slices.Sort(encoded)
+	return fields, encoded
+}
+
+// decodeFields reverses encodeFields.
+func decodeFields(fields map[string]any, encoded []string) (map[string]any, error) {
+	values := make(map[string]any, len(fields))
+	for k, v := range fields {
+		values[k] = v
+	}
+
+	for _, k := range encoded {
+		str, ok := values[k].(string)
+		if !ok {
+			return nil, fmt.Errorf("field %q is not base64 encoded", k)
+		}
+		raw, err := base64.StdEncoding.DecodeString(str)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", k, err)
+		}
+		values[k] = string(raw)
+	}
+
+	return values, nil
+}
+
+// exportNDJSON ends a failed export with a line holding only the error, so
+// a cut short file is not mistaken for a complete one.
+func exportNDJSON(ctx context.Context, r *RedisStream, stream string, opts ExportOpts, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	err := eachRecord(ctx, r, stream, opts, func(record ExportRecord) error {
+		return enc.Encode(record)
+	})
+	if err != nil {
+		_ = enc.Encode(struct {
+			Error string `json:"error"`
+		}{err.Error()})
+	}
+	return err
+}
+
+// exportCSVErrorMarker starts the last row of a CSV export that failed,
+// followed by the error.
+const exportCSVErrorMarker = "#error"
+
+// exportCSV makes two passes over the stream: the first collects the
+// flattened payload columns so the header is complete, the second writes
+// the rows, skipping entries added since the first. Metadata goes in a
+// single JSON column. A failed export ends with an exportCSVErrorMarker row.
+func exportCSV(ctx context.Context, r *RedisStream, stream string, opts ExportOpts, w io.Writer) error {
+	cw := csv.NewWriter(w)
+	err := writeCSV(ctx, r, stream, opts, cw)
+	if err != nil {
+		_ = cw.Write([]string{exportCSVErrorMarker, err.Error()})
+		cw.Flush()
+	}
+	return err
+}
+
+func writeCSV(ctx context.Context, r *RedisStream, stream string, opts ExportOpts, cw *csv.Writer) error {
+	columns := make(map[string]struct{})
+	var last string
+	err := eachRecord(ctx, r, stream, opts, func(record ExportRecord) error {
+		if last == "" || compareStreamIDs(record.ID, last) > 0 {
+			last = record.ID
+		}
+		for key := range flattenPayload(record) {
+			columns[key] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	dynamic := make([]string, 0, len(columns))
+	for key := range columns {
+		dynamic = append(dynamic, key)
+	}
+	slices.Sort(dynamic)
+
+	header := append([]string{"id", "stream", "timestamp", "uuid"}, dynamic...)
+	if err := cw.Write(append(header, "metadata")); err != nil {
+		return err
+	}
+
+	err = eachRecord(ctx, r, stream, opts, func(record ExportRecord) error {
+		// Entries added after the first pass may have columns the header
+		// lacks; they are left for the next export.
+		if last == "" || compareStreamIDs(record.ID, last) > 0 {
+			return nil
+		}
+
+		flat := flattenPayload(record)
+		metadata, err := json.Marshal(record.Metadata)
+		if err != nil {
+			return err
+		}
+
+		row := make([]string, 0, 5+len(dynamic))
+		row = append(row, record.ID, record.Stream, record.Timestamp.UTC().Format(time.RFC3339Nano), record.UUID)
+		for _, key := range dynamic {
+			row = append(row, flat[key])
+		}
+
+		return cw.Write(append(row, string(metadata)))
+	})
+	if err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// flattenPayload maps the record's payload to CSV columns, named by their
+// dotted path (e.g. "payload.customer.id"). Arrays are kept as JSON.
+func flattenPayload(record ExportRecord) map[string]string {
+	flat := make(map[string]string)
+	flatten("payload", record.Payload, flat)
+	return flat
+}
+
+func flatten(prefix string, value any, out map[string]string) {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, child := range v {
+			flatten(prefix+"."+k, child, out)
+		}
+	case nil:
+		out[prefix] = ""
+	case string:
+		out[prefix] = v
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			out[prefix] = fmt.Sprint(v)
+			return
+		}
+		out[prefix] = strings.TrimSpace(string(b))
+	}
+}
+
+// archiveEntry is an archived stream entry with its raw Redis fields,
+// encoded as in ExportRecord.
+type archiveEntry struct {
+	ID           string         `json:"id"`
+	Fields       map[string]any `json:"fields"`
+	Base64Fields []string       `json:"base64_fields,omitempty"`
+}
+
+// exportArchive writes a gzip compressed JSON document holding the raw
+// entries. The messages array is streamed element by element. A failed
+// export still ends as a valid document, with an "error" key after the
+// messages written so far.
+func exportArchive(ctx context.Context, r *RedisStream, stream string, opts ExportOpts, w io.Writer) (err error) {
+	gz := gzip.NewWriter(w)
+	defer func() {
+		err = errors.Join(err, gz.Close())
+	}()
+
+	header, err := json.Marshal(stream)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(gz, `{"stream":%s,"exported_at":%q,"messages":[`, header, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return err
+	}
+
+	first := true
+	err = eachRecord(ctx, r, stream, opts, func(record ExportRecord) error {
+		if !first {
+			if _, err := gz.Write([]byte{','}); err != nil {
+				return err
+			}
+		}
+		first = false
+
+		b, err := json.Marshal(archiveEntry{ID: record.ID, Fields: record.Fields, Base64Fields: record.Base64Fields})
+		if err != nil {
+			return err
+		}
+
+		_, err = gz.Write(b)
+		return err
+	})
+	if err != nil {
+		if msg, merr := json.Marshal(err.Error()); merr == nil {
+			fmt.Fprintf(gz, `],"error":%s}`, msg)
+		}
+		return err
+	}
+
+	_, err = gz.Write([]byte("]}"))
+	return err
+}
@@ -0,0 +1,186 @@
+package monitor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/suite"
+)
+
+type ExportTestSuite struct {
+	suite.Suite
+	mr      *miniredis.Miniredis
+	client  redis.UniversalClient
+	streams *StreamService
+	dlq     *DLQService
+	dlqName string
+}
+
+func (s *ExportTestSuite) SetupTest() {
+	s.mr = miniredis.RunT(s.T())
+	s.client = redis.NewClient(&redis.Options{Addr: s.mr.Addr()})
+	s.dlqName = "test_dlq"
+	stream := NewRedisStream(s.client)
+	s.streams = NewStreamService(stream, s.dlqName)
+	s.dlq = NewDLQService(stream, s.dlqName)
+}
+
+func (s *ExportTestSuite) TearDownTest() {
+	s.client.Close()
+	s.mr.Close()
+}
+
+func (s *ExportTestSuite) TestExportNDJSON() {
+	ctx := context.Background()
+
+	for i := range exportPageSize + 5 {
+		addTestMessage(s.T(), s.client, "orders.created", map[string]any{"id": i})
+	}
+
+	var buf bytes.Buffer
+	err := s.streams.Export(ctx, "orders.created", ExportFormatNdjson, ExportOpts{Order: SortOrderAsc}, &buf)
+	s.Require().NoError(err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	s.Len(lines, exportPageSize+5)
+
+	var first ExportRecord
+	s.Require().NoError(json.Unmarshal([]byte(lines[0]), &first))
+	s.Equal("orders.created", first.Stream)
+	s.Equal(float64(0), first.Payload["id"])
+	s.Equal("test-uuid", first.Fields[WatermillUUIDKey])
+}
+
+func (s *ExportTestSuite) TestExportCSV() {
+	ctx := context.Background()
+
+	addDLQMessage(s.T(), s.client, s.dlqName, "orders.created", map[string]any{"id": 1, "customer": map[string]any{"name": "ada"}})
+	addDLQMessage(s.T(), s.client, s.dlqName, "payments.processed", map[string]any{"amount": 10})
+
+	var buf bytes.Buffer
+	err := s.dlq.Export(ctx, ExportFormatCsv, ExportOpts{Order: SortOrderAsc}, &buf)
+	s.Require().NoError(err)
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	s.Require().NoError(err)
+	s.Require().Len(rows, 3)
+
+	header := rows[0]
+	s.Contains(header, "payload.customer.name")
+	s.Contains(header, "payload.amount")
+	s.Equal("metadata", header[len(header)-1])
+
+	col := func(name string) int {
+		for i, h := range header {
+			if h == name {
+				return i
+			}
+		}
+		return -1
+	}
+	s.Equal("ada", rows[1][col("payload.customer.name")])
+	s.Equal("", rows[1][col("payload.amount")])
+
+	var metadata map[string]string
+	s.Require().NoError(json.Unmarshal([]byte(rows[2][col("metadata")]), &metadata))
+	s.Equal("payments.processed", metadata[TopicPoisonedKey])
+}
+
+func (s *ExportTestSuite) TestExportCSV_AddedDuringExport() {
+	ctx := context.Background()
+
+	addDLQMessage(s.T(), s.client, s.dlqName, "orders.created", map[string]any{"id": 1})
+	addDLQMessage(s.T(), s.client, s.dlqName, "orders.created", map[string]any{"id": 2})
+
+	// The third record filtered is the first of the second pass.
+	filtered := 0
+	filter := func(ExportRecord) bool {
+		if filtered++; filtered == 3 {
+			addDLQMessage(s.T(), s.client, s.dlqName, "orders.created", map[string]any{"id": 3, "late": true})
+		}
+		return true
+	}
+
+	var buf bytes.Buffer
+	err := s.dlq.Export(ctx, ExportFormatCsv, ExportOpts{Order: SortOrderAsc, Filter: filter}, &buf)
+	s.Require().NoError(err)
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	s.Require().NoError(err)
+	s.Equal([]string{"id", "stream", "timestamp", "uuid", "payload.id", "metadata"}, rows[0])
+	s.Len(rows, 3)
+}
+
+func (s *ExportTestSuite) TestExportArchive() {
+	ctx := context.Background()
+
+	addDLQMessage(s.T(), s.client, s.dlqName, "orders.created", map[string]any{"id": 1})
+	addDLQMessage(s.T(), s.client, s.dlqName, "payments.processed", map[string]any{"id": 2})
+
+	var buf bytes.Buffer
+	err := s.dlq.Export(ctx, ExportFormatArchive, ExportOpts{
+		Filter: func(r ExportRecord) bool { return r.Metadata[TopicPoisonedKey] == "orders.created" },
+	}, &buf)
+	s.Require().NoError(err)
+
+	gz, err := gzip.NewReader(&buf)
+	s.Require().NoError(err)
+
+	var archive struct {
+		Stream   string         `json:"stream"`
+		Messages []archiveEntry `json:"messages"`
+	}
+	s.Require().NoError(json.NewDecoder(gz).Decode(&archive))
+
+	s.Equal(s.dlqName, archive.Stream)
+	s.Require().Len(archive.Messages, 1)
+	s.Equal([]string{WatermillMetadataKey}, archive.Messages[0].Base64Fields)
+
+	// The msgpack metadata survives as base64.
+	raw, err := s.client.XRange(ctx, s.dlqName, "-", "+").Result()
+	s.Require().NoError(err)
+	fields, err := decodeFields(archive.Messages[0].Fields, archive.Messages[0].Base64Fields)
+	s.Require().NoError(err)
+	s.Equal(raw[0].Values, fields)
+}
+
+func (s *ExportTestSuite) TestExportFailureMarkers() {
+	addDLQMessage(s.T(), s.client, s.dlqName, "orders.created", map[string]any{"id": 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var buf bytes.Buffer
+	s.Require().Error(s.dlq.Export(ctx, ExportFormatNdjson, ExportOpts{}, &buf))
+	var record ExportRecord
+	s.Require().NoError(json.Unmarshal(buf.Bytes(), &record))
+	s.Contains(record.Error, "context canceled")
+
+	buf.Reset()
+	s.Require().Error(s.dlq.Export(ctx, ExportFormatCsv, ExportOpts{}, &buf))
+	rows, err := csv.NewReader(&buf).ReadAll()
+	s.Require().NoError(err)
+	s.Equal(exportCSVErrorMarker, rows[len(rows)-1][0])
+
+	buf.Reset()
+	s.Require().Error(s.dlq.Export(ctx, ExportFormatArchive, ExportOpts{}, &buf))
+	gz, err := gzip.NewReader(&buf)
+	s.Require().NoError(err)
+	var archive struct {
+		Messages []archiveEntry `json:"messages"`
+		Error    string         `json:"error"`
+	}
+	s.Require().NoError(json.NewDecoder(gz).Decode(&archive))
+	s.Contains(archive.Error, "context canceled")
+}
+
+func TestExportSuite(t *testing.T) {
+	suite.Run(t, new(ExportTestSuite))
+}
@@ -0,0 +1,321 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultLeaderLease = 15 * time.Second
+	leaderReleaseGrace = 5 * time.Second
+)
+
+// ErrNotLeader is returned by fenced work once this replica has lost its term.
+var ErrNotLeader = errors.New("not the leader")
+
+// The lease holds "<token>:<id>"; each new term increments the fencing token.
+var acquireLeaseScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if current then
+	local sep = string.find(current, ':', 1, true)
+	if string.sub(current, sep + 1) == ARGV[1] then
+		redis.call('PEXPIRE', KEYS[1], ARGV[2])
+		return tonumber(string.sub(current, 1, sep - 1))
+	end
+	return 0
+end
+local token = redis.call('INCR', KEYS[2])
+redis.call('SET', KEYS[1], token .. ':' .. ARGV[1], 'PX', ARGV[2])
+return token
+`)
+
+var renewLeaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+var releaseLeaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// LeaderOpts configures a LeaderElection.
+type LeaderOpts struct {
+	// ID names this replica, the hostname and process ID by default.
+	ID string
+
+	// Lease is how long leadership lasts without renewal (15s by default).
+	Lease time.Duration
+}
+
+// LeaderStatus describes the current holder of the lease.
+type LeaderStatus struct {
+	ID        string     `json:"id"`
+	Leader    string     `json:"leader,omitempty"`
+	Token     int64      `json:"token,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	IsLeader  bool       `json:"is_leader"`
+}
+
+// LeaderElection elects one replica through a lease key with a fencing token per term.
+type LeaderElection struct {
+	client   redis.UniversalClient
+	key      string
+	tokenKey string
+	id       string
+	lease    time.Duration
+
+	mu    sync.Mutex
+	token int64
+}
+
+// NewLeaderElection returns an election over the lease named name.
+func NewLeaderElection(client redis.UniversalClient, name string, opts LeaderOpts) *LeaderElection {
+	if opts.ID == "" {
+		host, _ := os.Hostname()
+		opts.ID = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	// A random suffix keeps replicas configured with the same ID apart.
+	opts.ID += "-" + uuid.New().String()[:8]
+	if opts.Lease <= 0 {
+		opts.Lease = defaultLeaderLease
+	}
+
+	key := "windmill:{" + name + "}:leader"
+	return &LeaderElection{
+		client:   client,
+		key:      key,
+		tokenKey: key + ":token",
+		id:       opts.ID,
+		lease:    opts.Lease,
+	}
+}
+
+// ID returns the identity this replica campaigns under.
+func (l *LeaderElection) ID() string {
+	return l.id
+}
+
+// Token returns the fencing token of the current term, or 0 when not leading.
+func (l *LeaderElection) Token() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.token
+}
+
+// IsLeader reports whether this replica currently leads.
+func (l *LeaderElection) IsLeader() bool {
+	return l.Token() != 0
+}
+
+func (l *LeaderElection) setToken(token int64) {
+	l.mu.Lock()
+	l.token = token
+	l.mu.Unlock()
+}
+
+// Run campaigns until ctx is done, calling lead with a context cancelled when the lease is lost.
+func (l *LeaderElection) Run(ctx context.Context, logger *slog.Logger, lead func(ctx context.Context)) {
+	ticker := time.NewTicker(l.lease / 3)
+	defer ticker.Stop()
+
+	for {
+		token, err := l.acquire(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.Error("leader election failed", "id", l.id, "error", err)
+		}
+
+		if token > 0 {
+			logger.Info("elected leader", "id", l.id, "token", token)
+			l.lead(ctx, logger, token, lead)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// lead runs lead for one term, renewing the lease until it is lost.
+func (l *LeaderElection) lead(ctx context.Context, logger *slog.Logger, token int64, lead func(ctx context.Context)) {
+	l.setToken(token)
+	defer l.setToken(0)
+
+	leadCtx, cancel := context.WithCancel(withLeaderToken(ctx, token))
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		lead(leadCtx)
+	}()
+
+	ticker := time.NewTicker(l.lease / 3)
+	defer ticker.Stop()
+
+	// Renewal errors are tolerated for two thirds of the lease.
+	renewed := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			cancel()
+			<-done
+			l.stepDown(logger, token)
+			return
+		case <-done:
+			l.stepDown(logger, token)
+			return
+		case <-ticker.C:
+		}
+
+		held, err := l.renew(ctx, token)
+		switch {
+		case err == nil && held:
+			renewed = time.Now()
+			continue
+		case err == nil:
+			logger.Warn("lost leadership", "id", l.id, "token", token)
+		case time.Since(renewed) < l.lease*2/3:
+			logger.Error("failed to renew leadership", "id", l.id, "token", token, "error", err)
+			continue
+		default:
+			logger.Error("giving up leadership", "id", l.id, "token", token, "error", err)
+		}
+
+		cancel()
+		<-done
+		return
+	}
+}
+
+func (l *LeaderElection) stepDown(logger *slog.Logger, token int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), leaderReleaseGrace)
+	defer cancel()
+
+	if err := l.release(ctx, token); err != nil {
+		logger.Error("failed to release leadership", "id", l.id, "token", token, "error", err)
+		return
+	}
+	logger.Info("released leadership", "id", l.id, "token", token)
+}
+
+func (l *LeaderElection) value(token int64) string {
+	return strconv.FormatInt(token, 10) + ":" + l.id
+}
+
+func (l *LeaderElection) acquire(ctx context.Context) (int64, error) {
+	return acquireLeaseScript.Run(ctx, l.client, []string{l.key, l.tokenKey}, l.id, l.lease.Milliseconds()).Int64()
+}
+
+func (l *LeaderElection) renew(ctx context.Context, token int64) (bool, error) {
+	held, err := renewLeaseScript.Run(ctx, l.client, []string{l.key}, l.value(token), l.lease.Milliseconds()).Int64()
+	return held == 1, err
+}
+
+func (l *LeaderElection) release(ctx context.Context, token int64) error {
+	return releaseLeaseScript.Run(ctx, l.client, []string{l.key}, l.value(token)).Err()
+}
+
+// Verify returns ErrNotLeader unless token is still the current term.
+func (l *LeaderElection) Verify(ctx context.Context, token int64) error {
+	current, err := l.client.Get(ctx, l.key).Result()
+	if errors.Is(err, redis.Nil) {
+		return ErrNotLeader
+	}
+	if err != nil {
+		return err
+	}
+
+	if current != l.value(token) {
+		return ErrNotLeader
+	}
+	return nil
+}
+
+// Fence wraps w so each run first verifies its term is still current.
+func (l *LeaderElection) Fence(w Worker) Worker {
+	run := w.Run
+	w.Run = func(ctx context.Context) error {
+		token, ok := LeaderToken(ctx)
+		if !ok {
+			return ErrNotLeader
+		}
+
+		check := func(ctx context.Context) error {
+			return l.Verify(ctx, token)
+		}
+		if err := check(ctx); err != nil {
+			return err
+		}
+		return run(context.WithValue(ctx, fenceKey{}, check))
+	}
+	return w
+}
+
+type fenceKey struct{}
+
+// checkFence returns ErrNotLeader when a fenced run's term is over.
+func checkFence(ctx context.Context) error {
+	check, ok := ctx.Value(fenceKey{}).(func(context.Context) error)
+	if !ok {
+		return nil
+	}
+	return check(ctx)
+}
+
+// Status reports which replica holds the lease and until when.
+func (l *LeaderElection) Status(ctx context.Context) (*LeaderStatus, error) {
+	status := &LeaderStatus{ID: l.id}
+
+	current, err := l.client.Get(ctx, l.key).Result()
+	if errors.Is(err, redis.Nil) {
+		return status, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tokenStr, leader, _ := strings.Cut(current, ":")
+	status.Leader = leader
+	status.Token, _ = strconv.ParseInt(tokenStr, 10, 64)
+	status.IsLeader = leader == l.id
+
+	ttl, err := l.client.PTTL(ctx, l.key).Result()
+	if err != nil {
+		return nil, err
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl).UTC()
+		status.ExpiresAt = &expiresAt
+	}
+
+	return status, nil
+}
+
+type leaderTokenKey struct{}
+
+func withLeaderToken(ctx context.Context, token int64) context.Context {
+	return context.WithValue(ctx, leaderTokenKey{}, token)
+}
+
+// LeaderToken returns the fencing token of the term ctx was led under.
+func LeaderToken(ctx context.Context) (int64, bool) {
+	token, ok := ctx.Value(leaderTokenKey{}).(int64)
+	return token, ok
+}
@@ -0,0 +1,220 @@
+package monitor
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/suite"
+)
+
+type LeaderTestSuite struct {
+	suite.Suite
+	mr     *miniredis.Miniredis
+	client redis.UniversalClient
+	logger *slog.Logger
+}
+
+func (s *LeaderTestSuite) SetupTest() {
+	s.mr = miniredis.RunT(s.T())
+	s.client = redis.NewClient(&redis.Options{Addr: s.mr.Addr()})
+	s.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func (s *LeaderTestSuite) TearDownTest() {
+	s.client.Close()
+	s.mr.Close()
+}
+
+func (s *LeaderTestSuite) election(id string) *LeaderElection {
+	return NewLeaderElection(s.client, "test_dlq", LeaderOpts{ID: id, Lease: 60 * time.Millisecond})
+}
+
+func (s *LeaderTestSuite) TestAcquire() {
+	ctx := context.Background()
+	a, b := s.election("a"), s.election("b")
+
+	token, err := a.acquire(ctx)
+	s.Require().NoError(err)
+	s.Equal(int64(1), token)
+
+	token, err = b.acquire(ctx)
+	s.Require().NoError(err)
+	s.Zero(token)
+
+	token, err = a.acquire(ctx)
+	s.Require().NoError(err)
+	s.Equal(int64(1), token)
+
+	s.Require().NoError(a.release(ctx, 1))
+
+	token, err = b.acquire(ctx)
+	s.Require().NoError(err)
+	s.Equal(int64(2), token)
+
+	held, err := a.renew(ctx, 1)
+	s.Require().NoError(err)
+	s.False(held)
+
+	held, err = b.renew(ctx, 2)
+	s.Require().NoError(err)
+	s.True(held)
+}
+
+func (s *LeaderTestSuite) TestAcquire_SameConfiguredID() {
+	ctx := context.Background()
+	a, b := s.election("a"), s.election("a")
+	s.NotEqual(a.ID(), b.ID())
+
+	token, err := a.acquire(ctx)
+	s.Require().NoError(err)
+	s.Equal(int64(1), token)
+
+	token, err = b.acquire(ctx)
+	s.Require().NoError(err)
+	s.Zero(token)
+}
+
+func (s *LeaderTestSuite) TestAcquire_AfterExpiry() {
+	ctx := context.Background()
+	a, b := s.election("a"), s.election("b")
+
+	_, err := a.acquire(ctx)
+	s.Require().NoError(err)
+
+	s.mr.FastForward(time.Second)
+
+	token, err := b.acquire(ctx)
+	s.Require().NoError(err)
+	s.Equal(int64(2), token)
+
+	s.ErrorIs(a.Verify(ctx, 1), ErrNotLeader)
+	s.NoError(b.Verify(ctx, 2))
+}
+
+func (s *LeaderTestSuite) TestRun_HandsOverOnShutdown() {
+	a, b := s.election("a"), s.election("b")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leading := make(chan int64)
+	stopped := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		a.Run(ctx, s.logger, func(ctx context.Context) {
+			token, _ := LeaderToken(ctx)
+			leading <- token
+			<-ctx.Done()
+			close(stopped)
+		})
+	}()
+
+	s.Equal(int64(1), <-leading)
+	s.True(a.IsLeader())
+
+	status, err := b.Status(context.Background())
+	s.Require().NoError(err)
+	s.Equal(a.ID(), status.Leader)
+	s.Equal(int64(1), status.Token)
+	s.False(status.IsLeader)
+	s.NotNil(status.ExpiresAt)
+
+	cancel()
+	<-stopped
+	<-done
+
+	s.False(a.IsLeader())
+	s.False(s.mr.Exists("windmill:{test_dlq}:leader"))
+
+	token, err := b.acquire(context.Background())
+	s.Require().NoError(err)
+	s.Equal(int64(2), token)
+}
+
+func (s *LeaderTestSuite) TestRun_StopsWhenLeaseLost() {
+	a := s.election("a")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	leading := make(chan struct{})
+	lost := make(chan struct{})
+	go a.Run(ctx, s.logger, func(leadCtx context.Context) {
+		close(leading)
+		<-leadCtx.Done()
+		if ctx.Err() == nil {
+			close(lost)
+		}
+	})
+
+	<-leading
+	s.Require().NoError(s.client.Set(context.Background(), "windmill:{test_dlq}:leader", "7:b", time.Minute).Err())
+
+	select {
+	case <-lost:
+	case <-time.After(time.Second):
+		s.Fail("leader kept leading after its lease was taken")
+	}
+}
+
+func (s *LeaderTestSuite) TestFence() {
+	ctx := context.Background()
+	a := s.election("a")
+
+	var runs int
+	worker := a.Fence(Worker{Name: "test", Run: func(context.Context) error {
+		runs++
+		return nil
+	}})
+
+	s.ErrorIs(worker.Run(ctx), ErrNotLeader)
+
+	token, err := a.acquire(ctx)
+	s.Require().NoError(err)
+	s.NoError(worker.Run(withLeaderToken(ctx, token)))
+	s.Equal(1, runs)
+
+	s.mr.FastForward(time.Second)
+	_, err = s.election("b").acquire(ctx)
+	s.Require().NoError(err)
+
+	s.ErrorIs(worker.Run(withLeaderToken(ctx, token)), ErrNotLeader)
+	s.Equal(1, runs)
+}
+
+func (s *LeaderTestSuite) TestFence_CheckedBeforeEachWrite() {
+	ctx := context.Background()
+	a := s.election("a")
+
+	token, err := a.acquire(ctx)
+	s.Require().NoError(err)
+
+	var checks []error
+	worker := a.Fence(Worker{Name: "test", Run: func(ctx context.Context) error {
+		checks = append(checks, checkFence(ctx))
+
+		// The lease is lost part way through the run.
+		s.mr.FastForward(time.Second)
+		_, err := s.election("b").acquire(ctx)
+		s.Require().NoError(err)
+
+		checks = append(checks, checkFence(ctx))
+		return nil
+	}})
+
+	s.NoError(worker.Run(withLeaderToken(ctx, token)))
+	s.Require().Len(checks, 2)
+	s.NoError(checks[0])
+	s.ErrorIs(checks[1], ErrNotLeader)
+
+	// Unfenced callers, such as the API, are never stopped.
+	s.NoError(checkFence(ctx))
+}
+
+func TestLeaderTestSuite(t *testing.T) {
+	suite.Run(t, new(LeaderTestSuite))
+}
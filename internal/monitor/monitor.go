@@ -2,21 +2,49 @@ package monitor
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
 type Monitor struct {
-	streams *StreamService
-	dlq     *DLQService
+	redis     *RedisStream
+	streams   *StreamService
+	consumers *ConsumerService
+	dlq       *DLQService
+	topology  *TopologyService
+	server    *ServerService
+	leader    *LeaderElection
 }
 
+// Options configures the parts of a Monitor that have settings.
+type Options struct {
+	Leader  LeaderOpts
+	Catalog CatalogOpts
+}
+
+// New returns a Monitor for the DLQ dlqName. Its leader election is named
+// after the DLQ, so replicas monitoring the same DLQ elect one leader.
 func New(redisClient redis.UniversalClient, dlqName string) *Monitor {
+	return NewWithOptions(redisClient, dlqName, Options{})
+}
+
+// NewWithOptions is like New with configured leader election and stream
+// catalog.
+func NewWithOptions(redisClient redis.UniversalClient, dlqName string, opts Options) *Monitor {
 	redisStream := NewRedisStream(redisClient)
+	streams := NewStreamServiceWithCatalog(redisStream, dlqName, opts.Catalog)
+	dlq := NewDLQService(redisStream, dlqName)
 
 	return &Monitor{
-		streams: NewStreamService(redisStream, dlqName),
-		dlq:     NewDLQService(redisStream, dlqName),
+		redis:     redisStream,
+		streams:   streams,
+		consumers: NewConsumerService(redisStream, streams),
+		dlq:       dlq,
+		topology:  NewTopologyService(redisStream, streams, dlq),
+		server:    NewServerService(redisClient),
+		leader:    NewLeaderElection(redisClient, dlqName, opts.Leader),
 	}
 }
 
@@ -24,29 +52,102 @@ func (m *Monitor) Streams() *StreamService {
 	return m.streams
 }
 
+// Consumers returns the service reading the progress of consumer groups.
+func (m *Monitor) Consumers() *ConsumerService {
+	return m.consumers
+}
+
 func (m *Monitor) DLQ() *DLQService {
 	return m.dlq
 }
 
-func (m *Monitor) GetOverview(ctx context.Context) (*StatsOverview, error) {
-	streams, err := m.streams.GetStreams(ctx)
-	if err != nil {
-		return nil, err
+// Topology returns the service mapping topics to the handlers consuming
+// them.
+func (m *Monitor) Topology() *TopologyService {
+	return m.topology
+}
+
+// Server returns the service reading the health of Redis itself.
+func (m *Monitor) Server() *ServerService {
+	return m.server
+}
+
+// Leader returns the election deciding which replica runs background work.
+func (m *Monitor) Leader() *LeaderElection {
+	return m.leader
+}
+
+// ProbeCapabilities detects the commands Redis allows and adapts every
+// service of the Monitor to them.
+func (m *Monitor) ProbeCapabilities(ctx context.Context) (*RedisCapabilities, error) {
+	return m.redis.ProbeCapabilities(ctx)
+}
+
+// CapabilitiesWorker probes the capabilities on each run until a probe
+// succeeds, passing that probe's result to onProbe.
+func (m *Monitor) CapabilitiesWorker(interval time.Duration, onProbe func(*RedisCapabilities)) Worker {
+	return Worker{
+		Name:     "capabilities",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			if m.Capabilities().ProbedAt != nil {
+				return nil
+			}
+
+			ctx, cancel := context.WithTimeout(ctx, capabilityProbeTimeout)
+			defer cancel()
+
+			caps, err := m.ProbeCapabilities(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to probe redis capabilities: %w", err)
+			}
+			if onProbe != nil {
+				onProbe(caps)
+			}
+			return nil
+		},
 	}
+}
+
+// Capabilities returns the capabilities found by the last successful probe,
+// or all of them when none has run.
+func (m *Monitor) Capabilities() RedisCapabilities {
+	return m.redis.Capabilities()
+}
 
-	dlqStats, err := m.dlq.GetStats(ctx)
+func (m *Monitor) GetOverview(ctx context.Context, opts OverviewOpts) (*StatsOverview, error) {
+	streams, err := m.streams.GetStreams(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	var totalStreams int
 	var totalMessages int64
 	for _, s := range streams {
+		if opts.Allow != nil && !opts.Allow(s.Name) {
+			continue
+		}
+		totalStreams++
 		totalMessages += s.Length
 	}
 
+	var totalDLQMessages int64
+	if opts.Allow != nil {
+		totalDLQMessages, err = m.dlq.CountMessages(ctx, opts.Allow)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		dlqStats, err := m.dlq.GetStats(ctx)
+		if err != nil {
+			return nil, err
+		}
+		totalDLQMessages = dlqStats.Length
+	}
+
 	return &StatsOverview{
-		TotalStreams:     len(streams),
+		TotalStreams:     totalStreams,
 		TotalMessages:    totalMessages,
-		TotalDLQMessages: dlqStats.Length,
+		TotalDLQMessages: totalDLQMessages,
 	}, nil
 }
@@ -86,12 +86,21 @@ func (s *MonitorTestSuite) TestGetOverview() {
 	addTestMessage(s.T(), s.client, "payments.processed", map[string]any{"id": 3})
 	addDLQMessage(s.T(), s.client, s.dlqName, "orders.created", map[string]any{"id": 4})
 
-	overview, err := s.monitor.GetOverview(ctx)
+	overview, err := s.monitor.GetOverview(ctx, OverviewOpts{})
 	s.Require().NoError(err)
 
 	s.Equal(2, overview.TotalStreams)
 	s.Equal(int64(3), overview.TotalMessages)
 	s.Equal(int64(1), overview.TotalDLQMessages)
+
+	overview, err = s.monitor.GetOverview(ctx, OverviewOpts{
+		Allow: func(stream string) bool { return stream == "payments.processed" },
+	})
+	s.Require().NoError(err)
+
+	s.Equal(1, overview.TotalStreams)
+	s.Equal(int64(1), overview.TotalMessages)
+	s.Zero(overview.TotalDLQMessages)
 }
 
 func TestMonitorSuite(t *testing.T) {
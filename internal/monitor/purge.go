@@ -0,0 +1,120 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const purgePageSize = 500
+
+// ErrPurgeNotConfirmed is returned by Purge when the confirmation token
+// does not match the DLQ name.
+var ErrPurgeNotConfirmed = errors.New("purge not confirmed: confirmation must match the DLQ name")
+
+// Purge deletes the DLQ messages selected by opts. Only messages already in
+// the DLQ when the purge starts are considered, so messages poisoned while
+// it runs are never deleted, and none are deleted if the export fails.
+// The export defaults to NDJSON.
+func (d *DLQService) Purge(ctx context.Context, opts PurgeOpts) (*PurgeResult, error) {
+	if !opts.DryRun && opts.Confirm != d.dlqName {
+		return nil, ErrPurgeNotConfirmed
+	}
+
+	result := &PurgeResult{
+		DLQ:           d.dlqName,
+		Topic:         opts.Topic,
+		ErrorContains: opts.ErrorContains,
+		DryRun:        opts.DryRun,
+		PurgedAt:      time.Now().UTC(),
+	}
+	if !opts.Before.IsZero() {
+		before := opts.Before.UTC()
+		result.Before = &before
+	}
+
+	last, err := d.monitor.ReadMessages(ctx, d.dlqName, PaginationOpts{Limit: 1, Order: SortOrderDesc})
+	if err != nil {
+		return nil, err
+	}
+	if len(last) == 0 {
+		return result, nil
+	}
+	bound := last[0].ID
+
+	match := func(record ExportRecord) bool {
+		if compareStreamIDs(record.ID, bound) > 0 {
+			return false
+		}
+		if !opts.Before.IsZero() && !record.Timestamp.Before(opts.Before) {
+			return false
+		}
+		if opts.Topic != "" && record.Metadata[TopicPoisonedKey] != opts.Topic {
+			return false
+		}
+		if opts.ErrorContains != "" && !strings.Contains(record.Metadata[ReasonPoisonedKey], opts.ErrorContains) {
+			return false
+		}
+		return true
+	}
+
+	if opts.Export != nil && !opts.DryRun {
+		format := opts.ExportFormat
+		if format == "" {
+			format = ExportFormatNdjson
+		}
+
+		exportOpts := ExportOpts{Order: SortOrderAsc, Filter: match}
+		if err := d.Export(ctx, format, exportOpts, opts.Export); err != nil {
+			return nil, fmt.Errorf("export failed, nothing was deleted: %w", err)
+		}
+		result.Exported = true
+		result.ExportFormat = format
+	}
+
+	var batch []string
+	flush := func() error {
+		if len(batch) == 0 || opts.DryRun {
+			batch = batch[:0]
+			return nil
+		}
+
+		deleted, err := d.monitor.DeleteMessages(ctx, d.dlqName, batch...)
+		if err != nil {
+			return fmt.Errorf("failed to delete messages: %w", err)
+		}
+		result.Deleted += deleted
+		batch = batch[:0]
+		return nil
+	}
+
+	err = d.monitor.ScanMessages(ctx, d.dlqName, PaginationOpts{Limit: purgePageSize, Order: SortOrderAsc}, func(msg redis.XMessage) error {
+		if compareStreamIDs(msg.ID, bound) > 0 {
+			return errStopScan
+		}
+
+		record, err := newExportRecord(d.dlqName, msg)
+		if err != nil {
+			return err
+		}
+		if !match(*record) {
+			return nil
+		}
+
+		result.Matched++
+		batch = append(batch, msg.ID)
+		if len(batch) == purgePageSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopScan) {
+		return result, err
+	}
+
+	return result, flush()
+}
@@ -0,0 +1,130 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/suite"
+)
+
+type PurgeTestSuite struct {
+	suite.Suite
+	mr      *miniredis.Miniredis
+	client  redis.UniversalClient
+	service *DLQService
+	dlqName string
+}
+
+func (s *PurgeTestSuite) SetupTest() {
+	s.mr = miniredis.RunT(s.T())
+	s.client = redis.NewClient(&redis.Options{Addr: s.mr.Addr()})
+	s.dlqName = "test_dlq"
+	s.service = NewDLQService(NewRedisStream(s.client), s.dlqName)
+}
+
+func (s *PurgeTestSuite) TearDownTest() {
+	s.client.Close()
+	s.mr.Close()
+}
+
+func (s *PurgeTestSuite) length() int64 {
+	length, err := s.client.XLen(context.Background(), s.dlqName).Result()
+	s.Require().NoError(err)
+	return length
+}
+
+func (s *PurgeTestSuite) TestPurgeRequiresConfirmation() {
+	addDLQMessage(s.T(), s.client, s.dlqName, "orders.created", map[string]any{"id": 1})
+
+	_, err := s.service.Purge(context.Background(), PurgeOpts{Confirm: "yes"})
+	s.ErrorIs(err, ErrPurgeNotConfirmed)
+	s.Equal(int64(1), s.length())
+}
+
+func (s *PurgeTestSuite) TestPurgeAll() {
+	ctx := context.Background()
+	for i := range purgePageSize + 3 {
+		addDLQMessage(s.T(), s.client, s.dlqName, "orders.created", map[string]any{"id": i})
+	}
+
+	preview, err := s.service.Purge(ctx, PurgeOpts{DryRun: true})
+	s.Require().NoError(err)
+	s.Equal(int64(purgePageSize+3), preview.Matched)
+	s.Zero(preview.Deleted)
+	s.Equal(int64(purgePageSize+3), s.length())
+
+	result, err := s.service.Purge(ctx, PurgeOpts{Confirm: s.dlqName})
+	s.Require().NoError(err)
+	s.Equal(int64(purgePageSize+3), result.Deleted)
+	s.Zero(s.length())
+}
+
+func (s *PurgeTestSuite) TestPurgeFiltered() {
+	ctx := context.Background()
+
+	old := addDLQMessage(s.T(), s.client, s.dlqName, "orders.created", map[string]any{"id": 1})
+	addDLQMessage(s.T(), s.client, s.dlqName, "payments.processed", map[string]any{"id": 2})
+	s.mr.SetTime(time.Now().Add(time.Hour))
+	addDLQMessage(s.T(), s.client, s.dlqName, "orders.created", map[string]any{"id": 3})
+
+	ts, err := ParseStreamTimestamp(old)
+	s.Require().NoError(err)
+
+	result, err := s.service.Purge(ctx, PurgeOpts{
+		Before:  ts.Add(time.Minute),
+		Topic:   "orders.created",
+		Confirm: s.dlqName,
+	})
+	s.Require().NoError(err)
+	s.Equal(int64(1), result.Deleted)
+	s.Equal("orders.created", result.Topic)
+	s.NotNil(result.Before)
+
+	msg, err := s.service.GetMessage(ctx, old)
+	s.Require().NoError(err)
+	s.Nil(msg)
+	s.Equal(int64(2), s.length())
+
+	result, err = s.service.Purge(ctx, PurgeOpts{ErrorContains: "unrelated", Confirm: s.dlqName})
+	s.Require().NoError(err)
+	s.Zero(result.Matched)
+}
+
+func (s *PurgeTestSuite) TestPurgeExportsFirst() {
+	ctx := context.Background()
+	addDLQMessage(s.T(), s.client, s.dlqName, "orders.created", map[string]any{"id": 1})
+	addDLQMessage(s.T(), s.client, s.dlqName, "payments.processed", map[string]any{"id": 2})
+
+	var buf bytes.Buffer
+	result, err := s.service.Purge(ctx, PurgeOpts{Topic: "payments.processed", Confirm: s.dlqName, Export: &buf})
+	s.Require().NoError(err)
+	s.True(result.Exported)
+	s.Equal(ExportFormatNdjson, result.ExportFormat)
+	s.Equal(int64(1), result.Deleted)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	s.Require().Len(lines, 1)
+	s.Contains(lines[0], "payments.processed")
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func (s *PurgeTestSuite) TestPurgeKeepsMessagesWhenExportFails() {
+	addDLQMessage(s.T(), s.client, s.dlqName, "orders.created", map[string]any{"id": 1})
+
+	_, err := s.service.Purge(context.Background(), PurgeOpts{Confirm: s.dlqName, Export: failingWriter{}})
+	s.ErrorContains(err, "disk full")
+	s.Equal(int64(1), s.length())
+}
+
+func TestPurgeTestSuite(t *testing.T) {
+	suite.Run(t, new(PurgeTestSuite))
+}
@@ -3,42 +3,242 @@ package monitor
 import (
 	"context"
 	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
 
 	"github.com/redis/go-redis/v9"
 )
 
 type RedisStream struct {
 	client redis.UniversalClient
+	caps   atomic.Pointer[RedisCapabilities]
 }
 
 func NewRedisStream(client redis.UniversalClient) *RedisStream {
 	return &RedisStream{client: client}
 }
 
-func (r *RedisStream) ScanStreams(ctx context.Context) ([]string, error) {
+// StreamKey is a stream found by ScanStreams. Node is the address of the
+// node holding it and Slot its hash slot; they are only set on a Redis
+// Cluster (Node also on a Ring).
+type StreamKey struct {
+	Name string
+	Node string
+	Slot *int
+}
+
+// ScanStreams lists the streams whose name matches the glob-style pattern
+// match ("*" for every stream). A single SCAN only covers the node it is
+// sent to, so on a Redis Cluster every master is scanned, and on a Ring
+// every shard; keys seen twice, e.g. while a slot migrates, are reported
+// once. When SCAN does not accept TYPE, every key matching match is
+// checked with TYPE instead.
+func (r *RedisStream) ScanStreams(ctx context.Context, match string) ([]StreamKey, error) {
+	typed := r.Capabilities().ScanType
+
 	var (
-		cursor  uint64
-		streams []string
+		mu      sync.Mutex
+		seen    = make(map[string]bool)
+		streams []StreamKey
 	)
 
+	collect := func(ctx context.Context, node *redis.Client, cluster bool) error {
+		addr := node.Options().Addr
+		return scanStreamKeys(ctx, node, match, typed, func(key string) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if seen[key] {
+				return
+			}
+			seen[key] = true
+
+			stream := StreamKey{Name: key, Node: addr}
+			if cluster {
+				slot := KeySlot(key)
+				stream.Slot = &slot
+			}
+			streams = append(streams, stream)
+		})
+	}
+
+	var err error
+	switch c := r.client.(type) {
+	case *redis.ClusterClient:
+		err = c.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return collect(ctx, node, true)
+		})
+	case *redis.Ring:
+		err = c.ForEachShard(ctx, func(ctx context.Context, shard *redis.Client) error {
+			return collect(ctx, shard, false)
+		})
+	default:
+		err = scanStreamKeys(ctx, r.client, match, typed, func(key string) {
+			streams = append(streams, StreamKey{Name: key})
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(streams, func(i, j int) bool { return streams[i].Name < streams[j].Name })
+	return streams, nil
+}
+
+func scanStreamKeys(ctx context.Context, client redis.Cmdable, match string, typed bool, fn func(key string)) error {
+	var cursor uint64
 	for {
-		keys, nextCursor, err := r.client.ScanType(ctx, cursor, "*", 100, "stream").Result()
+		var (
+			keys       []string
+			nextCursor uint64
+			err        error
+		)
+		if typed {
+			keys, nextCursor, err = client.ScanType(ctx, cursor, match, 100, "stream").Result()
+		} else {
+			keys, nextCursor, err = client.Scan(ctx, cursor, match, 100).Result()
+			if err == nil {
+				keys, err = filterStreams(ctx, client, keys)
+			}
+		}
 		if err != nil {
-			return nil, err
+			return err
+		}
+
+		for _, key := range keys {
+			fn(key)
 		}
 
-		streams = append(streams, keys...)
 		cursor = nextCursor
 		if cursor == 0 {
-			break
+			return nil
+		}
+	}
+}
+
+// filterStreams keeps the keys holding a stream, checking their types in
+// one pipeline.
+func filterStreams(ctx context.Context, client redis.Cmdable, keys []string) ([]string, error) {
+	if len(keys) == 0 {
+		return keys, nil
+	}
+
+	cmds := make([]*redis.StatusCmd, len(keys))
+	_, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.Type(ctx, key)
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	streams := keys[:0]
+	for i, key := range keys {
+		if cmds[i].Val() == "stream" {
+			streams = append(streams, key)
+		}
+	}
 	return streams, nil
 }
 
+// LocateStream returns the node and slot of stream on a Redis Cluster, and
+// just its name otherwise.
+func (r *RedisStream) LocateStream(ctx context.Context, stream string) (StreamKey, error) {
+	key := StreamKey{Name: stream}
+
+	if c, ok := r.client.(*redis.ClusterClient); ok {
+		node, err := c.MasterForKey(ctx, stream)
+		if err != nil {
+			return key, err
+		}
+		slot := KeySlot(stream)
+		key.Node = node.Options().Addr
+		key.Slot = &slot
+	}
+
+	return key, nil
+}
+
+// GetStreamInfo returns the XINFO STREAM of stream. When XINFO is denied,
+// only its length and first and last entries are filled in, read with XLEN
+// and XRANGE.
 func (r *RedisStream) GetStreamInfo(ctx context.Context, stream string) (*redis.XInfoStream, error) {
-	return r.client.XInfoStream(ctx, stream).Result()
+	if r.Capabilities().XInfo {
+		return r.client.XInfoStream(ctx, stream).Result()
+	}
+
+	var fallback *streamInfoFallback
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		fallback = queueStreamInfoFallback(ctx, pipe, stream)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fallback.Result()
+}
+
+// GetStreamInfoFull returns the XINFO STREAM FULL of stream, listing at
+// most count entries and pending entries per group and consumer.
+func (r *RedisStream) GetStreamInfoFull(ctx context.Context, stream string, count int) (*redis.XInfoStreamFull, error) {
+	return r.client.XInfoStreamFull(ctx, stream, count).Result()
+}
+
+// streamInfoFallback reads the parts of XINFO STREAM other commands can
+// provide, for servers where XINFO is denied.
+type streamInfoFallback struct {
+	exists *redis.IntCmd
+	length *redis.IntCmd
+	first  *redis.XMessageSliceCmd
+	last   *redis.XMessageSliceCmd
+}
+
+func queueStreamInfoFallback(ctx context.Context, pipe redis.Pipeliner, stream string) *streamInfoFallback {
+	return &streamInfoFallback{
+		exists: pipe.Exists(ctx, stream),
+		length: pipe.XLen(ctx, stream),
+		first:  pipe.XRangeN(ctx, stream, "-", "+", 1),
+		last:   pipe.XRevRangeN(ctx, stream, "+", "-", 1),
+	}
+}
+
+// Result returns the stream info, or an error like the one XINFO STREAM
+// gives when the stream does not exist.
+func (f *streamInfoFallback) Result() (*redis.XInfoStream, error) {
+	exists, err := f.exists.Result()
+	if err != nil {
+		return nil, err
+	}
+	if exists == 0 {
+		return nil, errNoSuchKey
+	}
+
+	info := &redis.XInfoStream{}
+	if info.Length, err = f.length.Result(); err != nil {
+		return nil, err
+	}
+
+	first, err := f.first.Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(first) > 0 {
+		info.FirstEntry = first[0]
+	}
+
+	last, err := f.last.Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(last) > 0 {
+		info.LastEntry = last[0]
+		info.LastGeneratedID = last[0].ID
+	}
+
+	return info, nil
 }
 
 func (r *RedisStream) GetStreamLength(ctx context.Context, stream string) (int64, error) {
@@ -49,6 +249,21 @@ func (r *RedisStream) GetMemoryUsage(ctx context.Context, stream string) (int64,
 	return r.client.MemoryUsage(ctx, stream).Result()
 }
 
+// memoryUsage returns the memory used by stream, or nil and the reason it
+// is unknown, e.g. when an ACL forbids MEMORY USAGE. Once a probe found
+// MEMORY USAGE denied, it is not sent and no reason is given.
+func (r *RedisStream) memoryUsage(ctx context.Context, stream string) (*int64, string) {
+	if !r.Capabilities().MemoryUsage {
+		return nil, ""
+	}
+
+	memory, err := r.GetMemoryUsage(ctx, stream)
+	if err != nil {
+		return nil, "MEMORY USAGE: " + err.Error()
+	}
+	return &memory, ""
+}
+
 func (r *RedisStream) ReadMessages(ctx context.Context, stream string, opts PaginationOpts) ([]redis.XMessage, error) {
 	opts = opts.WithDefaults()
 	switch opts.Order {
@@ -61,6 +276,30 @@ func (r *RedisStream) ReadMessages(ctx context.Context, stream string, opts Pagi
 	}
 }
 
+// ScanMessages calls fn for every message of stream from opts.Cursor on,
+// reading one page at a time.
+func (r *RedisStream) ScanMessages(ctx context.Context, stream string, opts PaginationOpts, fn func(redis.XMessage) error) error {
+	opts = opts.WithDefaults()
+
+	for {
+		messages, err := r.ReadMessages(ctx, stream, opts)
+		if err != nil {
+			return err
+		}
+
+		for _, msg := range messages {
+			if err := fn(msg); err != nil {
+				return err
+			}
+		}
+
+		if len(messages) < int(opts.Limit) {
+			return nil
+		}
+		opts.Cursor = messages[len(messages)-1].ID
+	}
+}
+
 func (r *RedisStream) ReadMessage(ctx context.Context, stream, id string) (*redis.XMessage, error) {
 	messages, err := r.client.XRangeN(ctx, stream, id, id, 1).Result()
 	if err != nil {
@@ -86,6 +325,41 @@ func (r *RedisStream) DeleteMessage(ctx context.Context, stream, id string) erro
 	return err
 }
 
+func (r *RedisStream) DeleteMessages(ctx context.Context, stream string, ids ...string) (int64, error) {
+	return r.client.XDel(ctx, stream, ids...).Result()
+}
+
+func (r *RedisStream) GetGroups(ctx context.Context, stream string) ([]redis.XInfoGroup, error) {
+	return r.client.XInfoGroups(ctx, stream).Result()
+}
+
+func (r *RedisStream) GetConsumers(ctx context.Context, stream, group string) ([]redis.XInfoConsumer, error) {
+	return r.client.XInfoConsumers(ctx, stream, group).Result()
+}
+
+func (r *RedisStream) GetPendingSummary(ctx context.Context, stream, group string) (*redis.XPending, error) {
+	return r.client.XPending(ctx, stream, group).Result()
+}
+
+// TrimMinID removes the entries of stream older than minID and returns how
+// many were removed.
+func (r *RedisStream) TrimMinID(ctx context.Context, stream, minID string, approximate bool) (int64, error) {
+	if approximate {
+		return r.client.XTrimMinIDApprox(ctx, stream, minID, 0).Result()
+	}
+	return r.client.XTrimMinID(ctx, stream, minID).Result()
+}
+
+// TrimMaxLen trims stream to its newest maxLen entries and returns how
+// many were removed. An approximate trim only removes whole nodes and may
+// keep more.
+func (r *RedisStream) TrimMaxLen(ctx context.Context, stream string, maxLen int64, approximate bool) (int64, error) {
+	if approximate {
+		return r.client.XTrimMaxLenApprox(ctx, stream, maxLen, 0).Result()
+	}
+	return r.client.XTrimMaxLen(ctx, stream, maxLen).Result()
+}
+
 func (r *RedisStream) readRange(ctx context.Context, stream string, opts PaginationOpts) ([]redis.XMessage, error) {
 	start := opts.Cursor
 	if start == "" {
@@ -0,0 +1,77 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeySlot(t *testing.T) {
+	require.Equal(t, 12739, KeySlot("123456789"))
+	require.Equal(t, 12182, KeySlot("foo"))
+	require.Equal(t, KeySlot("orders"), KeySlot("{orders}.created"))
+	require.Equal(t, int(crc16("{}.created")%clusterSlots), KeySlot("{}.created"))
+}
+
+func addStreams(t *testing.T, client redis.UniversalClient, names ...string) {
+	for _, name := range names {
+		require.NoError(t, client.XAdd(context.Background(), &redis.XAddArgs{
+			Stream: name,
+			Values: map[string]any{"k": "v"},
+		}).Err())
+	}
+}
+
+func TestScanStreams_Cluster(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{mr.Addr()}})
+	t.Cleanup(func() { client.Close() })
+
+	addStreams(t, client, "orders.created", "payments.processed")
+	require.NoError(t, client.Set(context.Background(), "not-a-stream", "v", 0).Err())
+
+	streams, err := NewRedisStream(client).ScanStreams(context.Background(), "*")
+	require.NoError(t, err)
+	require.Len(t, streams, 2)
+
+	require.Equal(t, "orders.created", streams[0].Name)
+	require.Equal(t, mr.Addr(), streams[0].Node)
+	require.NotNil(t, streams[0].Slot)
+	require.Equal(t, KeySlot("orders.created"), *streams[0].Slot)
+
+	location, err := NewRedisStream(client).LocateStream(context.Background(), "payments.processed")
+	require.NoError(t, err)
+	require.Equal(t, mr.Addr(), location.Node)
+	require.Equal(t, KeySlot("payments.processed"), *location.Slot)
+}
+
+func TestScanStreams_Ring(t *testing.T) {
+	shards := map[string]string{}
+	for i := range 2 {
+		shards[fmt.Sprintf("shard%d", i)] = miniredis.RunT(t).Addr()
+	}
+	client := redis.NewRing(&redis.RingOptions{Addrs: shards})
+	t.Cleanup(func() { client.Close() })
+
+	var names []string
+	for i := range 20 {
+		names = append(names, fmt.Sprintf("stream.%02d", i))
+	}
+	addStreams(t, client, names...)
+
+	streams, err := NewRedisStream(client).ScanStreams(context.Background(), "*")
+	require.NoError(t, err)
+	require.Len(t, streams, len(names))
+
+	nodes := map[string]bool{}
+	for i, stream := range streams {
+		require.Equal(t, names[i], stream.Name)
+		require.Nil(t, stream.Slot)
+		nodes[stream.Node] = true
+	}
+	require.Len(t, nodes, 2)
+}
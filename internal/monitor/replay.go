@@ -0,0 +1,160 @@
+package monitor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/vmihailenco/msgpack"
+)
+
+const (
+	maxReplayLineSize = 16 << 20
+	maxReplayErrors   = 1000
+)
+
+var errReplayForbidden = errors.New("not allowed to write to destination stream")
+
+// Replay reads NDJSON records as produced by an ExportFormatNdjson export
+// from r and writes them back to Redis. Records that fail are reported in
+// the result and do not stop the replay; only read errors and context
+// cancellation abort it.
+func (s *StreamService) Replay(ctx context.Context, r io.Reader, opts ReplayOpts) (*ReplayResult, error) {
+	result := &ReplayResult{DryRun: opts.DryRun}
+
+	var interval time.Duration
+	if opts.Rate > 0 {
+		interval = time.Duration(float64(time.Second) / opts.Rate)
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxReplayLineSize)
+
+	var (
+		line int
+		last time.Time
+	)
+
+	for scanner.Scan() {
+		line++
+
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		result.Total++
+
+		var record ExportRecord
+		if err := json.Unmarshal(raw, &record); err != nil {
+			result.fail(line, "", fmt.Errorf("invalid record: %w", err))
+			continue
+		}
+		if record.Error != "" {
+			result.fail(line, "", fmt.Errorf("export failed: %s", record.Error))
+			continue
+		}
+
+		stream, fields, err := replayEntry(record, opts.Target)
+		if err == nil && opts.Allow != nil && !opts.Allow(stream) {
+			err = errReplayForbidden
+		}
+		if err != nil {
+			result.fail(line, record.ID, err)
+			continue
+		}
+
+		if opts.DryRun {
+			result.Replayed++
+			continue
+		}
+
+		if interval > 0 {
+			if wait := interval - time.Since(last); wait > 0 {
+				select {
+				case <-ctx.Done():
+					return result, ctx.Err()
+				case <-time.After(wait):
+				}
+			}
+			last = time.Now()
+		}
+
+		if _, err := s.monitor.AddMessage(ctx, stream, fields); err != nil {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			result.fail(line, record.ID, fmt.Errorf("failed to publish to %s: %w", stream, err))
+			continue
+		}
+		result.Replayed++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return result, fmt.Errorf("failed to read records: %w", err)
+	}
+
+	return result, nil
+}
+
+func (r *ReplayResult) fail(line int, id string, err error) {
+	r.Failed++
+	if len(r.Errors) < maxReplayErrors {
+		r.Errors = append(r.Errors, ReplayError{Line: line, ID: id, Error: err.Error()})
+	}
+}
+
+// replayEntry resolves the destination stream of record and the fields to
+// write. Raw fields are replayed as-is. Records going back to the topic
+// they were poisoned from lose their poison metadata, as they would when
+// requeued, so their metadata is rebuilt from the decoded copy; so is the
+// metadata of exports that predate base64 encoded fields, which did not
+// survive JSON encoding.
+func replayEntry(record ExportRecord, target string) (string, map[string]any, error) {
+	original := record.Metadata[TopicPoisonedKey]
+
+	stream := target
+	if stream == "" {
+		stream = original
+	}
+	if stream == "" {
+		stream = record.Stream
+	}
+	if stream == "" {
+		return "", nil, errors.New("no destination stream")
+	}
+
+	metadata := record.Metadata
+	stripped := original != "" && stream == original
+	if stripped {
+		metadata = withoutPoisonMetadata(metadata)
+	}
+
+	if len(record.Fields) == 0 {
+		if record.Payload == nil {
+			return "", nil, errors.New("record has neither fields nor payload")
+		}
+		fields, err := watermillFields(record.UUID, record.Payload, metadata)
+		return stream, fields, err
+	}
+
+	fields, err := decodeFields(record.Fields, record.Base64Fields)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if record.Metadata != nil && (stripped || !slices.Contains(record.Base64Fields, WatermillMetadataKey)) {
+		metadataBytes, err := msgpack.Marshal(metadata)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+		fields[WatermillMetadataKey] = string(metadataBytes)
+	}
+
+	return stream, fields, nil
+}
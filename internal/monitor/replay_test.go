@@ -0,0 +1,177 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/suite"
+)
+
+type ReplayTestSuite struct {
+	suite.Suite
+	mr      *miniredis.Miniredis
+	client  redis.UniversalClient
+	streams *StreamService
+	dlq     *DLQService
+	dlqName string
+}
+
+func (s *ReplayTestSuite) SetupTest() {
+	s.mr = miniredis.RunT(s.T())
+	s.client = redis.NewClient(&redis.Options{Addr: s.mr.Addr()})
+	s.dlqName = "test_dlq"
+	stream := NewRedisStream(s.client)
+	s.streams = NewStreamService(stream, s.dlqName)
+	s.dlq = NewDLQService(stream, s.dlqName)
+}
+
+func (s *ReplayTestSuite) TearDownTest() {
+	s.client.Close()
+	s.mr.Close()
+}
+
+func (s *ReplayTestSuite) exportDLQ() *bytes.Buffer {
+	var buf bytes.Buffer
+	err := s.dlq.Export(context.Background(), ExportFormatNdjson, ExportOpts{Order: SortOrderAsc}, &buf)
+	s.Require().NoError(err)
+	return &buf
+}
+
+func (s *ReplayTestSuite) TestReplayToOriginalTopic() {
+	ctx := context.Background()
+
+	addDLQMessage(s.T(), s.client, s.dlqName, "orders.created", map[string]any{"id": 1})
+	addDLQMessage(s.T(), s.client, s.dlqName, "payments.processed", map[string]any{"id": 2})
+
+	result, err := s.streams.Replay(ctx, s.exportDLQ(), ReplayOpts{})
+	s.Require().NoError(err)
+	s.Equal(2, result.Total)
+	s.Equal(2, result.Replayed)
+	s.Zero(result.Failed)
+
+	msgs, err := s.client.XRange(ctx, "orders.created", "-", "+").Result()
+	s.Require().NoError(err)
+	s.Require().Len(msgs, 1)
+
+	wmMsg, err := ParseWatermillMessage(msgs[0].Values)
+	s.Require().NoError(err)
+	s.Equal("test-uuid", wmMsg.UUID)
+	s.Equal(float64(1), wmMsg.Payload["id"])
+	s.NotContains(wmMsg.Metadata, TopicPoisonedKey)
+	s.NotContains(wmMsg.Metadata, ReasonPoisonedKey)
+
+	length, err := s.client.XLen(ctx, "payments.processed").Result()
+	s.Require().NoError(err)
+	s.Equal(int64(1), length)
+}
+
+func (s *ReplayTestSuite) TestReplayToTarget() {
+	ctx := context.Background()
+
+	addDLQMessage(s.T(), s.client, s.dlqName, "orders.created", map[string]any{"id": 1})
+
+	result, err := s.streams.Replay(ctx, s.exportDLQ(), ReplayOpts{Target: "orders.replayed"})
+	s.Require().NoError(err)
+	s.Equal(1, result.Replayed)
+
+	msgs, err := s.client.XRange(ctx, "orders.replayed", "-", "+").Result()
+	s.Require().NoError(err)
+	s.Require().Len(msgs, 1)
+
+	wmMsg, err := ParseWatermillMessage(msgs[0].Values)
+	s.Require().NoError(err)
+	s.Equal("orders.created", wmMsg.Metadata[TopicPoisonedKey])
+
+	// The raw metadata is written back byte for byte.
+	original, err := s.client.XRange(ctx, s.dlqName, "-", "+").Result()
+	s.Require().NoError(err)
+	s.Equal(original[0].Values[WatermillMetadataKey], msgs[0].Values[WatermillMetadataKey])
+}
+
+func (s *ReplayTestSuite) TestReplayReportsFailedExport() {
+	ctx := context.Background()
+
+	addDLQMessage(s.T(), s.client, s.dlqName, "orders.created", map[string]any{"id": 1})
+	buf := s.exportDLQ()
+	buf.WriteString(`{"error":"connection reset"}` + "\n")
+
+	result, err := s.streams.Replay(ctx, buf, ReplayOpts{Target: "orders.replayed"})
+	s.Require().NoError(err)
+	s.Equal(1, result.Replayed)
+	s.Equal(1, result.Failed)
+	s.Contains(result.Errors[0].Error, "export failed: connection reset")
+}
+
+func (s *ReplayTestSuite) TestReplayDryRun() {
+	ctx := context.Background()
+
+	addTestMessage(s.T(), s.client, "orders.created", map[string]any{"id": 1})
+
+	var buf bytes.Buffer
+	s.Require().NoError(s.streams.Export(ctx, "orders.created", ExportFormatNdjson, ExportOpts{}, &buf))
+
+	result, err := s.streams.Replay(ctx, &buf, ReplayOpts{DryRun: true})
+	s.Require().NoError(err)
+	s.True(result.DryRun)
+	s.Equal(1, result.Replayed)
+
+	length, err := s.client.XLen(ctx, "orders.created").Result()
+	s.Require().NoError(err)
+	s.Equal(int64(1), length)
+}
+
+func (s *ReplayTestSuite) TestReplayReportsRecordErrors() {
+	ctx := context.Background()
+
+	input := strings.Join([]string{
+		`{"id":"1-0","stream":"orders.created","payload":{"id":1}}`,
+		``,
+		`not json`,
+		`{"id":"2-0","stream":"orders.created"}`,
+		`{"id":"3-0","stream":"secret.stream","payload":{"id":3}}`,
+	}, "\n")
+
+	result, err := s.streams.Replay(ctx, strings.NewReader(input), ReplayOpts{
+		Allow: func(stream string) bool { return stream != "secret.stream" },
+	})
+	s.Require().NoError(err)
+	s.Equal(4, result.Total)
+	s.Equal(1, result.Replayed)
+	s.Equal(3, result.Failed)
+	s.Require().Len(result.Errors, 3)
+	s.Equal(3, result.Errors[0].Line)
+	s.Equal("2-0", result.Errors[1].ID)
+	s.Equal(5, result.Errors[2].Line)
+
+	msgs, err := s.client.XRange(ctx, "orders.created", "-", "+").Result()
+	s.Require().NoError(err)
+	s.Require().Len(msgs, 1)
+
+	wmMsg, err := ParseWatermillMessage(msgs[0].Values)
+	s.Require().NoError(err)
+	s.NotEmpty(wmMsg.UUID)
+	s.Equal(float64(1), wmMsg.Payload["id"])
+}
+
+func (s *ReplayTestSuite) TestReplayHonoursContext() {
+	addTestMessage(s.T(), s.client, "orders.created", map[string]any{"id": 1})
+	addTestMessage(s.T(), s.client, "orders.created", map[string]any{"id": 2})
+
+	var buf bytes.Buffer
+	s.Require().NoError(s.streams.Export(context.Background(), "orders.created", ExportFormatNdjson, ExportOpts{}, &buf))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := s.streams.Replay(ctx, &buf, ReplayOpts{Target: "orders.replayed", Rate: 1})
+	s.ErrorIs(err, context.Canceled)
+	s.LessOrEqual(result.Replayed, 1)
+}
+
+func TestReplayTestSuite(t *testing.T) {
+	suite.Run(t, new(ReplayTestSuite))
+}
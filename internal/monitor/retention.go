@@ -0,0 +1,118 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"time"
+)
+
+// RetentionWorker trims streams according to their retention policies.
+type RetentionWorker struct {
+	streams  *StreamService
+	policies []RetentionPolicy
+	logger   *slog.Logger
+	now      func() time.Time
+}
+
+func NewRetentionWorker(streams *StreamService, policies []RetentionPolicy, logger *slog.Logger) *RetentionWorker {
+	return &RetentionWorker{
+		streams:  streams,
+		policies: policies,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// Validate reports policies that match no stream name or bound nothing.
+func (w *RetentionWorker) Validate() error {
+	for _, p := range w.policies {
+		if _, err := path.Match(p.Stream, ""); err != nil || p.Stream == "" {
+			return fmt.Errorf("invalid retention stream pattern %q", p.Stream)
+		}
+		if p.MaxLen < 0 || p.MaxAge < 0 {
+			return fmt.Errorf("retention policy for %q has a negative bound", p.Stream)
+		}
+		if p.MaxLen == 0 && p.MaxAge == 0 {
+			return fmt.Errorf("retention policy for %q sets neither max length nor max age", p.Stream)
+		}
+	}
+	return nil
+}
+
+// Policy returns the first policy matching stream, if any.
+func (w *RetentionWorker) Policy(stream string) (RetentionPolicy, bool) {
+	for _, p := range w.policies {
+		if ok, _ := path.Match(p.Stream, stream); ok {
+			return p, true
+		}
+	}
+	return RetentionPolicy{}, false
+}
+
+// Run enforces the policies once on every stream of the catalog, so the DLQ
+// and streams the catalog excludes are never trimmed. Streams whose trim
+// would remove unprocessed entries are skipped and trimmed on a later run.
+func (w *RetentionWorker) Run(ctx context.Context) error {
+	if len(w.policies) == 0 {
+		return nil
+	}
+
+	streams, err := w.streams.GetStreams(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, info := range streams {
+		stream := info.Name
+		policy, ok := w.Policy(stream)
+		if !ok {
+			continue
+		}
+
+		for _, opts := range w.trims(policy) {
+			if err := checkFence(ctx); err != nil {
+				return errors.Join(append(errs, err)...)
+			}
+
+			result, err := w.streams.Trim(ctx, stream, opts)
+
+			var pending *PendingEntriesError
+			switch {
+			case errors.As(err, &pending):
+				w.logger.Warn("retention trim skipped", "stream", stream, "group", pending.Group, "entry", pending.ID)
+			case err != nil:
+				errs = append(errs, err)
+			case result.Removed > 0:
+				w.logger.Info("retention trimmed stream", "stream", stream, "strategy", opts.Strategy, "removed", result.Removed)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (w *RetentionWorker) trims(policy RetentionPolicy) []TrimOpts {
+	var trims []TrimOpts
+
+	if policy.MaxLen > 0 {
+		trims = append(trims, TrimOpts{
+			Strategy:    TrimStrategyMaxlen,
+			MaxLen:      policy.MaxLen,
+			Approximate: policy.Approximate,
+		})
+	}
+
+	if policy.MaxAge > 0 {
+		trims = append(trims, TrimOpts{
+			Strategy:    TrimStrategyMinid,
+			MinID:       fmt.Sprintf("%d-0", w.now().Add(-policy.MaxAge).UnixMilli()),
+			Approximate: policy.Approximate,
+		})
+	}
+
+	return trims
+}
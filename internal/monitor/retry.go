@@ -0,0 +1,164 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"path"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRetryMultiplier = 2.0
+	retryPageSize          = 100
+)
+
+// RetryWorker requeues DLQ messages according to their retry policies.
+type RetryWorker struct {
+	dlq      *DLQService
+	policies []RetryPolicy
+	patterns []*regexp.Regexp
+	errs     []error
+	logger   *slog.Logger
+	now      func() time.Time
+}
+
+func NewRetryWorker(dlq *DLQService, policies []RetryPolicy, logger *slog.Logger) *RetryWorker {
+	w := &RetryWorker{
+		dlq:      dlq,
+		policies: policies,
+		patterns: make([]*regexp.Regexp, len(policies)),
+		logger:   logger,
+		now:      time.Now,
+	}
+
+	for i, p := range policies {
+		if p.ErrorPattern == "" {
+			continue
+		}
+
+		re, err := regexp.Compile(p.ErrorPattern)
+		if err != nil {
+			w.errs = append(w.errs, fmt.Errorf("invalid retry error pattern %q: %w", p.ErrorPattern, err))
+			continue
+		}
+		w.patterns[i] = re
+	}
+
+	return w
+}
+
+// Validate reports invalid topic or error patterns and policies that never
+// retry.
+func (w *RetryWorker) Validate() error {
+	errs := append([]error(nil), w.errs...)
+
+	for _, p := range w.policies {
+		if _, err := path.Match(p.Topic, ""); err != nil || p.Topic == "" {
+			errs = append(errs, fmt.Errorf("invalid retry topic pattern %q", p.Topic))
+		}
+		if p.MaxAttempts <= 0 {
+			errs = append(errs, fmt.Errorf("retry policy for %q needs a positive max attempts", p.Topic))
+		}
+		if p.InitialBackoff < 0 || p.MaxBackoff < 0 || p.Multiplier < 0 {
+			errs = append(errs, fmt.Errorf("retry policy for %q has a negative backoff", p.Topic))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Policy returns the first policy matching the topic and error of msg.
+func (w *RetryWorker) Policy(msg *DLQMessage) (RetryPolicy, bool) {
+	for i, p := range w.policies {
+		if ok, _ := path.Match(p.Topic, msg.OriginalTopic); !ok {
+			continue
+		}
+		if w.patterns[i] != nil && !w.patterns[i].MatchString(msg.Error) {
+			continue
+		}
+		return p, true
+	}
+	return RetryPolicy{}, false
+}
+
+// NextRetry returns when msg is due for its next attempt under policy, or
+// false once it has used up its attempts.
+func (p RetryPolicy) NextRetry(msg *DLQMessage) (time.Time, bool) {
+	if msg.Attempts >= p.MaxAttempts {
+		return time.Time{}, false
+	}
+
+	multiplier := p.Multiplier
+	if multiplier == 0 {
+		multiplier = defaultRetryMultiplier
+	}
+
+	backoff := float64(p.InitialBackoff) * math.Pow(multiplier, float64(msg.Attempts))
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+
+	return msg.Timestamp.Add(time.Duration(backoff)), true
+}
+
+// Run requeues every DLQ message that is due for a retry, recording the
+// attempt in its metadata.
+func (w *RetryWorker) Run(ctx context.Context) error {
+	if len(w.policies) == 0 {
+		return nil
+	}
+
+	var (
+		retried int
+		errs    []error
+		opts    = PaginationOpts{Limit: retryPageSize, Order: SortOrderAsc}
+	)
+
+	for {
+		list, err := w.dlq.GetMessages(ctx, opts)
+		if err != nil {
+			return err
+		}
+
+		for _, msg := range list.Messages {
+			policy, ok := w.Policy(&msg)
+			if !ok {
+				continue
+			}
+
+			due, ok := policy.NextRetry(&msg)
+			if !ok || due.After(w.now()) {
+				continue
+			}
+
+			if err := checkFence(ctx); err != nil {
+				return errors.Join(append(errs, err)...)
+			}
+
+			metadata := withoutPoisonMetadata(msg.Metadata)
+			metadata[RetryAttemptKey] = strconv.Itoa(msg.Attempts + 1)
+
+			if err := w.dlq.requeueWithMetadata(ctx, &msg, metadata); err != nil {
+				errs = append(errs, fmt.Errorf("failed to retry message %s: %w", msg.ID, err))
+				continue
+			}
+			retried++
+		}
+
+		if !list.HasMore {
+			break
+		}
+		opts.Cursor = list.NextCursor
+	}
+
+	if retried > 0 {
+		w.logger.Info("retried dlq messages", "count", retried)
+	}
+
+	return errors.Join(errs...)
+}
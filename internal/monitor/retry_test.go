@@ -0,0 +1,128 @@
+package monitor
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/suite"
+	"github.com/vmihailenco/msgpack"
+)
+
+type RetryTestSuite struct {
+	suite.Suite
+	mr      *miniredis.Miniredis
+	client  redis.UniversalClient
+	dlq     *DLQService
+	dlqName string
+	logger  *slog.Logger
+}
+
+func (s *RetryTestSuite) SetupTest() {
+	s.mr = miniredis.RunT(s.T())
+	s.client = redis.NewClient(&redis.Options{Addr: s.mr.Addr()})
+	s.dlqName = "test_dlq"
+	s.dlq = NewDLQService(NewRedisStream(s.client), s.dlqName)
+	s.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func (s *RetryTestSuite) TearDownTest() {
+	s.client.Close()
+	s.mr.Close()
+}
+
+func (s *RetryTestSuite) poison(topic, reason string, metadata map[string]string) string {
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	metadata[TopicPoisonedKey] = topic
+	metadata[ReasonPoisonedKey] = reason
+
+	metadataBytes, err := msgpack.Marshal(metadata)
+	s.Require().NoError(err)
+
+	id, err := s.client.XAdd(context.Background(), &redis.XAddArgs{
+		Stream: s.dlqName,
+		Values: map[string]any{
+			WatermillUUIDKey:     "test-uuid",
+			WatermillPayloadKey:  `{"id":1}`,
+			WatermillMetadataKey: string(metadataBytes),
+		},
+	}).Result()
+	s.Require().NoError(err)
+	return id
+}
+
+func (s *RetryTestSuite) TestNextRetry() {
+	policy := RetryPolicy{Topic: "*", MaxAttempts: 3, InitialBackoff: time.Second, MaxBackoff: 3 * time.Second}
+	poisoned := time.Unix(0, 0)
+
+	due, ok := policy.NextRetry(&DLQMessage{Timestamp: poisoned})
+	s.True(ok)
+	s.Equal(poisoned.Add(time.Second), due)
+
+	due, ok = policy.NextRetry(&DLQMessage{Timestamp: poisoned, Attempts: 1})
+	s.True(ok)
+	s.Equal(poisoned.Add(2*time.Second), due)
+
+	due, ok = policy.NextRetry(&DLQMessage{Timestamp: poisoned, Attempts: 2})
+	s.True(ok)
+	s.Equal(poisoned.Add(3*time.Second), due)
+
+	_, ok = policy.NextRetry(&DLQMessage{Timestamp: poisoned, Attempts: 3})
+	s.False(ok)
+}
+
+func (s *RetryTestSuite) TestRunRetriesDueMessages() {
+	ctx := context.Background()
+
+	s.poison("orders.created", "connection refused", map[string]string{"trace_id": "abc"})
+	s.poison("orders.created", "validation failed", nil)
+	s.poison("orders.created", "connection refused", map[string]string{RetryAttemptKey: "3"})
+	s.poison("payments.processed", "connection refused", nil)
+
+	worker := NewRetryWorker(s.dlq, []RetryPolicy{
+		{Topic: "orders.*", ErrorPattern: "connection|timeout", MaxAttempts: 3, InitialBackoff: time.Minute},
+	}, s.logger)
+	s.Require().NoError(worker.Validate())
+
+	s.Require().NoError(worker.Run(ctx))
+	s.Equal(int64(4), s.client.XLen(ctx, s.dlqName).Val())
+
+	worker.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	s.Require().NoError(worker.Run(ctx))
+	s.Equal(int64(3), s.client.XLen(ctx, s.dlqName).Val())
+
+	msgs, err := s.client.XRange(ctx, "orders.created", "-", "+").Result()
+	s.Require().NoError(err)
+	s.Require().Len(msgs, 1)
+
+	wmMsg, err := ParseWatermillMessage(msgs[0].Values)
+	s.Require().NoError(err)
+	s.Equal("1", wmMsg.Metadata[RetryAttemptKey])
+	s.Equal("abc", wmMsg.Metadata["trace_id"])
+	s.NotContains(wmMsg.Metadata, TopicPoisonedKey)
+	s.Equal(float64(1), wmMsg.Payload["id"])
+}
+
+func (s *RetryTestSuite) TestAttemptsParsed() {
+	id := s.poison("orders.created", "boom", map[string]string{RetryAttemptKey: "2"})
+
+	msg, err := s.dlq.GetMessage(context.Background(), id)
+	s.Require().NoError(err)
+	s.Equal(2, msg.Attempts)
+}
+
+func (s *RetryTestSuite) TestValidate() {
+	s.Error(NewRetryWorker(s.dlq, []RetryPolicy{{Topic: "orders.*"}}, s.logger).Validate())
+	s.Error(NewRetryWorker(s.dlq, []RetryPolicy{{Topic: "orders.*", MaxAttempts: 1, ErrorPattern: "("}}, s.logger).Validate())
+	s.Error(NewRetryWorker(s.dlq, []RetryPolicy{{Topic: "[", MaxAttempts: 1}}, s.logger).Validate())
+}
+
+func TestRetryTestSuite(t *testing.T) {
+	suite.Run(t, new(RetryTestSuite))
+}
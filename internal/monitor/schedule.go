@@ -0,0 +1,465 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	schedulePageSize = 100
+
+	// scheduleRequeueAttempts bounds how often a requeue retries with a new topic ID.
+	scheduleRequeueAttempts = 20
+
+	// scheduleClaimTimeout hides a claimed schedule from other runs until a failed requeue is retried.
+	scheduleClaimTimeout = time.Minute
+)
+
+// claimScheduleScript pushes the due time of ARGV[1] from ARGV[2] to ARGV[3], returning 1 when claimed.
+var claimScheduleScript = redis.NewScript(`
+local score = redis.call("ZSCORE", KEYS[1], ARGV[1])
+if not score or tonumber(score) > tonumber(ARGV[2]) then
+	return 0
+end
+redis.call("ZADD", KEYS[1], ARGV[3], ARGV[1])
+return 1
+`)
+
+// setRequeueIDScript records ARGV[3] for DLQ message ARGV[1] if ARGV[2] ("" for none) is still recorded.
+var setRequeueIDScript = redis.NewScript(`
+local current = redis.call("HGET", KEYS[1], ARGV[1]) or ""
+if current ~= ARGV[2] then
+	return 0
+end
+redis.call("HSET", KEYS[1], ARGV[1], ARGV[3])
+return 1
+`)
+
+// ScheduledRequeue is a DLQ message due to be requeued at DueAt, optionally with a new Payload.
+type ScheduledRequeue struct {
+	ID      string         `json:"id"`
+	Topic   string         `json:"topic,omitempty"`
+	DueAt   time.Time      `json:"due_at"`
+	Payload map[string]any `json:"payload,omitempty"`
+}
+
+// The schedule is a sorted set of IDs by due time plus a payload hash, sharing a hash tag.
+func (d *DLQService) scheduleKey() string {
+	return "windmill:{" + d.dlqName + "}:scheduled"
+}
+
+func (d *DLQService) schedulePayloadKey() string {
+	return "windmill:{" + d.dlqName + "}:scheduled:payloads"
+}
+
+// scheduleRequeuedKey maps each DLQ message being requeued to its topic ID and UUID.
+func (d *DLQService) scheduleRequeuedKey() string {
+	return "windmill:{" + d.dlqName + "}:scheduled:requeued"
+}
+
+// ScheduleRequeue schedules the DLQ message id to be requeued at at, replacing any earlier one.
+func (d *DLQService) ScheduleRequeue(ctx context.Context, id string, at time.Time, payload map[string]any) (*ScheduledRequeue, error) {
+	msg, err := d.GetMessage(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if msg == nil {
+		return nil, fmt.Errorf("message not found: %s", id)
+	}
+
+	var payloadJSON []byte
+	if payload != nil {
+		if payloadJSON, err = json.Marshal(payload); err != nil {
+			return nil, fmt.Errorf("failed to marshal payload: %w", err)
+		}
+	}
+
+	_, err = d.monitor.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, d.scheduleKey(), redis.Z{Score: float64(at.UnixMilli()), Member: id})
+		if payloadJSON != nil {
+			pipe.HSet(ctx, d.schedulePayloadKey(), id, payloadJSON)
+		} else {
+			pipe.HDel(ctx, d.schedulePayloadKey(), id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to schedule requeue: %w", err)
+	}
+
+	return &ScheduledRequeue{ID: id, Topic: msg.OriginalTopic, DueAt: time.UnixMilli(at.UnixMilli()).UTC(), Payload: payload}, nil
+}
+
+// CancelRequeue removes the schedule of the DLQ message id and reports whether there was one.
+func (d *DLQService) CancelRequeue(ctx context.Context, id string) (bool, error) {
+	removed, err := d.unschedule(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel requeue: %w", err)
+	}
+	return removed, nil
+}
+
+// ScheduledRequeues returns a page of the schedules, limited to the topics allow accepts when set.
+func (d *DLQService) ScheduledRequeues(ctx context.Context, opts PaginationOpts, allow func(topic string) bool) (*MessageList[ScheduledRequeue], error) {
+	opts = opts.WithDefaults()
+
+	var offset int64
+	if opts.Cursor != "" {
+		n, err := strconv.ParseInt(opts.Cursor, 10, 64)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid cursor: %q", opts.Cursor)
+		}
+		offset = n
+	}
+
+	list := &MessageList[ScheduledRequeue]{Messages: []ScheduledRequeue{}}
+
+	if allow == nil {
+		total, err := d.monitor.client.ZCard(ctx, d.scheduleKey()).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		page, err := d.scheduledPage(ctx, offset, opts.Limit, opts.Order)
+		if err != nil {
+			return nil, err
+		}
+
+		list.Messages = page
+		list.TotalCount = total
+		list.HasMore = offset+int64(len(page)) < total
+		if list.HasMore {
+			list.NextCursor = strconv.FormatInt(offset+int64(len(page)), 10)
+		}
+		return list, nil
+	}
+
+	// Filtering needs every schedule, so they are counted in the same pass.
+	for start := int64(0); ; start += schedulePageSize {
+		page, err := d.scheduledPage(ctx, start, schedulePageSize, opts.Order)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, scheduled := range page {
+			if !allow(scheduled.Topic) {
+				continue
+			}
+			if list.TotalCount >= offset && int64(len(list.Messages)) < opts.Limit {
+				list.Messages = append(list.Messages, scheduled)
+			}
+			list.TotalCount++
+		}
+
+		if len(page) < schedulePageSize {
+			break
+		}
+	}
+
+	list.HasMore = offset+int64(len(list.Messages)) < list.TotalCount
+	if list.HasMore {
+		list.NextCursor = strconv.FormatInt(offset+int64(len(list.Messages)), 10)
+	}
+	return list, nil
+}
+
+// scheduledPage reads count schedules from offset with their payloads and topics in one pipeline.
+func (d *DLQService) scheduledPage(ctx context.Context, offset, count int64, order SortOrder) ([]ScheduledRequeue, error) {
+	var (
+		entries []redis.Z
+		err     error
+	)
+	if order == SortOrderAsc {
+		entries, err = d.monitor.client.ZRangeWithScores(ctx, d.scheduleKey(), offset, offset+count-1).Result()
+	} else {
+		entries, err = d.monitor.client.ZRevRangeWithScores(ctx, d.scheduleKey(), offset, offset+count-1).Result()
+	}
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+
+	ids := make([]string, len(entries))
+	for i, entry := range entries {
+		ids[i], _ = entry.Member.(string)
+	}
+
+	var (
+		payloads *redis.SliceCmd
+		messages = make([]*redis.XMessageSliceCmd, len(ids))
+	)
+	_, err = d.monitor.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		payloads = pipe.HMGet(ctx, d.schedulePayloadKey(), ids...)
+		for i, id := range ids {
+			messages[i] = pipe.XRangeN(ctx, d.dlqName, id, id, 1)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	scheduled := make([]ScheduledRequeue, len(entries))
+	for i, entry := range entries {
+		payload, err := decodeScheduledPayload(ids[i], payloads.Val()[i])
+		if err != nil {
+			return nil, err
+		}
+
+		var topic string
+		if msgs := messages[i].Val(); len(msgs) > 0 {
+			if msg, err := d.parseMessage(msgs[0].ID, msgs[0].Values); err == nil {
+				topic = msg.OriginalTopic
+			}
+		}
+
+		scheduled[i] = ScheduledRequeue{
+			ID:      ids[i],
+			Topic:   topic,
+			DueAt:   time.UnixMilli(int64(entry.Score)).UTC(),
+			Payload: payload,
+		}
+	}
+
+	return scheduled, nil
+}
+
+// RunScheduled requeues, exactly once, every message whose schedule is due.
+func (d *DLQService) RunScheduled(ctx context.Context, now time.Time) error {
+	var errs []error
+
+	for {
+		ids, err := d.monitor.client.ZRangeByScore(ctx, d.scheduleKey(), &redis.ZRangeBy{
+			Min:   "-inf",
+			Max:   strconv.FormatInt(now.UnixMilli(), 10),
+			Count: schedulePageSize,
+		}).Result()
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			if err := checkFence(ctx); err != nil {
+				return errors.Join(append(errs, err)...)
+			}
+
+			// An unclaimable schedule stays due, so carrying on would read it again.
+			claimed, err := d.claimSchedule(ctx, id, now)
+			if err != nil {
+				return errors.Join(append(errs, err)...)
+			}
+			if !claimed {
+				continue
+			}
+
+			if err := d.runSchedule(ctx, id); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		// Every schedule read was claimed, which took it out of the range.
+		if len(ids) < schedulePageSize {
+			return errors.Join(errs...)
+		}
+	}
+}
+
+// claimSchedule pushes a due schedule scheduleClaimTimeout past now, reporting whether this call claimed it.
+func (d *DLQService) claimSchedule(ctx context.Context, id string, now time.Time) (bool, error) {
+	claimed, err := claimScheduleScript.Run(ctx, d.monitor.client, []string{d.scheduleKey()},
+		id, now.UnixMilli(), now.Add(scheduleClaimTimeout).UnixMilli()).Int64()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim scheduled message %s: %w", id, err)
+	}
+	return claimed == 1, nil
+}
+
+// runSchedule requeues the message of a claimed schedule and removes the schedule.
+func (d *DLQService) runSchedule(ctx context.Context, id string) error {
+	payload, err := d.scheduledPayload(ctx, id)
+	var invalid *invalidPayloadError
+	if errors.As(err, &invalid) {
+		if _, uerr := d.unschedule(ctx, id); uerr != nil {
+			return errors.Join(err, uerr)
+		}
+		return fmt.Errorf("dropped scheduled message %s: %w", id, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	msg, err := d.GetMessage(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if msg != nil {
+		if payload != nil {
+			msg.Payload = payload
+		}
+		if err := d.requeueOnce(ctx, msg); err != nil {
+			return fmt.Errorf("failed to requeue scheduled message %s: %w", id, err)
+		}
+	}
+
+	_, err = d.unschedule(ctx, id)
+	return err
+}
+
+// requeueOnce publishes msg under a pre-recorded ID and UUID, so a retry never adds it twice.
+func (d *DLQService) requeueOnce(ctx context.Context, msg *DLQMessage) error {
+	if msg.OriginalTopic == "" {
+		return fmt.Errorf("original topic not found in message metadata")
+	}
+
+	record, err := d.monitor.client.HGet(ctx, d.scheduleRequeuedKey(), msg.ID).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		targetID, uuid, _ := strings.Cut(record, " ")
+		if record != "" {
+			fields, err := watermillFields(uuid, msg.Payload, nil)
+			if err != nil {
+				return err
+			}
+
+			added, err := d.requeueAdd(ctx, msg.OriginalTopic, targetID, fields)
+			if err != nil {
+				return fmt.Errorf("failed to publish to original topic: %w", err)
+			}
+			if added {
+				break
+			}
+		}
+
+		if attempt == scheduleRequeueAttempts {
+			return fmt.Errorf("failed to publish to original topic: no free stream id after %d attempts", attempt)
+		}
+
+		nextID, err := d.requeueID(ctx, msg.OriginalTopic, targetID)
+		if err != nil {
+			return err
+		}
+		if uuid == "" {
+			uuid = generateUUID()
+		}
+
+		next := nextID + " " + uuid
+		set, err := setRequeueIDScript.Run(ctx, d.monitor.client, []string{d.scheduleRequeuedKey()},
+			msg.ID, record, next).Int64()
+		if err != nil {
+			return err
+		}
+		if set == 1 {
+			record = next
+			continue
+		}
+
+		// Another run recorded an ID first; carry on with it.
+		if record, err = d.monitor.client.HGet(ctx, d.scheduleRequeuedKey(), msg.ID).Result(); err != nil {
+			return err
+		}
+	}
+
+	return d.DeleteMessage(ctx, msg.ID)
+}
+
+// requeueAdd adds fields to topic under id, reporting false when another entry took id.
+func (d *DLQService) requeueAdd(ctx context.Context, topic, id string, fields map[string]any) (bool, error) {
+	existing, err := d.monitor.ReadMessage(ctx, topic, id)
+	if err != nil || existing != nil {
+		return existing != nil && existing.Values[WatermillUUIDKey] == fields[WatermillUUIDKey], err
+	}
+
+	err = d.monitor.client.XAdd(ctx, &redis.XAddArgs{Stream: topic, ID: id, Values: fields}).Err()
+	if err == nil {
+		return true, nil
+	}
+	if !strings.Contains(err.Error(), "equal or smaller") {
+		return false, err
+	}
+
+	// Another run may have added it in the meantime.
+	existing, err = d.monitor.ReadMessage(ctx, topic, id)
+	return existing != nil && existing.Values[WatermillUUIDKey] == fields[WatermillUUIDKey], err
+}
+
+// requeueID returns an ID newer than both the last entry of topic and rejected.
+func (d *DLQService) requeueID(ctx context.Context, topic, rejected string) (string, error) {
+	id := fmt.Sprintf("%d-0", time.Now().UnixMilli())
+
+	last, err := d.monitor.ReadMessages(ctx, topic, PaginationOpts{Limit: 1, Order: SortOrderDesc})
+	if err != nil {
+		return "", err
+	}
+	if len(last) > 0 && compareStreamIDs(id, last[0].ID) <= 0 {
+		id = nextStreamID(last[0].ID)
+	}
+	if rejected != "" && compareStreamIDs(id, rejected) <= 0 {
+		id = nextStreamID(rejected)
+	}
+
+	return id, nil
+}
+
+// unschedule removes the schedule of id, reporting whether this call removed it.
+func (d *DLQService) unschedule(ctx context.Context, id string) (bool, error) {
+	var removed *redis.IntCmd
+	_, err := d.monitor.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		removed = pipe.ZRem(ctx, d.scheduleKey(), id)
+		pipe.HDel(ctx, d.schedulePayloadKey(), id)
+		pipe.HDel(ctx, d.scheduleRequeuedKey(), id)
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return removed.Val() == 1, nil
+}
+
+func (d *DLQService) scheduledPayload(ctx context.Context, id string) (map[string]any, error) {
+	raw, err := d.monitor.client.HGet(ctx, d.schedulePayloadKey(), id).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeScheduledPayload(id, raw)
+}
+
+// decodeScheduledPayload decodes raw, a payload override or nil for none.
+func decodeScheduledPayload(id string, raw any) (map[string]any, error) {
+	text, ok := raw.(string)
+	if !ok {
+		return nil, nil
+	}
+
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(text), &payload); err != nil {
+		return nil, &invalidPayloadError{id: id, err: err}
+	}
+	return payload, nil
+}
+
+// invalidPayloadError reports a scheduled payload that cannot be decoded.
+type invalidPayloadError struct {
+	id  string
+	err error
+}
+
+func (e *invalidPayloadError) Error() string {
+	return fmt.Sprintf("invalid scheduled payload for %s: %v", e.id, e.err)
+}
+
+func (e *invalidPayloadError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,325 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/suite"
+	"github.com/vmihailenco/msgpack"
+)
+
+type ScheduleTestSuite struct {
+	suite.Suite
+	mr      *miniredis.Miniredis
+	client  redis.UniversalClient
+	dlq     *DLQService
+	dlqName string
+}
+
+func (s *ScheduleTestSuite) SetupTest() {
+	s.mr = miniredis.RunT(s.T())
+	s.client = redis.NewClient(&redis.Options{Addr: s.mr.Addr()})
+	s.dlqName = "test_dlq"
+	s.dlq = NewDLQService(NewRedisStream(s.client), s.dlqName)
+}
+
+func (s *ScheduleTestSuite) TearDownTest() {
+	s.client.Close()
+	s.mr.Close()
+}
+
+func (s *ScheduleTestSuite) poison(topic string) string {
+	metadata, err := msgpack.Marshal(map[string]string{
+		TopicPoisonedKey:  topic,
+		ReasonPoisonedKey: "boom",
+	})
+	s.Require().NoError(err)
+
+	id, err := s.client.XAdd(context.Background(), &redis.XAddArgs{
+		Stream: s.dlqName,
+		Values: map[string]any{
+			WatermillUUIDKey:     "test-uuid",
+			WatermillPayloadKey:  `{"id":1}`,
+			WatermillMetadataKey: string(metadata),
+		},
+	}).Result()
+	s.Require().NoError(err)
+	return id
+}
+
+func (s *ScheduleTestSuite) scheduled() []ScheduledRequeue {
+	list, err := s.dlq.ScheduledRequeues(context.Background(), PaginationOpts{Limit: 100, Order: SortOrderAsc}, nil)
+	s.Require().NoError(err)
+	return list.Messages
+}
+
+func (s *ScheduleTestSuite) TestScheduleRequeue() {
+	ctx := context.Background()
+	id := s.poison("orders.created")
+	now := time.Now()
+
+	scheduled, err := s.dlq.ScheduleRequeue(ctx, id, now.Add(time.Minute), map[string]any{"id": 2})
+	s.Require().NoError(err)
+	s.Equal("orders.created", scheduled.Topic)
+
+	s.Require().NoError(s.dlq.RunScheduled(ctx, now))
+	s.Equal(int64(1), s.client.XLen(ctx, s.dlqName).Val())
+
+	list := s.scheduled()
+	s.Require().Len(list, 1)
+	s.Equal(id, list[0].ID)
+	s.Equal(map[string]any{"id": float64(2)}, list[0].Payload)
+
+	s.Require().NoError(s.dlq.RunScheduled(ctx, now.Add(time.Minute)))
+	s.Zero(s.client.XLen(ctx, s.dlqName).Val())
+
+	messages, err := s.client.XRange(ctx, "orders.created", "-", "+").Result()
+	s.Require().NoError(err)
+	s.Require().Len(messages, 1)
+	s.Equal(`{"id":2}`, messages[0].Values[WatermillPayloadKey])
+
+	list = s.scheduled()
+	s.Empty(list)
+}
+
+func (s *ScheduleTestSuite) TestScheduleRequeue_MessageNotFound() {
+	_, err := s.dlq.ScheduleRequeue(context.Background(), "1-0", time.Now(), nil)
+	s.Error(err)
+}
+
+func (s *ScheduleTestSuite) TestScheduleRequeue_Reschedule() {
+	ctx := context.Background()
+	id := s.poison("orders.created")
+	now := time.Now()
+
+	_, err := s.dlq.ScheduleRequeue(ctx, id, now, map[string]any{"id": 2})
+	s.Require().NoError(err)
+	_, err = s.dlq.ScheduleRequeue(ctx, id, now.Add(time.Hour), nil)
+	s.Require().NoError(err)
+
+	list := s.scheduled()
+	s.Require().Len(list, 1)
+	s.Nil(list[0].Payload)
+
+	s.Require().NoError(s.dlq.RunScheduled(ctx, now))
+	s.Equal(int64(1), s.client.XLen(ctx, s.dlqName).Val())
+}
+
+func (s *ScheduleTestSuite) TestCancelRequeue() {
+	ctx := context.Background()
+	id := s.poison("orders.created")
+
+	_, err := s.dlq.ScheduleRequeue(ctx, id, time.Now(), nil)
+	s.Require().NoError(err)
+
+	cancelled, err := s.dlq.CancelRequeue(ctx, id)
+	s.Require().NoError(err)
+	s.True(cancelled)
+
+	cancelled, err = s.dlq.CancelRequeue(ctx, id)
+	s.Require().NoError(err)
+	s.False(cancelled)
+
+	s.Require().NoError(s.dlq.RunScheduled(ctx, time.Now()))
+	s.Equal(int64(1), s.client.XLen(ctx, s.dlqName).Val())
+}
+
+func (s *ScheduleTestSuite) TestRunScheduled_MessageGone() {
+	ctx := context.Background()
+	id := s.poison("orders.created")
+
+	_, err := s.dlq.ScheduleRequeue(ctx, id, time.Now(), nil)
+	s.Require().NoError(err)
+	s.Require().NoError(s.dlq.DeleteMessage(ctx, id))
+
+	s.Require().NoError(s.dlq.RunScheduled(ctx, time.Now()))
+	s.Zero(s.client.XLen(ctx, "orders.created").Val())
+
+	list := s.scheduled()
+	s.Empty(list)
+}
+
+func (s *ScheduleTestSuite) TestRunScheduled_FiresOnceAcrossReplicas() {
+	ctx := context.Background()
+	now := time.Now()
+	for range 20 {
+		_, err := s.dlq.ScheduleRequeue(ctx, s.poison("orders.created"), now, nil)
+		s.Require().NoError(err)
+	}
+
+	var wg sync.WaitGroup
+	for range 4 {
+		client := redis.NewClient(&redis.Options{Addr: s.mr.Addr()})
+		replica := NewDLQService(NewRedisStream(client), s.dlqName)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer client.Close()
+			s.NoError(replica.RunScheduled(ctx, now))
+		}()
+	}
+	wg.Wait()
+
+	s.Equal(int64(20), s.client.XLen(ctx, "orders.created").Val())
+	s.Zero(s.client.XLen(ctx, s.dlqName).Val())
+}
+
+func (s *ScheduleTestSuite) TestRunScheduled_RetriesFailedRequeue() {
+	ctx := context.Background()
+	now := time.Now()
+
+	// Without an original topic the requeue fails.
+	id := s.poison("")
+	_, err := s.dlq.ScheduleRequeue(ctx, id, now, nil)
+	s.Require().NoError(err)
+
+	s.Require().Error(s.dlq.RunScheduled(ctx, now))
+
+	list := s.scheduled()
+	s.Require().Len(list, 1)
+	s.Equal(now.Add(scheduleClaimTimeout).UnixMilli(), list[0].DueAt.UnixMilli())
+
+	// Not retried before the claim times out.
+	s.Require().NoError(s.dlq.RunScheduled(ctx, now.Add(time.Second)))
+	s.Require().Error(s.dlq.RunScheduled(ctx, now.Add(scheduleClaimTimeout)))
+}
+
+func (s *ScheduleTestSuite) TestRunScheduled_DropsInvalidPayload() {
+	ctx := context.Background()
+	now := time.Now()
+
+	// More than a page of them must not keep the run looping.
+	for range schedulePageSize + 1 {
+		id := s.poison("orders.created")
+		_, err := s.dlq.ScheduleRequeue(ctx, id, now, nil)
+		s.Require().NoError(err)
+		s.Require().NoError(s.client.HSet(ctx, s.dlq.schedulePayloadKey(), id, "{").Err())
+	}
+
+	err := s.dlq.RunScheduled(ctx, now)
+	s.Require().Error(err)
+	s.Contains(err.Error(), "invalid scheduled payload")
+
+	s.Zero(s.client.ZCard(ctx, s.dlq.scheduleKey()).Val())
+	s.Zero(s.client.HLen(ctx, s.dlq.schedulePayloadKey()).Val())
+	s.Zero(s.client.XLen(ctx, "orders.created").Val())
+}
+
+func (s *ScheduleTestSuite) TestScheduledRequeues_Pages() {
+	ctx := context.Background()
+	now := time.Now()
+	var ids []string
+	for i := range 5 {
+		topic := "orders.created"
+		if i%2 == 1 {
+			topic = "payments.processed"
+		}
+		id := s.poison(topic)
+		ids = append(ids, id)
+		_, err := s.dlq.ScheduleRequeue(ctx, id, now.Add(time.Duration(i)*time.Minute), nil)
+		s.Require().NoError(err)
+	}
+
+	page, err := s.dlq.ScheduledRequeues(ctx, PaginationOpts{Limit: 2, Order: SortOrderAsc}, nil)
+	s.Require().NoError(err)
+	s.Equal(int64(5), page.TotalCount)
+	s.True(page.HasMore)
+	s.Equal([]string{ids[0], ids[1]}, []string{page.Messages[0].ID, page.Messages[1].ID})
+	s.Equal("payments.processed", page.Messages[1].Topic)
+
+	page, err = s.dlq.ScheduledRequeues(ctx, PaginationOpts{Cursor: page.NextCursor, Limit: 2, Order: SortOrderAsc}, nil)
+	s.Require().NoError(err)
+	s.Equal([]string{ids[2], ids[3]}, []string{page.Messages[0].ID, page.Messages[1].ID})
+
+	orders := func(topic string) bool { return topic == "orders.created" }
+	page, err = s.dlq.ScheduledRequeues(ctx, PaginationOpts{Cursor: "1", Limit: 1, Order: SortOrderAsc}, orders)
+	s.Require().NoError(err)
+	s.Equal(int64(3), page.TotalCount)
+	s.Require().Len(page.Messages, 1)
+	s.Equal(ids[2], page.Messages[0].ID)
+	s.Equal("2", page.NextCursor)
+}
+
+// crashHook fails every command, or pipeline holding a command, named in
+// crash without sending it, as if the process died before sending it.
+type crashHook struct {
+	crash map[string]bool
+}
+
+func (crashHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h crashHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if h.crash[cmd.Name()] {
+			return errors.New("crashed before " + cmd.Name())
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h crashHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			if h.crash[cmd.Name()] {
+				return errors.New("crashed before " + cmd.Name())
+			}
+		}
+		return next(ctx, cmds)
+	}
+}
+
+func (s *ScheduleTestSuite) TestRunScheduled_ResumesAfterCrash() {
+	// The run dies after adding the message to its topic, before removing
+	// it from the DLQ or before removing the schedule.
+	for _, crashBefore := range []string{"xdel", "zrem"} {
+		s.Run(crashBefore, func() {
+			s.SetupTest()
+			ctx := context.Background()
+			now := time.Now()
+
+			id := s.poison("orders.created")
+			_, err := s.dlq.ScheduleRequeue(ctx, id, now, nil)
+			s.Require().NoError(err)
+
+			client := redis.NewClient(&redis.Options{Addr: s.mr.Addr()})
+			defer client.Close()
+			client.AddHook(crashHook{crash: map[string]bool{crashBefore: true}})
+			crashing := NewDLQService(NewRedisStream(client), s.dlqName)
+
+			s.Require().Error(crashing.RunScheduled(ctx, now))
+			s.Equal(int64(1), s.client.XLen(ctx, "orders.created").Val())
+
+			s.Require().NoError(s.dlq.RunScheduled(ctx, now.Add(scheduleClaimTimeout)))
+			s.Equal(int64(1), s.client.XLen(ctx, "orders.created").Val())
+			s.Zero(s.client.XLen(ctx, s.dlqName).Val())
+			s.Empty(s.scheduled())
+			s.Zero(s.client.HLen(ctx, s.dlq.scheduleRequeuedKey()).Val())
+		})
+	}
+}
+
+func (s *ScheduleTestSuite) TestRunScheduled_RetriesWhenIDTaken() {
+	ctx := context.Background()
+	now := time.Now()
+
+	id := s.poison("orders.created")
+	_, err := s.dlq.ScheduleRequeue(ctx, id, now, nil)
+	s.Require().NoError(err)
+
+	// A recorded ID that another producer has since moved past.
+	addTestMessage(s.T(), s.client, "orders.created", map[string]any{"id": 1})
+	s.Require().NoError(s.client.HSet(ctx, s.dlq.scheduleRequeuedKey(), id, "1-0 test-uuid").Err())
+
+	s.Require().NoError(s.dlq.RunScheduled(ctx, now))
+	s.Equal(int64(2), s.client.XLen(ctx, "orders.created").Val())
+	s.Zero(s.client.XLen(ctx, s.dlqName).Val())
+}
+
+func TestScheduleTestSuite(t *testing.T) {
+	suite.Run(t, new(ScheduleTestSuite))
+}
@@ -0,0 +1,337 @@
+package monitor
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// slowLogScanned is how many of the latest SLOWLOG entries of each node
+// are searched for stream commands.
+const slowLogScanned = 128
+
+// RedisInfo describes the health of the Redis servers holding the streams,
+// one entry per node on a Redis Cluster or Ring.
+type RedisInfo struct {
+	Nodes []RedisNodeInfo `json:"nodes"`
+}
+
+// RedisNodeInfo is what INFO and SLOWLOG report about one Redis server.
+// Error or SlowLogError are set instead when a command was refused, e.g.
+// by an ACL.
+type RedisNodeInfo struct {
+	Addr          string `json:"addr,omitempty"`
+	Version       string `json:"version,omitempty"`
+	UptimeSeconds int64  `json:"uptime_seconds"`
+
+	UsedMemory         int64   `json:"used_memory"`
+	UsedMemoryPeak     int64   `json:"used_memory_peak"`
+	MaxMemory          int64   `json:"max_memory"`
+	MaxMemoryPolicy    string  `json:"max_memory_policy,omitempty"`
+	FragmentationRatio float64 `json:"fragmentation_ratio"`
+	EvictedKeys        int64   `json:"evicted_keys"`
+
+	ConnectedClients int64 `json:"connected_clients"`
+	BlockedClients   int64 `json:"blocked_clients"`
+	OpsPerSec        int64 `json:"ops_per_sec"`
+
+	Replication ReplicationInfo `json:"replication"`
+
+	// SlowLog lists the latest slow stream commands, newest first.
+	SlowLog      []SlowLogEntry `json:"slow_log"`
+	SlowLogError string         `json:"slow_log_error,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// ReplicationInfo describes the replication role of a node and how far
+// behind its replicas, or itself as a replica, are.
+type ReplicationInfo struct {
+	// Role is "master" or "replica".
+	Role string `json:"role"`
+
+	// Replicas lists the replicas of a master.
+	Replicas []ReplicaInfo `json:"replicas,omitempty"`
+
+	// MasterLinkStatus, MasterLastIOSeconds and LagBytes describe a
+	// replica's link to its master. A replica cannot tell how far behind
+	// it is, so LagBytes is computed against its master when that is among
+	// the nodes read, and left unknown otherwise.
+	MasterLinkStatus    string `json:"master_link_status,omitempty"`
+	MasterLastIOSeconds *int64 `json:"master_last_io_seconds,omitempty"`
+	LagBytes            *int64 `json:"lag_bytes,omitempty"`
+
+	// replID and offset are the replication ID and offset of the node,
+	// which a replica shares with its master whatever their addresses.
+	replID string
+	offset int64
+}
+
+// ReplicaInfo is a replica as seen by its master. LagBytes is how much of
+// the replication stream it has yet to acknowledge, LagSeconds how long ago
+// it last did.
+type ReplicaInfo struct {
+	Addr       string `json:"addr"`
+	State      string `json:"state"`
+	LagBytes   int64  `json:"lag_bytes"`
+	LagSeconds int64  `json:"lag_seconds"`
+}
+
+// SlowLogEntry is a stream command that exceeded the slowlog threshold.
+// Args stops at the first stream key, Key; the RedactedArgs arguments that
+// followed, which may hold message payloads, are left out.
+type SlowLogEntry struct {
+	ID           int64     `json:"id"`
+	Time         time.Time `json:"time"`
+	DurationUS   int64     `json:"duration_us"`
+	Command      string    `json:"command"`
+	Args         []string  `json:"args"`
+	Key          string    `json:"key,omitempty"`
+	RedactedArgs int       `json:"redacted_args,omitempty"`
+	ClientAddr   string    `json:"client_addr,omitempty"`
+	ClientName   string    `json:"client_name,omitempty"`
+}
+
+// ServerService reads the health of the Redis servers themselves.
+type ServerService struct {
+	client redis.UniversalClient
+}
+
+func NewServerService(client redis.UniversalClient) *ServerService {
+	return &ServerService{client: client}
+}
+
+// GetInfo reads INFO and the slow stream commands of every node: every
+// master and replica on a Redis Cluster, every shard on a Ring.
+func (s *ServerService) GetInfo(ctx context.Context) (*RedisInfo, error) {
+	var (
+		mu    sync.Mutex
+		nodes []RedisNodeInfo
+	)
+
+	collect := func(ctx context.Context, node *redis.Client) error {
+		info, err := nodeInfo(ctx, node)
+		if err != nil {
+			return err
+		}
+		info.Addr = node.Options().Addr
+
+		mu.Lock()
+		nodes = append(nodes, *info)
+		mu.Unlock()
+		return nil
+	}
+
+	var err error
+	switch c := s.client.(type) {
+	case *redis.ClusterClient:
+		err = c.ForEachShard(ctx, collect)
+	case *redis.Ring:
+		err = c.ForEachShard(ctx, collect)
+	default:
+		var info *RedisNodeInfo
+		if info, err = nodeInfo(ctx, s.client); err == nil {
+			nodes = append(nodes, *info)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Addr < nodes[j].Addr })
+	replicaLag(nodes)
+	return &RedisInfo{Nodes: nodes}, nil
+}
+
+// replicaLag sets the lag of each replica node to how far its replication
+// offset is behind the one of the master sharing its replication ID.
+// Masters list their replicas by IP while nodes are often addressed by
+// hostname, so the replication ID is matched rather than the address.
+func replicaLag(nodes []RedisNodeInfo) {
+	offsets := make(map[string]int64)
+	for _, node := range nodes {
+		if repl := node.Replication; repl.Role == "master" && repl.replID != "" {
+			offsets[repl.replID] = repl.offset
+		}
+	}
+
+	for i := range nodes {
+		repl := &nodes[i].Replication
+		if repl.Role != "replica" {
+			continue
+		}
+		if offset, ok := offsets[repl.replID]; ok {
+			lag := max(offset-repl.offset, 0)
+			repl.LagBytes = &lag
+		}
+	}
+}
+
+// nodeInfo reads INFO and SLOWLOG of one node. Commands Redis refuses are
+// reported in the result; other errors fail it.
+func nodeInfo(ctx context.Context, client redis.Cmdable) (*RedisNodeInfo, error) {
+	info := &RedisNodeInfo{SlowLog: []SlowLogEntry{}}
+
+	raw, err := client.Info(ctx).Result()
+	switch {
+	case err == nil:
+		parseNodeInfo(info, parseInfo(raw))
+	case unavailable(err):
+		info.Error = "INFO: " + err.Error()
+	default:
+		return nil, err
+	}
+
+	entries, err := client.SlowLogGet(ctx, slowLogScanned).Result()
+	switch {
+	case err == nil:
+		info.SlowLog = streamSlowLog(entries)
+	case unavailable(err):
+		info.SlowLogError = "SLOWLOG: " + err.Error()
+	default:
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// parseInfo splits the output of INFO into its fields.
+func parseInfo(raw string) map[string]string {
+	fields := make(map[string]string)
+	for line := range strings.Lines(raw) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, ":"); ok {
+			fields[key] = value
+		}
+	}
+	return fields
+}
+
+func parseNodeInfo(info *RedisNodeInfo, fields map[string]string) {
+	integer := func(key string) int64 {
+		n, _ := strconv.ParseInt(fields[key], 10, 64)
+		return n
+	}
+
+	info.Version = fields["redis_version"]
+	info.UptimeSeconds = integer("uptime_in_seconds")
+	info.UsedMemory = integer("used_memory")
+	info.UsedMemoryPeak = integer("used_memory_peak")
+	info.MaxMemory = integer("maxmemory")
+	info.MaxMemoryPolicy = fields["maxmemory_policy"]
+	info.FragmentationRatio, _ = strconv.ParseFloat(fields["mem_fragmentation_ratio"], 64)
+	info.EvictedKeys = integer("evicted_keys")
+	info.ConnectedClients = integer("connected_clients")
+	info.BlockedClients = integer("blocked_clients")
+	info.OpsPerSec = integer("instantaneous_ops_per_sec")
+
+	repl := &info.Replication
+	repl.Role = fields["role"]
+	if repl.Role == "slave" {
+		repl.Role = "replica"
+	}
+
+	repl.replID = fields["master_replid"]
+	if repl.Role == "replica" {
+		repl.offset = integer("slave_repl_offset")
+		repl.MasterLinkStatus = fields["master_link_status"]
+		if v, ok := fields["master_last_io_seconds_ago"]; ok {
+			seconds, _ := strconv.ParseInt(v, 10, 64)
+			repl.MasterLastIOSeconds = &seconds
+		}
+		return
+	}
+
+	masterOffset := integer("master_repl_offset")
+	repl.offset = masterOffset
+	for i := int64(0); i < integer("connected_slaves"); i++ {
+		replica, offset := parseReplica(fields["slave"+strconv.FormatInt(i, 10)])
+		replica.LagBytes = max(masterOffset-offset, 0)
+		repl.Replicas = append(repl.Replicas, replica)
+	}
+}
+
+// parseReplica parses a line such as
+// "ip=10.0.0.2,port=6379,state=online,offset=1024,lag=0" into the replica
+// and the replication offset it acknowledged.
+func parseReplica(line string) (ReplicaInfo, int64) {
+	fields := make(map[string]string)
+	for _, pair := range strings.Split(line, ",") {
+		if key, value, ok := strings.Cut(pair, "="); ok {
+			fields[key] = value
+		}
+	}
+
+	replica := ReplicaInfo{
+		Addr:  fields["ip"] + ":" + fields["port"],
+		State: fields["state"],
+	}
+	replica.LagSeconds, _ = strconv.ParseInt(fields["lag"], 10, 64)
+	offset, _ := strconv.ParseInt(fields["offset"], 10, 64)
+	return replica, offset
+}
+
+// streamSlowLog keeps the entries of stream commands, whose names all
+// start with X, with their arguments redacted after the first key.
+func streamSlowLog(entries []redis.SlowLog) []SlowLogEntry {
+	result := []SlowLogEntry{}
+	for _, e := range entries {
+		if len(e.Args) == 0 || !strings.HasPrefix(strings.ToUpper(e.Args[0]), "X") {
+			continue
+		}
+
+		command := strings.ToUpper(e.Args[0])
+		args := e.Args[1:]
+		entry := SlowLogEntry{
+			ID:         e.ID,
+			Time:       e.Time.UTC(),
+			DurationUS: e.Duration.Microseconds(),
+			Command:    command,
+			Args:       []string{},
+			ClientAddr: e.ClientAddr,
+			ClientName: e.ClientName,
+		}
+
+		if i := slowLogKeyIndex(command, args); i >= 0 {
+			entry.Key = args[i]
+			entry.Args = args[:i+1]
+			entry.RedactedArgs = len(args) - i - 1
+		} else {
+			entry.RedactedArgs = len(args)
+		}
+
+		result = append(result, entry)
+	}
+	return result
+}
+
+// slowLogKeyIndex returns the index in args of the first stream key of
+// command, or -1 when there is none. SLOWLOG truncates long argument
+// lists, so the key may be missing.
+func slowLogKeyIndex(command string, args []string) int {
+	i := 0
+	switch command {
+	case "XGROUP", "XINFO":
+		// The subcommand comes first.
+		i = 1
+	case "XREAD", "XREADGROUP":
+		i = slices.IndexFunc(args, func(arg string) bool { return strings.EqualFold(arg, "STREAMS") }) + 1
+		if i == 0 {
+			return -1
+		}
+	}
+
+	if i >= len(args) {
+		return -1
+	}
+	return i
+}
@@ -0,0 +1,180 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// serverHook answers INFO and SLOWLOG, which miniredis barely implements,
+// with canned replies.
+type serverHook struct {
+	info    string
+	slowLog []redis.SlowLog
+}
+
+func (serverHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h serverHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		switch cmd := cmd.(type) {
+		case *redis.StringCmd:
+			if cmd.Name() == "info" {
+				cmd.SetVal(h.info)
+				return nil
+			}
+		case *redis.SlowLogCmd:
+			cmd.SetVal(h.slowLog)
+			return nil
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (serverHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+type ServerTestSuite struct {
+	suite.Suite
+	mr     *miniredis.Miniredis
+	client *redis.Client
+}
+
+func (s *ServerTestSuite) SetupTest() {
+	s.mr = miniredis.RunT(s.T())
+	s.client = redis.NewClient(&redis.Options{Addr: s.mr.Addr()})
+}
+
+func (s *ServerTestSuite) TearDownTest() {
+	s.client.Close()
+	s.mr.Close()
+}
+
+func (s *ServerTestSuite) TestGetInfo_Master() {
+	now := time.Now().UTC().Truncate(time.Second)
+	s.client.AddHook(serverHook{
+		info: "# Server\r\nredis_version:7.2.4\r\nuptime_in_seconds:3600\r\n" +
+			"# Clients\r\nconnected_clients:12\r\nblocked_clients:3\r\n" +
+			"# Memory\r\nused_memory:1048576\r\nused_memory_peak:2097152\r\nmaxmemory:4194304\r\n" +
+			"maxmemory_policy:noeviction\r\nmem_fragmentation_ratio:1.42\r\n" +
+			"# Stats\r\ninstantaneous_ops_per_sec:250\r\nevicted_keys:7\r\n" +
+			"# Replication\r\nrole:master\r\nconnected_slaves:1\r\n" +
+			"slave0:ip=10.0.0.2,port=6379,state=online,offset=900,lag=1\r\nmaster_repl_offset:1000\r\n",
+		slowLog: []redis.SlowLog{
+			{ID: 4, Time: now, Duration: 12 * time.Millisecond, Args: []string{"XADD", "payments", "*", "payload", `{"card":"4242"}`}},
+			{ID: 3, Time: now, Duration: 11 * time.Millisecond, Args: []string{"XREADGROUP", "GROUP", "billing", "worker-1", "STREAMS", "orders", ">"}},
+			{ID: 2, Time: now, Duration: 15 * time.Millisecond, Args: []string{"xrange", "orders", "-", "+"}, ClientAddr: "10.0.0.5:5000"},
+			{ID: 1, Time: now, Duration: 20 * time.Millisecond, Args: []string{"KEYS", "*"}},
+		},
+	})
+
+	info, err := NewServerService(s.client).GetInfo(context.Background())
+	s.Require().NoError(err)
+	s.Require().Len(info.Nodes, 1)
+
+	node := info.Nodes[0]
+	s.Empty(node.Error)
+	s.Equal("7.2.4", node.Version)
+	s.Equal(int64(3600), node.UptimeSeconds)
+	s.Equal(int64(12), node.ConnectedClients)
+	s.Equal(int64(3), node.BlockedClients)
+	s.Equal(int64(1048576), node.UsedMemory)
+	s.Equal(int64(4194304), node.MaxMemory)
+	s.Equal("noeviction", node.MaxMemoryPolicy)
+	s.InDelta(1.42, node.FragmentationRatio, 0.001)
+	s.Equal(int64(250), node.OpsPerSec)
+	s.Equal(int64(7), node.EvictedKeys)
+
+	s.Equal("master", node.Replication.Role)
+	s.Equal([]ReplicaInfo{{Addr: "10.0.0.2:6379", State: "online", LagBytes: 100, LagSeconds: 1}}, node.Replication.Replicas)
+
+	// Arguments after the key, such as payloads, are redacted.
+	s.Equal([]SlowLogEntry{{
+		ID:           4,
+		Time:         now,
+		DurationUS:   12000,
+		Command:      "XADD",
+		Args:         []string{"payments"},
+		Key:          "payments",
+		RedactedArgs: 3,
+	}, {
+		ID:           3,
+		Time:         now,
+		DurationUS:   11000,
+		Command:      "XREADGROUP",
+		Args:         []string{"GROUP", "billing", "worker-1", "STREAMS", "orders"},
+		Key:          "orders",
+		RedactedArgs: 1,
+	}, {
+		ID:           2,
+		Time:         now,
+		DurationUS:   15000,
+		Command:      "XRANGE",
+		Args:         []string{"orders"},
+		Key:          "orders",
+		RedactedArgs: 2,
+		ClientAddr:   "10.0.0.5:5000",
+	}}, node.SlowLog)
+}
+
+func (s *ServerTestSuite) TestGetInfo_Replica() {
+	s.client.AddHook(serverHook{
+		info: "# Replication\r\nrole:slave\r\nmaster_link_status:up\r\nmaster_last_io_seconds_ago:2\r\n" +
+			"slave_repl_offset:950\r\nmaster_repl_offset:1000\r\n",
+	})
+
+	info, err := NewServerService(s.client).GetInfo(context.Background())
+	s.Require().NoError(err)
+
+	// Alone, a replica cannot tell how far behind its master it is.
+	repl := info.Nodes[0].Replication
+	s.Equal("replica", repl.Role)
+	s.Equal("up", repl.MasterLinkStatus)
+	s.Equal(int64(2), *repl.MasterLastIOSeconds)
+	s.Nil(repl.LagBytes)
+}
+
+func TestReplicaLag(t *testing.T) {
+	node := func(addr, info string) RedisNodeInfo {
+		n := RedisNodeInfo{Addr: addr}
+		parseNodeInfo(&n, parseInfo(info))
+		return n
+	}
+
+	// Nodes are addressed by hostname while the master lists its replica
+	// by IP, so only the replication ID ties them together.
+	nodes := []RedisNodeInfo{
+		node("redis-0.redis:6379", "role:master\r\nmaster_replid:aaa\r\nmaster_repl_offset:1000\r\n"+
+			"connected_slaves:1\r\nslave0:ip=10.0.0.2,port=6379,state=online,offset=900,lag=0\r\n"),
+		node("redis-1.redis:6379", "role:slave\r\nmaster_replid:aaa\r\nslave_repl_offset:900\r\nmaster_repl_offset:900\r\n"),
+		node("redis-2.redis:6379", "role:master\r\nmaster_replid:bbb\r\nmaster_repl_offset:50\r\n"),
+		node("redis-3.redis:6379", "role:slave\r\nmaster_replid:ccc\r\nslave_repl_offset:10\r\n"),
+	}
+
+	replicaLag(nodes)
+	require.Equal(t, int64(100), *nodes[1].Replication.LagBytes)
+	require.Nil(t, nodes[0].Replication.LagBytes)
+	require.Nil(t, nodes[2].Replication.LagBytes)
+	// The master of this replica was not read.
+	require.Nil(t, nodes[3].Replication.LagBytes)
+}
+
+func (s *ServerTestSuite) TestGetInfo_Denied() {
+	s.client.AddHook(denyHook{deny: map[string]bool{"info": true, "slowlog": true}})
+
+	info, err := NewServerService(s.client).GetInfo(context.Background())
+	s.Require().NoError(err)
+	s.Contains(info.Nodes[0].Error, "INFO: NOPERM")
+	s.Contains(info.Nodes[0].SlowLogError, "SLOWLOG: NOPERM")
+	s.Empty(info.Nodes[0].SlowLog)
+}
+
+func TestServerTestSuite(t *testing.T) {
+	suite.Run(t, new(ServerTestSuite))
+}
@@ -2,87 +2,55 @@ package monitor
 
 import (
 	"context"
-	"time"
+	"fmt"
 
-	"golang.org/x/sync/errgroup"
+	"github.com/redis/go-redis/v9"
 )
 
 type StreamService struct {
 	monitor *RedisStream
 	dlqName string
+	catalog *streamCatalog
 }
 
 func NewStreamService(monitor *RedisStream, dlqName string) *StreamService {
+	return NewStreamServiceWithCatalog(monitor, dlqName, CatalogOpts{})
+}
+
+func NewStreamServiceWithCatalog(monitor *RedisStream, dlqName string, opts CatalogOpts) *StreamService {
 	return &StreamService{
 		monitor: monitor,
 		dlqName: dlqName,
+		catalog: newStreamCatalog(monitor, dlqName, opts),
 	}
 }
 
+// GetStreams returns every listed stream from the catalog, refreshing it
+// first when it is stale.
 func (s *StreamService) GetStreams(ctx context.Context) ([]StreamInfo, error) {
-	streams, err := s.monitor.ScanStreams(ctx)
+	streams, _, err := s.catalog.get(ctx)
+	return streams, err
+}
+
+// ListStreams returns a page of the stream catalog.
+func (s *StreamService) ListStreams(ctx context.Context, opts StreamListOpts) (*StreamList, error) {
+	streams, refreshedAt, err := s.catalog.get(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	streamsInfo := make([]StreamInfo, len(streams))
-	errG, grpCtx := errgroup.WithContext(ctx)
-	errG.SetLimit(10)
-
-	for i, name := range streams {
-		if name == s.dlqName {
-			continue
-		}
-
-		errG.Go(func() error {
-			meta, err := s.monitor.GetStreamInfo(grpCtx, name)
-			if err != nil {
-				return nil
-			}
-
-			memory, err := s.monitor.GetMemoryUsage(grpCtx, name)
-			if err != nil {
-				return nil
-			}
-
-			var (
-				lastEntryID  *string
-				lastActivity *time.Time
-			)
-
-			if meta.LastEntry.ID != "" {
-				id := meta.LastEntry.ID
-				lastEntryID = &id
-
-				if ts, err := ParseStreamTimestamp(*lastEntryID); err == nil {
-					lastActivity = ts
-				}
-			}
-
-			streamsInfo[i] = StreamInfo{
-				Name:         name,
-				Length:       meta.Length,
-				MemoryBytes:  memory,
-				LastEntryID:  lastEntryID,
-				LastActivity: lastActivity,
-			}
-
-			return nil
-		})
-	}
-
-	if err := errG.Wait(); err != nil {
+	list, err := listStreams(streams, opts)
+	if err != nil {
 		return nil, err
 	}
+	list.RefreshedAt = refreshedAt
 
-	var result []StreamInfo
-	for _, info := range streamsInfo {
-		if info.Name != "" {
-			result = append(result, info)
-		}
-	}
+	return list, nil
+}
 
-	return result, nil
+// RefreshCatalog rescans the streams and replaces the cached catalog.
+func (s *StreamService) RefreshCatalog(ctx context.Context) error {
+	return s.catalog.refresh(ctx)
 }
 
 func (s *StreamService) GetStreamDetail(ctx context.Context, stream string) (*StreamDetail, error) {
@@ -91,29 +59,18 @@ func (s *StreamService) GetStreamDetail(ctx context.Context, stream string) (*St
 		return nil, err
 	}
 
-	memory, err := s.monitor.GetMemoryUsage(ctx, stream)
-	if err != nil {
-		return nil, err
-	}
-
-	var (
-		firstEntryID *string
-		lastEntryID  *string
-		lastActivity *time.Time
-	)
+	memory, memoryErr := s.monitor.memoryUsage(ctx, stream)
+	lastEntryID, lastActivity := lastEntry(meta)
 
+	var firstEntryID *string
 	if meta.FirstEntry.ID != "" {
 		id := meta.FirstEntry.ID
 		firstEntryID = &id
 	}
 
-	if meta.LastEntry.ID != "" {
-		id := meta.LastEntry.ID
-		lastEntryID = &id
-
-		if ts, err := ParseStreamTimestamp(*lastEntryID); err == nil {
-			lastActivity = ts
-		}
+	location, err := s.monitor.LocateStream(ctx, stream)
+	if err != nil {
+		return nil, err
 	}
 
 	return &StreamDetail{
@@ -123,11 +80,137 @@ func (s *StreamService) GetStreamDetail(ctx context.Context, stream string) (*St
 			MemoryBytes:  memory,
 			LastEntryID:  lastEntryID,
 			LastActivity: lastActivity,
+			Node:         location.Node,
+			Slot:         location.Slot,
+			Error:        memoryErr,
 		},
 		FirstEntryID: firstEntryID,
 	}, nil
 }
 
+// GetStreamDetailFull returns the detail of stream along with what XINFO
+// STREAM FULL reports: entries added since creation, tombstones, the radix
+// tree and every group with its consumers and pending entries, at most
+// count of them each. When XINFO is denied, Full is left nil and Error
+// says why.
+func (s *StreamService) GetStreamDetailFull(ctx context.Context, stream string, count int) (*StreamDetail, error) {
+	detail, err := s.GetStreamDetail(ctx, stream)
+	if err != nil {
+		return nil, err
+	}
+
+	if reason, denied := s.monitor.Capabilities().Unavailable["XINFO"]; denied {
+		detail.Error = joinErrors(detail.Error, "XINFO STREAM FULL: "+reason)
+		return detail, nil
+	}
+
+	full, err := s.monitor.GetStreamInfoFull(ctx, stream, count)
+	switch {
+	case err == nil:
+		detail.Full = streamFullInfo(full)
+	case unavailable(err) && !isNoSuchKey(err):
+		detail.Error = joinErrors(detail.Error, "XINFO STREAM FULL: "+err.Error())
+	default:
+		return nil, err
+	}
+
+	return detail, nil
+}
+
+// streamFullInfo converts the reply of XINFO STREAM FULL. Only Redis 7 and
+// later record the first entry ID, and with it the entries added, so its
+// absence marks those counters as unknown.
+func streamFullInfo(full *redis.XInfoStreamFull) *StreamFullInfo {
+	info := &StreamFullInfo{
+		LastGeneratedID:      full.LastGeneratedID,
+		RecordedFirstEntryID: full.RecordedFirstEntryID,
+		RadixTreeKeys:        full.RadixTreeKeys,
+		RadixTreeNodes:       full.RadixTreeNodes,
+		Groups:               make([]StreamGroupDetail, 0, len(full.Groups)),
+	}
+
+	redis7 := full.RecordedFirstEntryID != ""
+	if redis7 {
+		added := full.EntriesAdded
+		deleted := max(full.EntriesAdded-full.Length, 0)
+		info.EntriesAdded = &added
+		info.DeletedEntries = &deleted
+		if full.MaxDeletedEntryID != "0-0" {
+			info.MaxDeletedEntryID = full.MaxDeletedEntryID
+		}
+	}
+
+	for _, g := range full.Groups {
+		group := StreamGroupDetail{
+			Name:            g.Name,
+			LastDeliveredID: g.LastDeliveredID,
+			PendingCount:    g.PelCount,
+			Pending:         make([]PendingEntry, len(g.Pending)),
+			Consumers:       make([]StreamConsumerDetail, len(g.Consumers)),
+		}
+		if redis7 {
+			group.EntriesRead, group.Lag = groupProgress(g.EntriesRead, g.Lag, g.LastDeliveredID, full.LastGeneratedID)
+		}
+
+		for i, p := range g.Pending {
+			group.Pending[i] = PendingEntry{
+				ID:            p.ID,
+				Consumer:      p.Consumer,
+				DeliveredAt:   p.DeliveryTime.UTC(),
+				DeliveryCount: p.DeliveryCount,
+			}
+		}
+
+		for i, c := range g.Consumers {
+			consumer := StreamConsumerDetail{
+				Name:         c.Name,
+				SeenAt:       c.SeenTime.UTC(),
+				PendingCount: c.PelCount,
+				Pending:      make([]PendingEntry, len(c.Pending)),
+			}
+			if !c.ActiveTime.IsZero() {
+				active := c.ActiveTime.UTC()
+				consumer.ActiveAt = &active
+			}
+			for j, p := range c.Pending {
+				consumer.Pending[j] = PendingEntry{
+					ID:            p.ID,
+					DeliveredAt:   p.DeliveryTime.UTC(),
+					DeliveryCount: p.DeliveryCount,
+				}
+			}
+			group.Consumers[i] = consumer
+		}
+
+		info.Groups = append(info.Groups, group)
+	}
+
+	return info
+}
+
+// groupProgress returns the entries-read and lag Redis 7 reports for a
+// group, or nil for those it could not determine. go-redis reads a null
+// reply as zero, so a zero is only trusted when it cannot be a null: a
+// group that read nothing yet, or one caught up with the stream.
+func groupProgress(entriesRead, lag int64, lastDeliveredID, lastGeneratedID string) (*int64, *int64) {
+	var read, behind *int64
+	if entriesRead > 0 || lastDeliveredID == "0-0" {
+		read = &entriesRead
+	}
+	if lag > 0 || lastDeliveredID == lastGeneratedID {
+		behind = &lag
+	}
+	return read, behind
+}
+
+// joinErrors appends err to the errors already reported in errs.
+func joinErrors(errs, err string) string {
+	if errs == "" {
+		return err
+	}
+	return errs + "; " + err
+}
+
 func (s *StreamService) GetStreamMessages(ctx context.Context, stream string, opts PaginationOpts) (*MessageList[Message], error) {
 	messages, err := s.monitor.ReadMessages(ctx, stream, opts)
 	if err != nil {
@@ -153,7 +236,9 @@ func (s *StreamService) GetStreamMessages(ctx context.Context, stream string, op
 
 		result = append(result, Message{
 			ID:        msg.ID,
+			UUID:      wmMsg.UUID,
 			Payload:   wmMsg.Payload,
+			Metadata:  wmMsg.Metadata,
 			Timestamp: *ts,
 		})
 	}
@@ -194,7 +279,44 @@ func (s *StreamService) GetMessage(ctx context.Context, stream, id string) (*Mes
 
 	return &Message{
 		ID:        msg.ID,
+		UUID:      wmMsg.UUID,
 		Payload:   wmMsg.Payload,
+		Metadata:  wmMsg.Metadata,
+		Timestamp: *ts,
+	}, nil
+}
+
+// Publish adds a new Watermill message to stream, encoded the same way as
+// a requeued DLQ message, and returns it as stored.
+func (s *StreamService) Publish(ctx context.Context, stream string, payload map[string]any, metadata map[string]string) (*Message, error) {
+	if payload == nil {
+		payload = map[string]any{}
+	}
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+
+	uuid := generateUUID()
+	fields, err := watermillFields(uuid, payload, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := s.monitor.AddMessage(ctx, stream, fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish to %s: %w", stream, err)
+	}
+
+	ts, err := ParseStreamTimestamp(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		ID:        id,
+		UUID:      uuid,
+		Payload:   payload,
+		Metadata:  metadata,
 		Timestamp: *ts,
 	}, nil
 }
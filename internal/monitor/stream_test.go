@@ -3,6 +3,7 @@ package monitor
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/redis/go-redis/v9"
@@ -73,6 +74,125 @@ func (s *StreamTestSuite) TestGetStreamDetail() {
 	s.Equal(lastID, *detail.LastEntryID)
 }
 
+// streamFullHook answers XINFO STREAM FULL, which miniredis does not
+// implement, with full and records the arguments it was sent.
+type streamFullHook struct {
+	full *redis.XInfoStreamFull
+	args *[]any
+}
+
+func (streamFullHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h streamFullHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if cmd, ok := cmd.(*redis.XInfoStreamFullCmd); ok {
+			*h.args = cmd.Args()
+			cmd.SetVal(h.full)
+			return nil
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (streamFullHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func (s *StreamTestSuite) TestGetStreamDetailFull() {
+	ctx := context.Background()
+	addTestMessage(s.T(), s.client, "orders.created", map[string]any{"id": 1})
+
+	delivered := time.UnixMilli(1704067200000)
+	var args []any
+	s.client.AddHook(streamFullHook{args: &args, full: &redis.XInfoStreamFull{
+		Length:               1,
+		RadixTreeKeys:        1,
+		RadixTreeNodes:       2,
+		LastGeneratedID:      "5-0",
+		MaxDeletedEntryID:    "4-0",
+		EntriesAdded:         5,
+		RecordedFirstEntryID: "5-0",
+		Groups: []redis.XInfoStreamGroup{
+			{
+				Name:            "billing",
+				LastDeliveredID: "5-0",
+				EntriesRead:     5,
+				PelCount:        1,
+				Pending:         []redis.XInfoStreamGroupPending{{ID: "5-0", Consumer: "worker-1", DeliveryTime: delivered, DeliveryCount: 2}},
+				Consumers: []redis.XInfoStreamConsumer{{
+					Name:     "worker-1",
+					SeenTime: delivered,
+					PelCount: 1,
+					Pending:  []redis.XInfoStreamConsumerPending{{ID: "5-0", DeliveryTime: delivered, DeliveryCount: 2}},
+				}},
+			},
+			// A null lag, read by go-redis as zero, is reported as unknown.
+			{Name: "audit", LastDeliveredID: "3-0", EntriesRead: 3},
+		},
+	}})
+
+	detail, err := s.service.GetStreamDetailFull(ctx, "orders.created", 25)
+	s.Require().NoError(err)
+	s.Equal([]any{"xinfo", "stream", "orders.created", "full", "count", 25}, args)
+
+	full := detail.Full
+	s.Require().NotNil(full)
+	s.Equal(int64(5), *full.EntriesAdded)
+	s.Equal(int64(4), *full.DeletedEntries)
+	s.Equal("4-0", full.MaxDeletedEntryID)
+	s.Equal(int64(2), full.RadixTreeNodes)
+	s.Require().Len(full.Groups, 2)
+
+	billing := full.Groups[0]
+	s.Equal(int64(5), *billing.EntriesRead)
+	s.Equal(int64(0), *billing.Lag)
+	s.Equal(int64(1), billing.PendingCount)
+	s.Equal("worker-1", billing.Pending[0].Consumer)
+	s.Equal(delivered.UTC(), billing.Pending[0].DeliveredAt)
+	s.Require().Len(billing.Consumers, 1)
+	s.Nil(billing.Consumers[0].ActiveAt)
+	s.Equal(int64(2), billing.Consumers[0].Pending[0].DeliveryCount)
+
+	audit := full.Groups[1]
+	s.Equal(int64(3), *audit.EntriesRead)
+	s.Nil(audit.Lag)
+}
+
+func (s *StreamTestSuite) TestGetStreamDetailFull_Redis6() {
+	ctx := context.Background()
+	addTestMessage(s.T(), s.client, "orders.created", map[string]any{"id": 1})
+
+	var args []any
+	s.client.AddHook(streamFullHook{args: &args, full: &redis.XInfoStreamFull{
+		Length:          1,
+		LastGeneratedID: "1-0",
+		Groups:          []redis.XInfoStreamGroup{{Name: "billing", LastDeliveredID: "1-0"}},
+	}})
+
+	detail, err := s.service.GetStreamDetailFull(ctx, "orders.created", 10)
+	s.Require().NoError(err)
+	s.Require().NotNil(detail.Full)
+	s.Nil(detail.Full.EntriesAdded)
+	s.Nil(detail.Full.DeletedEntries)
+	s.Nil(detail.Full.Groups[0].Lag)
+	s.Nil(detail.Full.Groups[0].EntriesRead)
+}
+
+func (s *StreamTestSuite) TestGetStreamDetailFull_Denied() {
+	ctx := context.Background()
+	addTestMessage(s.T(), s.client, "orders.created", map[string]any{"id": 1})
+
+	s.client.AddHook(denyHook{deny: map[string]bool{"xinfo": true}})
+	_, err := s.service.monitor.ProbeCapabilities(ctx)
+	s.Require().NoError(err)
+
+	detail, err := s.service.GetStreamDetailFull(ctx, "orders.created", 10)
+	s.Require().NoError(err)
+	s.Equal(int64(1), detail.Length)
+	s.Nil(detail.Full)
+	s.Contains(detail.Error, "XINFO STREAM FULL: NOPERM")
+}
+
 func (s *StreamTestSuite) TestGetStreamMessages() {
 	ctx := context.Background()
 
@@ -136,6 +256,26 @@ func (s *StreamTestSuite) TestDeleteMessage() {
 	s.Nil(msg)
 }
 
+func (s *StreamTestSuite) TestPublish() {
+	ctx := context.Background()
+
+	published, err := s.service.Publish(ctx, "orders.created", map[string]any{"id": 1}, map[string]string{"trace_id": "abc"})
+	s.Require().NoError(err)
+	s.NotEmpty(published.UUID)
+
+	msg, err := s.service.GetMessage(ctx, "orders.created", published.ID)
+	s.Require().NoError(err)
+	s.Require().NotNil(msg)
+	s.Equal(published.UUID, msg.UUID)
+	s.Equal(float64(1), msg.Payload["id"])
+	s.Equal("abc", msg.Metadata["trace_id"])
+
+	clone, err := s.service.Publish(ctx, "orders.created", msg.Payload, msg.Metadata)
+	s.Require().NoError(err)
+	s.NotEqual(msg.UUID, clone.UUID)
+	s.NotEqual(msg.ID, clone.ID)
+}
+
 func TestStreamSuite(t *testing.T) {
 	suite.Run(t, new(StreamTestSuite))
 }
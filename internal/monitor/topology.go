@@ -0,0 +1,492 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// topologyDLQScanned is how many of the newest DLQ messages are read to
+// find the handlers that poisoned them.
+const topologyDLQScanned = 10000
+
+var errTopologyScanned = errors.New("topology scan limit reached")
+
+// Kinds of topology nodes.
+const (
+	TopologyTopic    = "topic"
+	TopologyGroup    = "group"
+	TopologyConsumer = "consumer"
+	TopologyHandler  = "handler"
+)
+
+// Kinds of topology edges. A topic is consumed by its groups, a group by
+// its consumers and a consumer, or a group without consumers, by the
+// handlers running it. A handler consumes a topic directly when its group
+// is unknown, and publishes to the topics its messages are sent to.
+const (
+	TopologyConsumes  = "consumes"
+	TopologyPublishes = "publishes"
+)
+
+// HandlerRegistration describes a Watermill handler reported by the
+// application. ConsumerGroup is the consumer group its Redis Streams
+// subscriber reads with, empty when unknown.
+type HandlerRegistration struct {
+	Name           string `json:"name"`
+	SubscribeTopic string `json:"subscribe_topic,omitempty"`
+	PublishTopic   string `json:"publish_topic,omitempty"`
+	ConsumerGroup  string `json:"consumer_group,omitempty"`
+}
+
+// Topology is a graph of the topics, consumer groups, consumers and
+// handlers of the listed streams. Errors lists what could not be read.
+type Topology struct {
+	Nodes  []TopologyNode `json:"nodes"`
+	Edges  []TopologyEdge `json:"edges"`
+	Errors []string       `json:"errors,omitempty"`
+}
+
+// TopologyNode is a topic, group, consumer or handler. Stream and Group
+// locate groups and consumers. Handlers are Registered when the
+// application reported them, and count the DLQ messages they Poisoned
+// among the newest ones.
+type TopologyNode struct {
+	ID         string `json:"id"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Stream     string `json:"stream,omitempty"`
+	Group      string `json:"group,omitempty"`
+	Pending    int64  `json:"pending,omitempty"`
+	Registered bool   `json:"registered,omitempty"`
+	Poisoned   int64  `json:"poisoned,omitempty"`
+}
+
+// TopologyEdge links two nodes by ID. Inferred edges link a handler to a
+// group by name rather than by what the application registered.
+type TopologyEdge struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Kind     string `json:"kind"`
+	Inferred bool   `json:"inferred,omitempty"`
+}
+
+// TopologyOpts configures GetTopology. Allow, when set, keeps only the
+// topics it accepts.
+type TopologyOpts struct {
+	Allow func(stream string) bool
+}
+
+// TopologyService reconstructs which handlers consume which topics, from
+// consumer groups, the poison metadata of DLQ messages and the handlers
+// the application registers.
+type TopologyService struct {
+	monitor *RedisStream
+	streams *StreamService
+	dlq     *DLQService
+
+	mu       sync.RWMutex
+	handlers map[string]HandlerRegistration
+}
+
+func NewTopologyService(monitor *RedisStream, streams *StreamService, dlq *DLQService) *TopologyService {
+	return &TopologyService{
+		monitor:  monitor,
+		streams:  streams,
+		dlq:      dlq,
+		handlers: make(map[string]HandlerRegistration),
+	}
+}
+
+// RegisterHandler records a handler of the application. Registering the
+// same name again fills in the fields that were empty.
+func (t *TopologyService) RegisterHandler(h HandlerRegistration) {
+	t.mu.RLock()
+	known, ok := t.handlers[h.Name]
+	t.mu.RUnlock()
+	if ok && merge(known, h) == known {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.handlers[h.Name] = merge(t.handlers[h.Name], h)
+}
+
+// merge returns known with its empty fields taken from h.
+func merge(known, h HandlerRegistration) HandlerRegistration {
+	known.Name = h.Name
+	if known.SubscribeTopic == "" {
+		known.SubscribeTopic = h.SubscribeTopic
+	}
+	if known.PublishTopic == "" {
+		known.PublishTopic = h.PublishTopic
+	}
+	if known.ConsumerGroup == "" {
+		known.ConsumerGroup = h.ConsumerGroup
+	}
+	return known
+}
+
+// Handlers returns the registered handlers sorted by name.
+func (t *TopologyService) Handlers() []HandlerRegistration {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	handlers := make([]HandlerRegistration, 0, len(t.handlers))
+	for _, h := range t.handlers {
+		handlers = append(handlers, h)
+	}
+	sort.Slice(handlers, func(i, j int) bool { return handlers[i].Name < handlers[j].Name })
+	return handlers
+}
+
+// topologyGroup is a consumer group and the names of its consumers.
+type topologyGroup struct {
+	name      string
+	pending   int64
+	consumers []TopologyNode
+}
+
+// GetTopology builds the graph of the listed streams. A handler found in
+// the DLQ or registered without a consumer group is linked to the group of
+// its topic named after it, or to the only group of that topic; failing
+// both, it consumes the topic directly.
+func (t *TopologyService) GetTopology(ctx context.Context, opts TopologyOpts) (*Topology, error) {
+	allow := func(stream string) bool { return opts.Allow == nil || opts.Allow(stream) }
+	g := newTopologyGraph()
+
+	streams, err := t.streams.GetStreams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, stream := range streams {
+		if allow(stream.Name) {
+			names = append(names, stream.Name)
+			g.topic(stream.Name)
+		}
+	}
+
+	groups, failed, err := t.readGroups(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, stream := range names {
+		if err, ok := failed[stream]; ok {
+			g.errors = append(g.errors, stream+": "+err.Error())
+			continue
+		}
+
+		for _, group := range groups[stream] {
+			groupID := g.add(TopologyNode{
+				ID:      "group:" + stream + "/" + group.name,
+				Kind:    TopologyGroup,
+				Name:    group.name,
+				Stream:  stream,
+				Pending: group.pending,
+			})
+			g.edge(TopologyEdge{From: "topic:" + stream, To: groupID, Kind: TopologyConsumes})
+
+			for _, consumer := range group.consumers {
+				g.edge(TopologyEdge{From: groupID, To: g.add(consumer), Kind: TopologyConsumes})
+			}
+		}
+	}
+
+	handlers := make(map[string]*HandlerRegistration)
+	for _, h := range t.Handlers() {
+		handlers[h.Name] = &h
+		g.add(TopologyNode{ID: "handler:" + h.Name, Kind: TopologyHandler, Name: h.Name, Registered: true})
+	}
+
+	poisoned, err := t.poisonedHandlers(ctx)
+	if err != nil {
+		if !unavailable(err) {
+			return nil, err
+		}
+		g.errors = append(g.errors, t.dlq.Name()+": "+err.Error())
+	}
+
+	type subscription struct{ handler, topic, group string }
+	var subscriptions []subscription
+	for _, h := range handlers {
+		if h.SubscribeTopic != "" {
+			subscriptions = append(subscriptions, subscription{h.Name, h.SubscribeTopic, h.ConsumerGroup})
+		}
+		if h.PublishTopic != "" && allow(h.PublishTopic) {
+			g.topic(h.PublishTopic)
+			g.edge(TopologyEdge{From: "handler:" + h.Name, To: "topic:" + h.PublishTopic, Kind: TopologyPublishes})
+		}
+	}
+	for key, count := range poisoned {
+		if !allow(key.topic) {
+			continue
+		}
+		node := g.add(TopologyNode{ID: "handler:" + key.handler, Kind: TopologyHandler, Name: key.handler})
+		g.nodes[node].Poisoned += count
+
+		if h, ok := handlers[key.handler]; !ok || h.SubscribeTopic != key.topic {
+			subscriptions = append(subscriptions, subscription{key.handler, key.topic, ""})
+		}
+	}
+
+	for _, s := range subscriptions {
+		if !allow(s.topic) {
+			continue
+		}
+		topicID := g.topic(s.topic)
+		handlerID := "handler:" + s.handler
+
+		group, inferred := linkGroup(groups[s.topic], s.handler, s.group)
+		if group == nil {
+			g.edge(TopologyEdge{From: topicID, To: handlerID, Kind: TopologyConsumes})
+			continue
+		}
+
+		if len(group.consumers) == 0 {
+			g.edge(TopologyEdge{From: "group:" + s.topic + "/" + group.name, To: handlerID, Kind: TopologyConsumes, Inferred: inferred})
+		}
+		for _, consumer := range group.consumers {
+			g.edge(TopologyEdge{From: consumer.ID, To: handlerID, Kind: TopologyConsumes, Inferred: inferred})
+		}
+	}
+
+	if opts.Allow != nil {
+		// Handlers of topics the caller cannot see are left out.
+		g.pruneHandlers()
+	}
+	return g.topology(), nil
+}
+
+// linkGroup returns the group of a topic a handler reads with: the one it
+// registered, else the one named after it, else the only one. Inferred is
+// set unless the group was registered.
+func linkGroup(groups []topologyGroup, handler, registered string) (group *topologyGroup, inferred bool) {
+	if registered != "" {
+		for i := range groups {
+			if groups[i].name == registered {
+				return &groups[i], false
+			}
+		}
+		return nil, false
+	}
+
+	for i := range groups {
+		if groups[i].name == handler {
+			return &groups[i], true
+		}
+	}
+	if len(groups) == 1 {
+		return &groups[0], true
+	}
+	return nil, false
+}
+
+// readGroups reads the consumer groups of streams and their consumers,
+// pipelined in batches. Streams whose groups or consumers Redis refused to
+// list are returned in failed instead.
+func (t *TopologyService) readGroups(ctx context.Context, streams []string) (groups map[string][]topologyGroup, failed map[string]error, err error) {
+	groups = make(map[string][]topologyGroup)
+	failed = make(map[string]error)
+	for batch := range slices.Chunk(streams, catalogBatchSize) {
+		if err := t.readGroupBatch(ctx, batch, groups, failed); err != nil {
+			return nil, nil, err
+		}
+	}
+	return groups, failed, nil
+}
+
+// readGroupBatch lists the groups of streams in one pipeline, then their
+// consumers in another.
+func (t *TopologyService) readGroupBatch(ctx context.Context, streams []string, groups map[string][]topologyGroup, failed map[string]error) error {
+	groupCmds := make([]*redis.XInfoGroupsCmd, len(streams))
+	_, err := t.monitor.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, stream := range streams {
+			groupCmds[i] = pipe.XInfoGroups(ctx, stream)
+		}
+		return nil
+	})
+	// Commands failing on their own are checked one by one below.
+	var redisErr redis.Error
+	if err != nil && !errors.As(err, &redisErr) {
+		return err
+	}
+
+	type groupRef struct {
+		stream string
+		index  int
+	}
+	var refs []groupRef
+	for i, stream := range streams {
+		infos, err := groupCmds[i].Result()
+		switch {
+		case isNoSuchKey(err):
+			continue
+		case unavailable(err):
+			failed[stream] = err
+			continue
+		case err != nil:
+			return err
+		}
+
+		for _, info := range infos {
+			refs = append(refs, groupRef{stream, len(groups[stream])})
+			groups[stream] = append(groups[stream], topologyGroup{name: info.Name, pending: info.Pending})
+		}
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+
+	consumerCmds := make([]*redis.XInfoConsumersCmd, len(refs))
+	_, err = t.monitor.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, ref := range refs {
+			consumerCmds[i] = pipe.XInfoConsumers(ctx, ref.stream, groups[ref.stream][ref.index].name)
+		}
+		return nil
+	})
+	if err != nil && !errors.As(err, &redisErr) {
+		return err
+	}
+
+	for i, ref := range refs {
+		consumers, err := consumerCmds[i].Result()
+		switch {
+		case unavailable(err):
+			failed[ref.stream] = err
+			continue
+		case err != nil:
+			return err
+		}
+
+		group := &groups[ref.stream][ref.index]
+		for _, c := range consumers {
+			group.consumers = append(group.consumers, TopologyNode{
+				ID:      "consumer:" + ref.stream + "/" + group.name + "/" + c.Name,
+				Kind:    TopologyConsumer,
+				Name:    c.Name,
+				Stream:  ref.stream,
+				Group:   group.name,
+				Pending: c.Pending,
+			})
+		}
+	}
+
+	for stream := range failed {
+		delete(groups, stream)
+	}
+	return nil
+}
+
+type poisonedKey struct{ handler, topic string }
+
+// poisonedHandlers counts the newest DLQ messages by the handler that
+// poisoned them and the topic they came from.
+func (t *TopologyService) poisonedHandlers(ctx context.Context) (map[poisonedKey]int64, error) {
+	counts := make(map[poisonedKey]int64)
+	scanned := 0
+
+	err := t.monitor.ScanMessages(ctx, t.dlq.Name(), PaginationOpts{Limit: 500, Order: SortOrderDesc}, func(msg redis.XMessage) error {
+		if scanned++; scanned > topologyDLQScanned {
+			return errTopologyScanned
+		}
+
+		wmMsg, err := ParseWatermillMessage(msg.Values)
+		if err != nil {
+			return nil
+		}
+		handler, topic := wmMsg.Metadata[HandlerPoisonedKey], wmMsg.Metadata[TopicPoisonedKey]
+		if handler != "" && topic != "" {
+			counts[poisonedKey{handler, topic}]++
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errTopologyScanned) {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// topologyGraph collects nodes and edges without duplicates.
+type topologyGraph struct {
+	nodes  map[string]*TopologyNode
+	edges  map[TopologyEdge]bool
+	errors []string
+}
+
+func newTopologyGraph() *topologyGraph {
+	return &topologyGraph{nodes: make(map[string]*TopologyNode), edges: make(map[TopologyEdge]bool)}
+}
+
+// add adds node unless one with its ID exists, and returns the ID.
+func (g *topologyGraph) add(node TopologyNode) string {
+	if _, ok := g.nodes[node.ID]; !ok {
+		g.nodes[node.ID] = &node
+	}
+	return node.ID
+}
+
+func (g *topologyGraph) topic(name string) string {
+	return g.add(TopologyNode{ID: "topic:" + name, Kind: TopologyTopic, Name: name})
+}
+
+// edge adds e, keeping a known link over an inferred one.
+func (g *topologyGraph) edge(e TopologyEdge) {
+	inferred := e
+	inferred.Inferred = true
+	known := e
+	known.Inferred = false
+
+	if g.edges[known] {
+		return
+	}
+	if !e.Inferred {
+		delete(g.edges, inferred)
+	}
+	g.edges[e] = true
+}
+
+// pruneHandlers removes the handlers linked to no topic.
+func (g *topologyGraph) pruneHandlers() {
+	linked := make(map[string]bool)
+	for e := range g.edges {
+		linked[e.From], linked[e.To] = true, true
+	}
+	for id, node := range g.nodes {
+		if node.Kind == TopologyHandler && !linked[id] {
+			delete(g.nodes, id)
+		}
+	}
+}
+
+func (g *topologyGraph) topology() *Topology {
+	topology := &Topology{
+		Nodes:  make([]TopologyNode, 0, len(g.nodes)),
+		Edges:  make([]TopologyEdge, 0, len(g.edges)),
+		Errors: g.errors,
+	}
+	for _, node := range g.nodes {
+		topology.Nodes = append(topology.Nodes, *node)
+	}
+	for edge := range g.edges {
+		topology.Edges = append(topology.Edges, edge)
+	}
+
+	sort.Slice(topology.Nodes, func(i, j int) bool { return topology.Nodes[i].ID < topology.Nodes[j].ID })
+	sort.Slice(topology.Edges, func(i, j int) bool {
+		a, b := topology.Edges[i], topology.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+	return topology
+}
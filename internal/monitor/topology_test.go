@@ -0,0 +1,226 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/suite"
+	"github.com/vmihailenco/msgpack"
+)
+
+type TopologyTestSuite struct {
+	suite.Suite
+	mr      *miniredis.Miniredis
+	client  redis.UniversalClient
+	monitor *Monitor
+	dlqName string
+}
+
+func (s *TopologyTestSuite) SetupTest() {
+	s.mr = miniredis.RunT(s.T())
+	s.client = redis.NewClient(&redis.Options{Addr: s.mr.Addr()})
+	s.dlqName = "test_dlq"
+	s.monitor = New(s.client, s.dlqName)
+
+	ctx := context.Background()
+	addTestMessage(s.T(), s.client, "orders.created", map[string]any{"id": 1})
+	addTestMessage(s.T(), s.client, "payments.processed", map[string]any{"id": 2})
+
+	s.Require().NoError(s.client.XGroupCreate(ctx, "orders.created", "billing", "0").Err())
+	s.Require().NoError(s.client.XGroupCreate(ctx, "orders.created", "audit", "0").Err())
+	s.Require().NoError(s.client.XGroupCreate(ctx, "payments.processed", "ledger", "0").Err())
+	s.Require().NoError(s.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    "billing",
+		Consumer: "worker-1",
+		Streams:  []string{"orders.created", ">"},
+	}).Err())
+
+	s.addPoisoned("billing", "orders.created")
+	s.addPoisoned("billing", "orders.created")
+	s.addPoisoned("ledger-handler", "payments.processed")
+}
+
+func (s *TopologyTestSuite) TearDownTest() {
+	s.client.Close()
+	s.mr.Close()
+}
+
+// addPoisoned adds a DLQ message as the Watermill poison queue does when
+// handler fails a message of topic.
+func (s *TopologyTestSuite) addPoisoned(handler, topic string) {
+	metadata, err := msgpack.Marshal(map[string]string{
+		HandlerPoisonedKey: handler,
+		TopicPoisonedKey:   topic,
+		ReasonPoisonedKey:  "test error",
+	})
+	s.Require().NoError(err)
+
+	s.Require().NoError(s.client.XAdd(context.Background(), &redis.XAddArgs{
+		Stream: s.dlqName,
+		Values: map[string]any{
+			WatermillUUIDKey:     "test-uuid",
+			WatermillPayloadKey:  "{}",
+			WatermillMetadataKey: string(metadata),
+		},
+	}).Err())
+}
+
+func (s *TopologyTestSuite) node(topology *Topology, id string) *TopologyNode {
+	for i := range topology.Nodes {
+		if topology.Nodes[i].ID == id {
+			return &topology.Nodes[i]
+		}
+	}
+	return nil
+}
+
+func (s *TopologyTestSuite) TestGetTopology() {
+	ctx := context.Background()
+	s.monitor.Topology().RegisterHandler(HandlerRegistration{Name: "notifier", ConsumerGroup: "audit"})
+	s.monitor.Topology().RegisterHandler(HandlerRegistration{
+		Name:           "notifier",
+		SubscribeTopic: "orders.created",
+		PublishTopic:   "notifications.sent",
+	})
+
+	topology, err := s.monitor.Topology().GetTopology(ctx, TopologyOpts{})
+	s.Require().NoError(err)
+	s.Empty(topology.Errors)
+
+	billing := s.node(topology, "handler:billing")
+	s.Require().NotNil(billing)
+	s.Equal(int64(2), billing.Poisoned)
+	s.False(billing.Registered)
+
+	notifier := s.node(topology, "handler:notifier")
+	s.Require().NotNil(notifier)
+	s.True(notifier.Registered)
+	s.NotNil(s.node(topology, "topic:notifications.sent"))
+
+	consumer := s.node(topology, "consumer:orders.created/billing/worker-1")
+	s.Require().NotNil(consumer)
+	s.Equal(int64(1), consumer.Pending)
+
+	s.ElementsMatch([]TopologyEdge{
+		{From: "topic:orders.created", To: "group:orders.created/audit", Kind: TopologyConsumes},
+		{From: "topic:orders.created", To: "group:orders.created/billing", Kind: TopologyConsumes},
+		{From: "group:orders.created/billing", To: "consumer:orders.created/billing/worker-1", Kind: TopologyConsumes},
+		// Linked by name.
+		{From: "consumer:orders.created/billing/worker-1", To: "handler:billing", Kind: TopologyConsumes, Inferred: true},
+		// Linked by the registered group, which has no consumers.
+		{From: "group:orders.created/audit", To: "handler:notifier", Kind: TopologyConsumes},
+		{From: "handler:notifier", To: "topic:notifications.sent", Kind: TopologyPublishes},
+		{From: "topic:payments.processed", To: "group:payments.processed/ledger", Kind: TopologyConsumes},
+		// Linked to the only group of its topic.
+		{From: "group:payments.processed/ledger", To: "handler:ledger-handler", Kind: TopologyConsumes, Inferred: true},
+	}, topology.Edges)
+}
+
+func (s *TopologyTestSuite) TestGetTopology_Unlinked() {
+	ctx := context.Background()
+	s.Require().NoError(s.client.XGroupCreate(ctx, "payments.processed", "reports", "0").Err())
+	s.monitor.Topology().RegisterHandler(HandlerRegistration{Name: "idle"})
+
+	topology, err := s.monitor.Topology().GetTopology(ctx, TopologyOpts{})
+	s.Require().NoError(err)
+
+	// With two groups and neither named after it, the handler consumes
+	// the topic directly.
+	s.Contains(topology.Edges, TopologyEdge{From: "topic:payments.processed", To: "handler:ledger-handler", Kind: TopologyConsumes})
+
+	// A handler registered before handling any message has no topic yet.
+	s.NotNil(s.node(topology, "handler:idle"))
+}
+
+func (s *TopologyTestSuite) TestGetTopology_Filtered() {
+	ctx := context.Background()
+	s.monitor.Topology().RegisterHandler(HandlerRegistration{Name: "notifier", SubscribeTopic: "orders.created"})
+
+	topology, err := s.monitor.Topology().GetTopology(ctx, TopologyOpts{
+		Allow: func(stream string) bool { return stream == "payments.processed" },
+	})
+	s.Require().NoError(err)
+
+	ids := make([]string, len(topology.Nodes))
+	for i, node := range topology.Nodes {
+		ids[i] = node.ID
+	}
+	s.Equal([]string{
+		"group:payments.processed/ledger",
+		"handler:ledger-handler",
+		"topic:payments.processed",
+	}, ids)
+}
+
+func (s *TopologyTestSuite) TestGetTopology_Denied() {
+	ctx := context.Background()
+	s.client.AddHook(denyHook{deny: map[string]bool{"xinfo": true}})
+
+	topology, err := s.monitor.Topology().GetTopology(ctx, TopologyOpts{})
+	s.Require().NoError(err)
+	s.Len(topology.Errors, 2)
+	s.Contains(topology.Errors[0], "NOPERM")
+
+	// Handlers from the DLQ still consume their topics.
+	s.Contains(topology.Edges, TopologyEdge{From: "topic:orders.created", To: "handler:billing", Kind: TopologyConsumes})
+}
+
+// roundTripHook counts the round trips sending XINFO commands.
+type roundTripHook struct {
+	trips *int
+}
+
+func (roundTripHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h roundTripHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if cmd.Name() == "xinfo" {
+			*h.trips++
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h roundTripHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			if cmd.Name() == "xinfo" {
+				*h.trips++
+				break
+			}
+		}
+		return next(ctx, cmds)
+	}
+}
+
+func (s *TopologyTestSuite) TestGetTopology_Pipelined() {
+	ctx := context.Background()
+	for i := range 20 {
+		stream := fmt.Sprintf("stream-%02d", i)
+		addTestMessage(s.T(), s.client, stream, map[string]any{"id": i})
+		s.Require().NoError(s.client.XGroupCreate(ctx, stream, "group-a", "0").Err())
+		s.Require().NoError(s.client.XGroupCreate(ctx, stream, "group-b", "0").Err())
+	}
+	// Warm the catalog, whose refresh also pipelines XINFO.
+	_, err := s.monitor.Streams().GetStreams(ctx)
+	s.Require().NoError(err)
+
+	var trips int
+	s.client.AddHook(roundTripHook{trips: &trips})
+
+	topology, err := s.monitor.Topology().GetTopology(ctx, TopologyOpts{})
+	s.Require().NoError(err)
+	s.Empty(topology.Errors)
+
+	// One pipeline lists the groups of every stream, one their consumers.
+	s.Equal(2, trips)
+	s.NotNil(s.node(topology, "group:stream-19/group-b"))
+	s.NotNil(s.node(topology, "consumer:orders.created/billing/worker-1"))
+}
+
+func TestTopologyTestSuite(t *testing.T) {
+	suite.Run(t, new(TopologyTestSuite))
+}
@@ -0,0 +1,263 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+const trimPageSize = 1000
+
+// ErrInvalidTrimOpts is returned for trim options that select no valid boundary.
+var ErrInvalidTrimOpts = errors.New("invalid trim options")
+
+var errStopScan = errors.New("stop scan")
+
+// PendingEntriesError refuses a trim that would remove entries a group has not processed.
+type PendingEntriesError struct {
+	Stream string
+	Group  string
+	ID     string
+}
+
+func (e *PendingEntriesError) Error() string {
+	return fmt.Sprintf("trimming %s would remove entry %s, which consumer group %s has not processed", e.Stream, e.ID, e.Group)
+}
+
+// Trim removes the oldest entries of stream selected by opts, refusing to remove unprocessed ones.
+func (s *StreamService) Trim(ctx context.Context, stream string, opts TrimOpts) (*TrimResult, error) {
+	result := &TrimResult{
+		Stream:      stream,
+		Strategy:    opts.Strategy,
+		Approximate: opts.Approximate,
+		DryRun:      opts.DryRun,
+	}
+
+	switch opts.Strategy {
+	case TrimStrategyMaxlen:
+		if opts.MaxLen < 0 {
+			return nil, fmt.Errorf("%w: max length must not be negative", ErrInvalidTrimOpts)
+		}
+		return s.trimMaxLen(ctx, stream, opts, result)
+
+	case TrimStrategyMinid:
+		ms, seq, err := parseStreamID(opts.MinID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidTrimOpts, err)
+		}
+		// Redis reads a bound without a sequence as the largest one.
+		opts.MinID = fmt.Sprintf("%d-%d", ms, seq)
+		return s.trimMinID(ctx, stream, opts, result)
+
+	default:
+		return nil, fmt.Errorf("%w: unknown strategy %q", ErrInvalidTrimOpts, opts.Strategy)
+	}
+}
+
+func (s *StreamService) trimMaxLen(ctx context.Context, stream string, opts TrimOpts, result *TrimResult) (*TrimResult, error) {
+	length, err := s.monitor.GetStreamLength(ctx, stream)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := length - opts.MaxLen
+	if removed <= 0 {
+		return result, nil
+	}
+
+	unprocessed, err := s.firstUnprocessed(ctx, stream)
+	if err != nil {
+		return nil, err
+	}
+
+	// With every entry processed, Redis trims by length itself.
+	if unprocessed == nil {
+		if opts.DryRun {
+			result.Removed = removed
+			return result, nil
+		}
+
+		result.Removed, err = s.monitor.TrimMaxLen(ctx, stream, opts.MaxLen, opts.Approximate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to trim %s: %w", stream, err)
+		}
+		return result, nil
+	}
+
+	// Walk from whichever end of the stream is closer to the boundary.
+	var lastRemoved string
+	if removed <= opts.MaxLen+1 {
+		lastRemoved, err = s.nthEntry(ctx, stream, SortOrderAsc, removed)
+	} else {
+		lastRemoved, err = s.nthEntry(ctx, stream, SortOrderDesc, opts.MaxLen+1)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if lastRemoved == "" {
+		return result, nil
+	}
+
+	if compareStreamIDs(unprocessed.ID, lastRemoved) <= 0 {
+		return nil, unprocessed
+	}
+
+	if opts.DryRun {
+		result.Removed = removed
+		return result, nil
+	}
+
+	return s.trimThrough(ctx, stream, lastRemoved, opts, result)
+}
+
+func (s *StreamService) trimMinID(ctx context.Context, stream string, opts TrimOpts, result *TrimResult) (*TrimResult, error) {
+	// The newest entry older than MinID is the last one removed.
+	last, err := s.monitor.ReadMessages(ctx, stream, PaginationOpts{
+		Cursor: opts.MinID,
+		Limit:  1,
+		Order:  SortOrderDesc,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(last) == 0 {
+		return result, nil
+	}
+	lastRemoved := last[0].ID
+
+	unprocessed, err := s.firstUnprocessed(ctx, stream)
+	if err != nil {
+		return nil, err
+	}
+	if unprocessed != nil && compareStreamIDs(unprocessed.ID, lastRemoved) <= 0 {
+		return nil, unprocessed
+	}
+
+	if opts.DryRun {
+		return s.estimateRemoved(ctx, stream, lastRemoved, result)
+	}
+
+	return s.trimThrough(ctx, stream, lastRemoved, opts, result)
+}
+
+// estimateRemoved interpolates how many entries lie up to lastRemoved from the first and last IDs.
+func (s *StreamService) estimateRemoved(ctx context.Context, stream, lastRemoved string, result *TrimResult) (*TrimResult, error) {
+	length, err := s.monitor.GetStreamLength(ctx, stream)
+	if err != nil {
+		return nil, err
+	}
+
+	first, err := s.monitor.ReadMessages(ctx, stream, PaginationOpts{Limit: 1, Order: SortOrderAsc})
+	if err != nil {
+		return nil, err
+	}
+	last, err := s.monitor.ReadMessages(ctx, stream, PaginationOpts{Limit: 1, Order: SortOrderDesc})
+	if err != nil {
+		return nil, err
+	}
+	if len(first) == 0 || len(last) == 0 {
+		return result, nil
+	}
+
+	switch {
+	case compareStreamIDs(lastRemoved, last[0].ID) >= 0:
+		result.Removed = length
+	case compareStreamIDs(lastRemoved, first[0].ID) <= 0:
+		result.Removed = 1
+	default:
+		firstMs, firstSeq, _ := parseStreamID(first[0].ID)
+		lastMs, lastSeq, _ := parseStreamID(last[0].ID)
+		boundMs, boundSeq, _ := parseStreamID(lastRemoved)
+
+		// Entries sharing a millisecond are spread by sequence instead.
+		from, to, at := float64(firstMs), float64(lastMs), float64(boundMs)
+		if firstMs == lastMs {
+			from, to, at = float64(firstSeq), float64(lastSeq), float64(boundSeq)
+		}
+
+		estimate := 1 + int64(math.Round(float64(length-1)*(at-from)/(to-from)))
+		result.Removed = min(max(estimate, 1), length-1)
+		result.Estimated = true
+	}
+
+	return result, nil
+}
+
+// trimThrough removes the entries of stream up to and including lastRemoved.
+func (s *StreamService) trimThrough(ctx context.Context, stream, lastRemoved string, opts TrimOpts, result *TrimResult) (*TrimResult, error) {
+	removed, err := s.monitor.TrimMinID(ctx, stream, nextStreamID(lastRemoved), opts.Approximate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to trim %s: %w", stream, err)
+	}
+	result.Removed = removed
+
+	return result, nil
+}
+
+// nthEntry returns the ID of the n-th entry of stream in order, counting from 1.
+func (s *StreamService) nthEntry(ctx context.Context, stream string, order SortOrder, n int64) (string, error) {
+	opts := PaginationOpts{Limit: min(n, trimPageSize), Order: order}
+	for {
+		messages, err := s.monitor.ReadMessages(ctx, stream, opts)
+		if err != nil {
+			return "", err
+		}
+
+		if n <= int64(len(messages)) {
+			return messages[n-1].ID, nil
+		}
+		if len(messages) < int(opts.Limit) {
+			return "", nil
+		}
+
+		n -= int64(len(messages))
+		opts.Cursor = messages[len(messages)-1].ID
+		opts.Limit = min(n, trimPageSize)
+	}
+}
+
+// firstUnprocessed returns the oldest entry a group has not processed, as a trim error, or nil.
+func (s *StreamService) firstUnprocessed(ctx context.Context, stream string) (*PendingEntriesError, error) {
+	groups, err := s.monitor.GetGroups(ctx, stream)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read consumer groups: %w", err)
+	}
+
+	var first *PendingEntriesError
+	found := func(group, id string) {
+		if first == nil || compareStreamIDs(id, first.ID) < 0 {
+			first = &PendingEntriesError{Stream: stream, Group: group, ID: id}
+		}
+	}
+
+	for _, group := range groups {
+		next, err := s.monitor.ReadMessages(ctx, stream, PaginationOpts{
+			Cursor: group.LastDeliveredID,
+			Limit:  1,
+			Order:  SortOrderAsc,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(next) > 0 {
+			found(group.Name, next[0].ID)
+		}
+
+		if group.Pending == 0 {
+			continue
+		}
+
+		pending, err := s.monitor.GetPendingSummary(ctx, stream, group.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pending entries of %s: %w", group.Name, err)
+		}
+		if pending.Count > 0 {
+			found(group.Name, pending.Lower)
+		}
+	}
+
+	return first, nil
+}
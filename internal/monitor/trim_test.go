@@ -0,0 +1,282 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/suite"
+)
+
+type TrimTestSuite struct {
+	suite.Suite
+	mr      *miniredis.Miniredis
+	client  redis.UniversalClient
+	service *StreamService
+}
+
+func (s *TrimTestSuite) SetupTest() {
+	s.mr = miniredis.RunT(s.T())
+	s.client = redis.NewClient(&redis.Options{Addr: s.mr.Addr()})
+	s.service = NewStreamService(NewRedisStream(s.client), "test_dlq")
+}
+
+func (s *TrimTestSuite) TearDownTest() {
+	s.client.Close()
+	s.mr.Close()
+}
+
+func (s *TrimTestSuite) addMessages(stream string, n int) []string {
+	ids := make([]string, n)
+	for i := range n {
+		ids[i] = addTestMessage(s.T(), s.client, stream, map[string]any{"id": i})
+	}
+	return ids
+}
+
+// addSpacedMessages adds n messages one second apart, so that MinID
+// previews, estimated from the IDs, are exact.
+func (s *TrimTestSuite) addSpacedMessages(stream string, n int) []string {
+	ids := make([]string, n)
+	for i := range n {
+		ids[i] = fmt.Sprintf("%d-0", (i+1)*1000)
+		s.Require().NoError(s.client.XAdd(context.Background(), &redis.XAddArgs{Stream: stream, ID: ids[i], Values: map[string]any{"id": i}}).Err())
+	}
+	return ids
+}
+
+func (s *TrimTestSuite) length(stream string) int64 {
+	length, err := s.client.XLen(context.Background(), stream).Result()
+	s.Require().NoError(err)
+	return length
+}
+
+func (s *TrimTestSuite) TestTrimMaxLen() {
+	ctx := context.Background()
+	s.addMessages("orders.created", 10)
+
+	preview, err := s.service.Trim(ctx, "orders.created", TrimOpts{Strategy: TrimStrategyMaxlen, MaxLen: 3, DryRun: true})
+	s.Require().NoError(err)
+	s.True(preview.DryRun)
+	s.Equal(int64(7), preview.Removed)
+	s.Equal(int64(10), s.length("orders.created"))
+
+	result, err := s.service.Trim(ctx, "orders.created", TrimOpts{Strategy: TrimStrategyMaxlen, MaxLen: 3})
+	s.Require().NoError(err)
+	s.Equal(int64(7), result.Removed)
+	s.Equal(int64(3), s.length("orders.created"))
+
+	result, err = s.service.Trim(ctx, "orders.created", TrimOpts{Strategy: TrimStrategyMaxlen, MaxLen: 5})
+	s.Require().NoError(err)
+	s.Zero(result.Removed)
+}
+
+func (s *TrimTestSuite) TestTrimMinID() {
+	ctx := context.Background()
+	ids := s.addSpacedMessages("orders.created", 5)
+
+	preview, err := s.service.Trim(ctx, "orders.created", TrimOpts{Strategy: TrimStrategyMinid, MinID: ids[2], DryRun: true})
+	s.Require().NoError(err)
+	s.Equal(int64(2), preview.Removed)
+
+	result, err := s.service.Trim(ctx, "orders.created", TrimOpts{Strategy: TrimStrategyMinid, MinID: ids[2]})
+	s.Require().NoError(err)
+	s.Equal(int64(2), result.Removed)
+
+	msgs, err := s.client.XRange(ctx, "orders.created", "-", "+").Result()
+	s.Require().NoError(err)
+	s.Equal(ids[2], msgs[0].ID)
+}
+
+func (s *TrimTestSuite) TestTrimMinIDWithoutSequence() {
+	ctx := context.Background()
+	for _, id := range []string{"1000-0", "1000-1", "2000-0", "2000-1", "3000-0"} {
+		s.Require().NoError(s.client.XAdd(ctx, &redis.XAddArgs{Stream: "orders.created", ID: id, Values: map[string]any{"n": id}}).Err())
+	}
+
+	result, err := s.service.Trim(ctx, "orders.created", TrimOpts{Strategy: TrimStrategyMinid, MinID: "2000"})
+	s.Require().NoError(err)
+	s.Equal(int64(2), result.Removed)
+
+	msgs, err := s.client.XRange(ctx, "orders.created", "-", "+").Result()
+	s.Require().NoError(err)
+	s.Equal("2000-0", msgs[0].ID)
+	s.Len(msgs, 3)
+}
+
+func (s *TrimTestSuite) TestTrimMinIDPreviewEstimate() {
+	ctx := context.Background()
+	for ms := 1; ms <= 10; ms++ {
+		s.Require().NoError(s.client.XAdd(ctx, &redis.XAddArgs{Stream: "orders.created", ID: fmt.Sprintf("%d-0", ms*1000), Values: map[string]any{"n": ms}}).Err())
+	}
+
+	preview, err := s.service.Trim(ctx, "orders.created", TrimOpts{Strategy: TrimStrategyMinid, MinID: "11000-0", DryRun: true})
+	s.Require().NoError(err)
+	s.Equal(int64(10), preview.Removed)
+	s.False(preview.Estimated)
+
+	preview, err = s.service.Trim(ctx, "orders.created", TrimOpts{Strategy: TrimStrategyMinid, MinID: "6000-0", DryRun: true})
+	s.Require().NoError(err)
+	s.InDelta(5, preview.Removed, 1)
+	s.True(preview.Estimated)
+}
+
+func (s *TrimTestSuite) TestTrimInvalidOpts() {
+	ctx := context.Background()
+
+	_, err := s.service.Trim(ctx, "orders.created", TrimOpts{Strategy: TrimStrategyMinid, MinID: "yesterday"})
+	s.ErrorIs(err, ErrInvalidTrimOpts)
+
+	_, err = s.service.Trim(ctx, "orders.created", TrimOpts{Strategy: TrimStrategyMaxlen, MaxLen: -1})
+	s.ErrorIs(err, ErrInvalidTrimOpts)
+
+	_, err = s.service.Trim(ctx, "orders.created", TrimOpts{})
+	s.ErrorIs(err, ErrInvalidTrimOpts)
+}
+
+func (s *TrimTestSuite) TestTrimRefusesPendingEntries() {
+	ctx := context.Background()
+	ids := s.addSpacedMessages("orders.created", 6)
+
+	s.Require().NoError(s.client.XGroupCreate(ctx, "orders.created", "billing", "0").Err())
+	_, err := s.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    "billing",
+		Consumer: "worker-1",
+		Streams:  []string{"orders.created", ">"},
+		Count:    4,
+	}).Result()
+	s.Require().NoError(err)
+	s.Require().NoError(s.client.XAck(ctx, "orders.created", "billing", ids[0], ids[1]).Err())
+
+	// ids[2] and ids[3] are pending, ids[4] and ids[5] undelivered.
+	_, err = s.service.Trim(ctx, "orders.created", TrimOpts{Strategy: TrimStrategyMaxlen, MaxLen: 3})
+	var pending *PendingEntriesError
+	s.Require().ErrorAs(err, &pending)
+	s.Equal("billing", pending.Group)
+	s.Equal(ids[2], pending.ID)
+	s.Equal(int64(6), s.length("orders.created"))
+
+	result, err := s.service.Trim(ctx, "orders.created", TrimOpts{Strategy: TrimStrategyMaxlen, MaxLen: 4})
+	s.Require().NoError(err)
+	s.Equal(int64(2), result.Removed)
+
+	s.Require().NoError(s.client.XAck(ctx, "orders.created", "billing", ids[2], ids[3]).Err())
+	_, err = s.service.Trim(ctx, "orders.created", TrimOpts{Strategy: TrimStrategyMaxlen, MaxLen: 1})
+	s.Require().ErrorAs(err, &pending)
+	s.Equal(ids[4], pending.ID)
+
+	_, err = s.service.Trim(ctx, "orders.created", TrimOpts{Strategy: TrimStrategyMinid, MinID: ids[5], DryRun: true})
+	s.Require().ErrorAs(err, &pending)
+	s.Equal(ids[4], pending.ID)
+
+	preview, err := s.service.Trim(ctx, "orders.created", TrimOpts{Strategy: TrimStrategyMinid, MinID: ids[4], DryRun: true})
+	s.Require().NoError(err)
+	s.Equal(int64(2), preview.Removed)
+}
+
+func (s *TrimTestSuite) TestRetentionWorker() {
+	ctx := context.Background()
+	s.addMessages("orders.created", 5)
+	s.addMessages("payments.processed", 5)
+
+	worker := NewRetentionWorker(s.service, []RetentionPolicy{
+		{Stream: "orders.*", MaxLen: 2},
+		{Stream: "payments.*", MaxAge: time.Hour},
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	s.Require().NoError(worker.Validate())
+
+	s.Require().NoError(worker.Run(ctx))
+	s.Equal(int64(2), s.length("orders.created"))
+	s.Equal(int64(5), s.length("payments.processed"))
+
+	worker.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	s.Require().NoError(worker.Run(ctx))
+	s.Zero(s.length("payments.processed"))
+}
+
+func (s *TrimTestSuite) TestRetentionWorkerTrimsCatalogStreams() {
+	ctx := context.Background()
+	s.addMessages("orders.created", 5)
+	s.addMessages("orders.audit", 5)
+	s.addMessages("test_dlq", 5)
+
+	service := NewStreamServiceWithCatalog(NewRedisStream(s.client), "test_dlq", CatalogOpts{Exclude: []string{"*.audit"}})
+	worker := NewRetentionWorker(service, []RetentionPolicy{{Stream: "*", MaxLen: 2}}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	s.Require().NoError(worker.Run(ctx))
+	s.Equal(int64(2), s.length("orders.created"))
+	s.Equal(int64(5), s.length("orders.audit"))
+	s.Equal(int64(5), s.length("test_dlq"))
+}
+
+func (s *TrimTestSuite) TestRetentionWorker_LeadershipChanges() {
+	ctx := context.Background()
+	s.addMessages("orders.created", 5)
+	s.addMessages("orders.updated", 5)
+
+	election := func(id string) *LeaderElection {
+		return NewLeaderElection(s.client, "test_dlq", LeaderOpts{ID: id, Lease: time.Minute})
+	}
+	a, b := election("a"), election("b")
+
+	token, err := a.acquire(ctx)
+	s.Require().NoError(err)
+
+	// The lease moves to b as soon as a has trimmed its first stream.
+	s.client.AddHook(afterHook{name: "xtrim", fn: func() {
+		s.Require().NoError(a.release(ctx, token))
+		_, err := b.acquire(ctx)
+		s.Require().NoError(err)
+	}})
+
+	retention := NewRetentionWorker(s.service, []RetentionPolicy{{Stream: "orders.*", MaxLen: 2}}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	worker := a.Fence(Worker{Name: "retention", Run: retention.Run})
+
+	s.ErrorIs(worker.Run(withLeaderToken(ctx, token)), ErrNotLeader)
+	s.ElementsMatch([]int64{2, 5}, []int64{s.length("orders.created"), s.length("orders.updated")})
+
+	// The old term never runs again.
+	s.ErrorIs(worker.Run(withLeaderToken(ctx, token)), ErrNotLeader)
+	s.ElementsMatch([]int64{2, 5}, []int64{s.length("orders.created"), s.length("orders.updated")})
+}
+
+// afterHook calls fn once, after the first command named name.
+type afterHook struct {
+	name string
+	fn   func()
+}
+
+func (afterHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h afterHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	done := false
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		err := next(ctx, cmd)
+		if cmd.Name() == h.name && !done {
+			done = true
+			h.fn()
+		}
+		return err
+	}
+}
+
+func (h afterHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func (s *TrimTestSuite) TestRetentionWorkerValidate() {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	s.Error(NewRetentionWorker(s.service, []RetentionPolicy{{Stream: "orders.*"}}, logger).Validate())
+	s.Error(NewRetentionWorker(s.service, []RetentionPolicy{{Stream: "[", MaxLen: 1}}, logger).Validate())
+	s.Error(NewRetentionWorker(s.service, []RetentionPolicy{{Stream: "orders.*", MaxLen: -1}}, logger).Validate())
+}
+
+func TestTrimTestSuite(t *testing.T) {
+	suite.Run(t, new(TrimTestSuite))
+}
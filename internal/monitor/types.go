@@ -4,6 +4,8 @@ package monitor
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"math"
 	"strconv"
 	"strings"
 	"time"
@@ -26,38 +28,147 @@ const (
 	SubscriberPoisonedKey = "subscriber_poisoned"
 )
 
+// Windmill metadata keys
+const (
+	// RetryAttemptKey counts the automatic retries of a message. Watermill
+	// keeps metadata when poisoning a message again, so it survives the
+	// round trip through the DLQ.
+	RetryAttemptKey = "windmill_retry_attempt"
+)
+
 // ENUM(asc, desc)
 type SortOrder string
 
+// ENUM(ndjson, csv, archive)
+type ExportFormat string
+
+// ENUM(maxlen, minid)
+type TrimStrategy string
+
+// ENUM(name, length, memory, last_activity)
+type StreamSort string
+
 type StatsOverview struct {
 	TotalStreams     int   `json:"total_streams"`
 	TotalMessages    int64 `json:"total_messages"`
 	TotalDLQMessages int64 `json:"total_dlq_messages"`
 }
 
+// OverviewOpts scopes an overview. Allow, when set, counts only the
+// streams it accepts and the DLQ messages poisoned from them.
+type OverviewOpts struct {
+	Allow func(stream string) bool
+}
+
 type PaginationOpts struct {
 	Cursor string
 	Limit  int64
 	Order  SortOrder
 }
 
+// StreamInfo summarizes a stream. Metrics that could not be read are left
+// unknown (nil MemoryBytes, zero Length) and Error explains why, e.g. when
+// an ACL forbids MEMORY USAGE.
 type StreamInfo struct {
 	Name         string     `json:"name"`
 	Length       int64      `json:"length"`
-	MemoryBytes  int64      `json:"memory_bytes"`
+	MemoryBytes  *int64     `json:"memory_bytes"`
 	LastEntryID  *string    `json:"last_entry_id,omitempty"`
 	LastActivity *time.Time `json:"last_activity"`
+	Error        string     `json:"error,omitempty"`
+
+	// Node and Slot locate the stream on a Redis Cluster.
+	Node string `json:"node,omitempty"`
+	Slot *int   `json:"slot,omitempty"`
+}
+
+// StreamListOpts pages through the stream catalog sorted by Sort. Query
+// keeps only streams whose name contains it, and Allow, when set, only the
+// streams it accepts. Cursor is the offset returned as NextCursor by the
+// previous page.
+type StreamListOpts struct {
+	Query  string
+	Allow  func(stream string) bool
+	Sort   StreamSort
+	Order  SortOrder
+	Cursor string
+	Limit  int64
+}
+
+type StreamList struct {
+	Streams     []StreamInfo `json:"streams"`
+	TotalCount  int64        `json:"total_count"`
+	HasMore     bool         `json:"has_more"`
+	NextCursor  string       `json:"next_cursor,omitempty"`
+	RefreshedAt time.Time    `json:"refreshed_at"`
 }
 
 type StreamDetail struct {
 	StreamInfo
 	FirstEntryID *string `json:"first_entry_id,omitempty"`
+
+	// Full is set by GetStreamDetailFull.
+	Full *StreamFullInfo `json:"full,omitempty"`
+}
+
+// StreamFullInfo is what XINFO STREAM FULL reports about a stream beyond
+// its length and entries. EntriesAdded, DeletedEntries, MaxDeletedEntryID
+// and RecordedFirstEntryID are only known on Redis 7 and later.
+type StreamFullInfo struct {
+	LastGeneratedID string `json:"last_generated_id"`
+
+	// EntriesAdded counts every entry ever added to the stream, and
+	// DeletedEntries those since deleted or trimmed, i.e. its tombstones.
+	EntriesAdded   *int64 `json:"entries_added,omitempty"`
+	DeletedEntries *int64 `json:"deleted_entries,omitempty"`
+
+	MaxDeletedEntryID    string `json:"max_deleted_entry_id,omitempty"`
+	RecordedFirstEntryID string `json:"recorded_first_entry_id,omitempty"`
+
+	RadixTreeKeys  int64 `json:"radix_tree_keys"`
+	RadixTreeNodes int64 `json:"radix_tree_nodes"`
+
+	Groups []StreamGroupDetail `json:"groups"`
+}
+
+// StreamGroupDetail is a consumer group with its pending entries and
+// consumers. Pending lists at most the COUNT entries XINFO STREAM FULL was
+// asked for, while PendingCount counts all of them.
+type StreamGroupDetail struct {
+	Name            string                 `json:"name"`
+	LastDeliveredID string                 `json:"last_delivered_id"`
+	EntriesRead     *int64                 `json:"entries_read,omitempty"`
+	Lag             *int64                 `json:"lag,omitempty"`
+	PendingCount    int64                  `json:"pending_count"`
+	Pending         []PendingEntry         `json:"pending"`
+	Consumers       []StreamConsumerDetail `json:"consumers"`
+}
+
+// StreamConsumerDetail is a consumer of a group and the entries delivered
+// to it but not yet acknowledged, at most COUNT of them. ActiveAt is nil on
+// servers older than Redis 7.2.
+type StreamConsumerDetail struct {
+	Name         string         `json:"name"`
+	SeenAt       time.Time      `json:"seen_at"`
+	ActiveAt     *time.Time     `json:"active_at,omitempty"`
+	PendingCount int64          `json:"pending_count"`
+	Pending      []PendingEntry `json:"pending"`
+}
+
+// PendingEntry is an entry delivered to a consumer but not acknowledged.
+type PendingEntry struct {
+	ID            string    `json:"id"`
+	Consumer      string    `json:"consumer,omitempty"`
+	DeliveredAt   time.Time `json:"delivered_at"`
+	DeliveryCount int64     `json:"delivery_count"`
 }
 
 type Message struct {
-	ID        string         `json:"id"`
-	Payload   map[string]any `json:"payload"`
-	Timestamp time.Time      `json:"timestamp"`
+	ID        string            `json:"id"`
+	UUID      string            `json:"uuid,omitempty"`
+	Payload   map[string]any    `json:"payload"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
 }
 
 type MessageList[T any] struct {
@@ -68,11 +179,147 @@ type MessageList[T any] struct {
 }
 
 type DLQMessage struct {
-	ID            string         `json:"id"`
-	Payload       map[string]any `json:"payload"`
-	Timestamp     time.Time      `json:"timestamp"`
-	OriginalTopic string         `json:"original_topic"`
-	Error         string         `json:"error"`
+	ID            string            `json:"id"`
+	Payload       map[string]any    `json:"payload"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	Timestamp     time.Time         `json:"timestamp"`
+	OriginalTopic string            `json:"original_topic"`
+	Error         string            `json:"error"`
+	Attempts      int               `json:"attempts"`
+}
+
+// ExportOpts selects which messages an export includes. Filter, when set,
+// is called for every message and drops those it returns false for.
+type ExportOpts struct {
+	Order  SortOrder
+	Cursor string
+	Filter func(ExportRecord) bool
+}
+
+// ExportRecord is a stream entry as written by an export. Fields holds the
+// entry's raw Redis fields so it can be replayed unchanged; values that are
+// not valid UTF-8, such as Watermill's msgpack metadata, are base64 encoded
+// and named in Base64Fields. Payload and Metadata are nil when the entry is
+// not a valid Watermill message.
+//
+// An NDJSON export that fails part way ends with a line holding only
+// Error.
+type ExportRecord struct {
+	ID           string            `json:"id"`
+	Stream       string            `json:"stream"`
+	Timestamp    time.Time         `json:"timestamp"`
+	UUID         string            `json:"uuid,omitempty"`
+	Payload      map[string]any    `json:"payload"`
+	Metadata     map[string]string `json:"metadata"`
+	Fields       map[string]any    `json:"fields"`
+	Base64Fields []string          `json:"base64_fields,omitempty"`
+	Error        string            `json:"error,omitempty"`
+}
+
+// ReplayOpts controls how exported records are replayed. Target, when set,
+// receives every record; otherwise each record goes back to the topic it
+// was poisoned from, or the stream it was exported from. Allow, when set,
+// is asked whether a record may be written to its destination. Rate limits
+// the number of messages written per second; zero means unlimited.
+type ReplayOpts struct {
+	Target string
+	DryRun bool
+	Rate   float64
+	Allow  func(stream string) bool
+}
+
+type ReplayResult struct {
+	DryRun   bool          `json:"dry_run"`
+	Total    int           `json:"total"`
+	Replayed int           `json:"replayed"`
+	Failed   int           `json:"failed"`
+	Errors   []ReplayError `json:"errors,omitempty"`
+}
+
+// ReplayError reports a record that could not be replayed. Line is the
+// 1-based line of the record in the NDJSON input.
+type ReplayError struct {
+	Line  int    `json:"line"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error"`
+}
+
+// TrimOpts selects the entries removed by a trim. With TrimStrategyMaxlen
+// the newest MaxLen entries are kept; with TrimStrategyMinid entries older
+// than MinID are removed. Approximate lets Redis remove fewer entries when
+// that is cheaper.
+type TrimOpts struct {
+	Strategy    TrimStrategy
+	MaxLen      int64
+	MinID       string
+	Approximate bool
+	DryRun      bool
+}
+
+// TrimResult reports the entries removed by a trim. For a dry run, Removed
+// is the number of entries an exact trim would remove, estimated from the
+// entry IDs when Estimated is set.
+type TrimResult struct {
+	Stream      string       `json:"stream"`
+	Strategy    TrimStrategy `json:"strategy"`
+	Approximate bool         `json:"approximate"`
+	DryRun      bool         `json:"dry_run"`
+	Removed     int64        `json:"removed"`
+	Estimated   bool         `json:"estimated,omitempty"`
+}
+
+// RetentionPolicy bounds the size or age of every stream whose name
+// matches Stream, a path.Match pattern. A zero MaxLen or MaxAge leaves that
+// bound unset.
+type RetentionPolicy struct {
+	Stream      string        `json:"stream" yaml:"stream"`
+	MaxLen      int64         `json:"max_len,omitempty" yaml:"max_len"`
+	MaxAge      time.Duration `json:"max_age,omitempty" yaml:"max_age"`
+	Approximate bool          `json:"approximate" yaml:"approximate"`
+}
+
+// PurgeOpts selects the DLQ messages a purge deletes: those older than
+// Before, poisoned from Topic and whose error contains ErrorContains. Zero
+// values select everything. Confirm must equal the DLQ name unless DryRun
+// is set. When Export is set, the selected messages are written to it in
+// ExportFormat before any is deleted.
+type PurgeOpts struct {
+	Before        time.Time
+	Topic         string
+	ErrorContains string
+	Confirm       string
+	DryRun        bool
+	Export        io.Writer
+	ExportFormat  ExportFormat
+}
+
+// PurgeResult records what a purge selected, deleted and exported.
+type PurgeResult struct {
+	DLQ           string       `json:"dlq"`
+	Before        *time.Time   `json:"before,omitempty"`
+	Topic         string       `json:"topic,omitempty"`
+	ErrorContains string       `json:"error_contains,omitempty"`
+	DryRun        bool         `json:"dry_run"`
+	Matched       int64        `json:"matched"`
+	Deleted       int64        `json:"deleted"`
+	Exported      bool         `json:"exported"`
+	ExportFormat  ExportFormat `json:"export_format,omitempty"`
+	PurgedAt      time.Time    `json:"purged_at"`
+}
+
+// RetryPolicy automatically requeues DLQ messages poisoned from topics
+// matching Topic, a path.Match pattern, whose error matches ErrorPattern,
+// a regular expression (any error when empty). A message is retried at
+// most MaxAttempts times, waiting InitialBackoff after it was poisoned
+// the first time and Multiplier times longer after every further attempt,
+// up to MaxBackoff.
+type RetryPolicy struct {
+	Topic          string        `json:"topic" yaml:"topic"`
+	ErrorPattern   string        `json:"error_pattern,omitempty" yaml:"error_pattern"`
+	MaxAttempts    int           `json:"max_attempts" yaml:"max_attempts"`
+	InitialBackoff time.Duration `json:"initial_backoff" yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `json:"max_backoff,omitempty" yaml:"max_backoff"`
+	Multiplier     float64       `json:"multiplier,omitempty" yaml:"multiplier"`
 }
 
 type WatermillMessage struct {
@@ -108,6 +355,66 @@ func ParseStreamTimestamp(id string) (*time.Time, error) {
 	return &t, nil
 }
 
+// parseStreamID splits a full stream ID into its millisecond and sequence
+// parts. An ID without a sequence, as accepted by XTRIM MINID, has
+// sequence 0.
+func parseStreamID(id string) (ms, seq uint64, err error) {
+	msPart, seqPart, hasSeq := strings.Cut(id, "-")
+
+	ms, err = strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid stream id: %q", id)
+	}
+
+	if hasSeq {
+		seq, err = strconv.ParseUint(seqPart, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid stream id: %q", id)
+		}
+	}
+
+	return ms, seq, nil
+}
+
+// compareStreamIDs returns -1, 0 or 1 depending on whether a is older than,
+// equal to or newer than b. Both must be valid IDs.
+func compareStreamIDs(a, b string) int {
+	aMs, aSeq, _ := parseStreamID(a)
+	bMs, bSeq, _ := parseStreamID(b)
+
+	switch {
+	case aMs < bMs || (aMs == bMs && aSeq < bSeq):
+		return -1
+	case aMs == bMs && aSeq == bSeq:
+		return 0
+	default:
+		return 1
+	}
+}
+
+// nextStreamID returns the smallest ID greater than id.
+func nextStreamID(id string) string {
+	ms, seq, _ := parseStreamID(id)
+	if seq == math.MaxUint64 {
+		return fmt.Sprintf("%d-0", ms+1)
+	}
+	return fmt.Sprintf("%d-%d", ms, seq+1)
+}
+
+// prevStreamID returns the largest ID smaller than id, or "" if id is the
+// smallest possible ID.
+func prevStreamID(id string) string {
+	ms, seq, _ := parseStreamID(id)
+	switch {
+	case seq > 0:
+		return fmt.Sprintf("%d-%d", ms, seq-1)
+	case ms > 0:
+		return fmt.Sprintf("%d-%d", ms-1, uint64(math.MaxUint64))
+	default:
+		return ""
+	}
+}
+
 func ParseWatermillMessage(values map[string]any) (*WatermillMessage, error) {
 	msg := &WatermillMessage{
 		Metadata: make(map[string]string),
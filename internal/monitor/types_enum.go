@@ -10,6 +10,66 @@ import (
 	"fmt"
 )
 
+const (
+	// ExportFormatNdjson is a ExportFormat of type ndjson.
+	ExportFormatNdjson ExportFormat = "ndjson"
+	// ExportFormatCsv is a ExportFormat of type csv.
+	ExportFormatCsv ExportFormat = "csv"
+	// ExportFormatArchive is a ExportFormat of type archive.
+	ExportFormatArchive ExportFormat = "archive"
+)
+
+var ErrInvalidExportFormat = errors.New("not a valid ExportFormat")
+
+// String implements the Stringer interface.
+func (x ExportFormat) String() string {
+	return string(x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x ExportFormat) IsValid() bool {
+	_, err := ParseExportFormat(string(x))
+	return err == nil
+}
+
+var _ExportFormatValue = map[string]ExportFormat{
+	"ndjson":  ExportFormatNdjson,
+	"csv":     ExportFormatCsv,
+	"archive": ExportFormatArchive,
+}
+
+// ParseExportFormat attempts to convert a string to a ExportFormat.
+func ParseExportFormat(name string) (ExportFormat, error) {
+	if x, ok := _ExportFormatValue[name]; ok {
+		return x, nil
+	}
+	return ExportFormat(""), fmt.Errorf("%s is %w", name, ErrInvalidExportFormat)
+}
+
+// MarshalText implements the text marshaller method.
+func (x ExportFormat) MarshalText() ([]byte, error) {
+	return []byte(string(x)), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *ExportFormat) UnmarshalText(text []byte) error {
+	tmp, err := ParseExportFormat(string(text))
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
+
+// AppendText appends the textual representation of itself to the end of b
+// (allocating a larger slice if necessary) and returns the updated slice.
+//
+// Implementations must not retain b, nor mutate any bytes within b[:len(b)].
+func (x *ExportFormat) AppendText(b []byte) ([]byte, error) {
+	return append(b, x.String()...), nil
+}
+
 const (
 	// SortOrderAsc is a SortOrder of type asc.
 	SortOrderAsc SortOrder = "asc"
@@ -66,3 +126,123 @@ func (x *SortOrder) UnmarshalText(text []byte) error {
 func (x *SortOrder) AppendText(b []byte) ([]byte, error) {
 	return append(b, x.String()...), nil
 }
+
+const (
+	// TrimStrategyMaxlen is a TrimStrategy of type maxlen.
+	TrimStrategyMaxlen TrimStrategy = "maxlen"
+	// TrimStrategyMinid is a TrimStrategy of type minid.
+	TrimStrategyMinid TrimStrategy = "minid"
+)
+
+var ErrInvalidTrimStrategy = errors.New("not a valid TrimStrategy")
+
+// String implements the Stringer interface.
+func (x TrimStrategy) String() string {
+	return string(x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x TrimStrategy) IsValid() bool {
+	_, err := ParseTrimStrategy(string(x))
+	return err == nil
+}
+
+var _TrimStrategyValue = map[string]TrimStrategy{
+	"maxlen": TrimStrategyMaxlen,
+	"minid":  TrimStrategyMinid,
+}
+
+// ParseTrimStrategy attempts to convert a string to a TrimStrategy.
+func ParseTrimStrategy(name string) (TrimStrategy, error) {
+	if x, ok := _TrimStrategyValue[name]; ok {
+		return x, nil
+	}
+	return TrimStrategy(""), fmt.Errorf("%s is %w", name, ErrInvalidTrimStrategy)
+}
+
+// MarshalText implements the text marshaller method.
+func (x TrimStrategy) MarshalText() ([]byte, error) {
+	return []byte(string(x)), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *TrimStrategy) UnmarshalText(text []byte) error {
+	tmp, err := ParseTrimStrategy(string(text))
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
+
+// AppendText appends the textual representation of itself to the end of b
+// (allocating a larger slice if necessary) and returns the updated slice.
+//
+// Implementations must not retain b, nor mutate any bytes within b[:len(b)].
+func (x *TrimStrategy) AppendText(b []byte) ([]byte, error) {
+	return append(b, x.String()...), nil
+}
+
+const (
+	// StreamSortName is a StreamSort of type name.
+	StreamSortName StreamSort = "name"
+	// StreamSortLength is a StreamSort of type length.
+	StreamSortLength StreamSort = "length"
+	// StreamSortMemory is a StreamSort of type memory.
+	StreamSortMemory StreamSort = "memory"
+	// StreamSortLastActivity is a StreamSort of type last_activity.
+	StreamSortLastActivity StreamSort = "last_activity"
+)
+
+var ErrInvalidStreamSort = errors.New("not a valid StreamSort")
+
+// String implements the Stringer interface.
+func (x StreamSort) String() string {
+	return string(x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x StreamSort) IsValid() bool {
+	_, err := ParseStreamSort(string(x))
+	return err == nil
+}
+
+var _StreamSortValue = map[string]StreamSort{
+	"name":          StreamSortName,
+	"length":        StreamSortLength,
+	"memory":        StreamSortMemory,
+	"last_activity": StreamSortLastActivity,
+}
+
+// ParseStreamSort attempts to convert a string to a StreamSort.
+func ParseStreamSort(name string) (StreamSort, error) {
+	if x, ok := _StreamSortValue[name]; ok {
+		return x, nil
+	}
+	return StreamSort(""), fmt.Errorf("%s is %w", name, ErrInvalidStreamSort)
+}
+
+// MarshalText implements the text marshaller method.
+func (x StreamSort) MarshalText() ([]byte, error) {
+	return []byte(string(x)), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *StreamSort) UnmarshalText(text []byte) error {
+	tmp, err := ParseStreamSort(string(text))
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
+
+// AppendText appends the textual representation of itself to the end of b
+// (allocating a larger slice if necessary) and returns the updated slice.
+//
+// Implementations must not retain b, nor mutate any bytes within b[:len(b)].
+func (x *StreamSort) AppendText(b []byte) ([]byte, error) {
+	return append(b, x.String()...), nil
+}
@@ -0,0 +1,45 @@
+package monitor
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Worker is a job run in the background every Interval by RunWorkers.
+type Worker struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// RunWorkers runs every worker once immediately and then on its interval
+// until ctx is done. A failed run is logged and retried on the next tick.
+func RunWorkers(ctx context.Context, logger *slog.Logger, workers ...Worker) {
+	var wg sync.WaitGroup
+
+	for _, w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ticker := time.NewTicker(w.Interval)
+			defer ticker.Stop()
+
+			for {
+				if err := w.Run(ctx); err != nil && ctx.Err() == nil {
+					logger.Error("background worker failed", "worker", w.Name, "error", err)
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
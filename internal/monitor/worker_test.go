@@ -0,0 +1,67 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunWorkers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var ok, failing atomic.Int32
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		RunWorkers(ctx, logger,
+			Worker{Name: "ok", Interval: 5 * time.Millisecond, Run: func(context.Context) error {
+				ok.Add(1)
+				return nil
+			}},
+			// A failed run is retried on the next tick.
+			Worker{Name: "failing", Interval: 5 * time.Millisecond, Run: func(context.Context) error {
+				failing.Add(1)
+				return errors.New("boom")
+			}},
+		)
+	}()
+
+	require.Eventually(t, func() bool {
+		return ok.Load() >= 3 && failing.Load() >= 3
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("RunWorkers kept running after its context was done")
+	}
+
+	runs := ok.Load()
+	time.Sleep(20 * time.Millisecond)
+	require.Equal(t, runs, ok.Load())
+}
+
+func TestRunWorkers_RunsImmediately(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ran := make(chan struct{})
+	go RunWorkers(ctx, slog.New(slog.NewTextHandler(io.Discard, nil)), Worker{Name: "slow", Interval: time.Hour, Run: func(context.Context) error {
+		close(ran)
+		return nil
+	}})
+
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("worker did not run before its first interval")
+	}
+}
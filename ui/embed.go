@@ -1,7 +1,9 @@
 package ui
 
 import (
+	"bytes"
 	"embed"
+	"html"
 	"io/fs"
 	"net/http"
 	"path"
@@ -11,39 +13,81 @@ import (
 //go:embed dist/*
 var assets embed.FS
 
-type spaFileSystem struct {
-	fs http.FileSystem
+// Handler returns an http.Handler that serves the embedded UI assets from
+// the root path.
+func Handler() http.Handler {
+	return HandlerWithBasePath("")
 }
 
-func (sfs spaFileSystem) Open(name string) (http.File, error) {
-	f, err := sfs.fs.Open(name)
-	if err == nil {
-		return f, nil
-	}
-
-	// If file not found and doesn't have an extension, serve index.html
-	if path.Ext(name) == "" {
-		return sfs.fs.Open("index.html")
+// HandlerWithBasePath returns an http.Handler that serves the embedded UI
+// assets for a dashboard reachable under basePath (e.g. "/windmill"). The
+// base path is injected into index.html so the SPA resolves its assets, API
+// calls and routes relative to it. Requests are expected with basePath
+// already stripped.
+func HandlerWithBasePath(basePath string) http.Handler {
+	sub, err := fs.Sub(assets, "dist")
+	if err != nil {
+		panic(err)
 	}
 
-	return nil, err
-}
-
-// Handler returns an http.Handler that serves the embedded UI assets.
-func Handler() http.Handler {
-	sub, err := fs.Sub(assets, "dist")
+	index, err := fs.ReadFile(sub, "index.html")
 	if err != nil {
 		panic(err)
 	}
 
-	return http.FileServer(spaFileSystem{http.FS(sub)})
+	return &spaHandler{
+		fs:    sub,
+		files: http.FileServer(http.FS(sub)),
+		index: injectBasePath(index, strings.TrimSuffix(basePath, "/")),
+	}
 }
 
 // FixedHandler is a helper for mounting the UI at a specific prefix.
 func FixedHandler(prefix string) http.Handler {
-	h := Handler()
-	if prefix == "" || prefix == "/" {
+	prefix = strings.TrimSuffix(prefix, "/")
+	h := HandlerWithBasePath(prefix)
+	if prefix == "" {
 		return h
 	}
-	return http.StripPrefix(strings.TrimSuffix(prefix, "/"), h)
+	return http.StripPrefix(prefix, h)
+}
+
+type spaHandler struct {
+	fs    fs.FS
+	files http.Handler
+	index []byte
+}
+
+func (s *spaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+
+	if name == "" || name == "index.html" {
+		s.serveIndex(w)
+		return
+	}
+
+	if _, err := fs.Stat(s.fs, name); err != nil {
+		// Client-side routes have no extension and fall back to index.html
+		if path.Ext(name) == "" {
+			s.serveIndex(w)
+			return
+		}
+	}
+
+	s.files.ServeHTTP(w, r)
+}
+
+func (s *spaHandler) serveIndex(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	_, _ = w.Write(s.index)
+}
+
+// injectBasePath adds a <base> element, so relative asset URLs resolve
+// from the dashboard root on any client-side route, and a meta tag the SPA
+// reads to prefix its API calls and routes.
+func injectBasePath(index []byte, basePath string) []byte {
+	escaped := html.EscapeString(basePath)
+	tags := `<head><base href="` + escaped + `/"><meta name="windmill-base-path" content="` + escaped + `">`
+	return bytes.Replace(index, []byte("<head>"), []byte(tags), 1)
 }
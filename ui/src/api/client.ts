@@ -1,4 +1,5 @@
-import { ApiResponse, ErrorResponse } from './types'
+import { ApiResponse, Capabilities, ConsumerHealth, Diagnostics, ErrorResponse, InstancesOverview, MessageList, RedisInfo, ExportFormat, PurgeRequest, PurgeResult, ReplayResult, ScheduledRequeue, StreamDetail, StreamList, StreamListOpts, Topology, TrimRequest, TrimResult } from './types'
+import { basePath } from '@/lib/utils'
 
 export class ApiError extends Error {
   constructor(public status: number, public message: string) {
@@ -6,11 +7,17 @@ export class ApiError extends Error {
   }
 }
 
+function csrfToken(): string {
+  const match = document.cookie.match(/(?:^|;\s*)windmill_csrf=([^;]*)/)
+  return match ? decodeURIComponent(match[1]) : ''
+}
+
 async function request<T>(path: string, options?: RequestInit): Promise<T> {
-  const response = await fetch(path, {
+  const response = await fetch(basePath + path, {
     ...options,
     headers: {
       'Content-Type': 'application/json',
+      'X-CSRF-Token': csrfToken(),
       ...options?.headers,
     },
   })
@@ -40,33 +47,136 @@ async function request<T>(path: string, options?: RequestInit): Promise<T> {
   return data.data
 }
 
+const instanceStorageKey = 'windmill_instance'
+
+// The instance scoped requests target. Empty selects the server's first
+// instance through the unscoped routes.
+let currentInstance = localStorage.getItem(instanceStorageKey) ?? ''
+const instanceListeners = new Set<() => void>()
+
+export function getInstance(): string {
+  return currentInstance
+}
+
+export function setInstance(name: string) {
+  currentInstance = name
+  if (name) {
+    localStorage.setItem(instanceStorageKey, name)
+  } else {
+    localStorage.removeItem(instanceStorageKey)
+  }
+  instanceListeners.forEach((listener) => listener())
+}
+
+export function subscribeInstance(listener: () => void) {
+  instanceListeners.add(listener)
+  return () => {
+    instanceListeners.delete(listener)
+  }
+}
+
+// scoped returns the URL path of an instance route, e.g. "/streams".
+function scoped(path: string): string {
+  if (!currentInstance) return `/api${path}`
+  return `/api/instances/${encodeURIComponent(currentInstance)}${path}`
+}
+
+// Export URLs are opened as plain downloads so the browser streams the
+// file instead of buffering it through fetch.
+export const exportUrls = {
+  stream: (name: string, format: ExportFormat) =>
+    `${basePath}${scoped(`/streams/${encodeURIComponent(name)}/export`)}?format=${format}`,
+  dlq: (format: ExportFormat) => `${basePath}${scoped('/dlq/export')}?format=${format}`,
+}
+
 export const api = {
-  getOverview: () => request<any>('/api/overview'),
-  getStreams: () => request<any[]>('/api/streams'),
-  getStream: (name: string) => request<any>(`/api/streams/${name}`),
+  getCapabilities: () => request<Capabilities>('/api/capabilities'),
+  getInstances: () => request<InstancesOverview>('/api/instances'),
+  getOverview: () => request<any>(scoped('/overview')),
+  getDiagnostics: () => request<Diagnostics>(scoped('/diagnostics')),
+  getRedisInfo: () => request<RedisInfo>(scoped('/redis/info')),
+  getConsumerHealth: () => request<ConsumerHealth>(scoped('/health/consumers')),
+  getTopology: () => request<Topology>(scoped('/topology')),
+  getStreams: (opts: StreamListOpts) => {
+    const searchParams = new URLSearchParams()
+    if (opts.q) searchParams.set('q', opts.q)
+    if (opts.sort) searchParams.set('sort', opts.sort)
+    if (opts.order) searchParams.set('order', opts.order)
+    if (opts.cursor) searchParams.set('cursor', opts.cursor)
+    if (opts.limit) searchParams.set('limit', opts.limit.toString())
+    return request<StreamList>(scoped(`/streams?${searchParams.toString()}`))
+  },
+  getStream: (name: string, full = false) => request<StreamDetail>(scoped(`/streams/${name}${full ? '?full=true' : ''}`)),
   getStreamMessages: (name: string, params: any) => {
     const searchParams = new URLSearchParams()
     if (params.cursor) searchParams.set('cursor', params.cursor)
     if (params.limit) searchParams.set('limit', params.limit.toString())
     if (params.order) searchParams.set('order', params.order)
-    return request<any>(`/api/streams/${name}/messages?${searchParams.toString()}`)
+    return request<any>(scoped(`/streams/${name}/messages?${searchParams.toString()}`))
   },
-  getDLQStats: () => request<any>('/api/dlq'),
+  getDLQStats: () => request<any>(scoped('/dlq')),
   getDLQMessages: (params: any) => {
     const searchParams = new URLSearchParams()
     if (params.cursor) searchParams.set('cursor', params.cursor)
     if (params.limit) searchParams.set('limit', params.limit.toString())
     if (params.order) searchParams.set('order', params.order)
-    return request<any>(`/api/dlq/messages?${searchParams.toString()}`)
+    return request<any>(scoped(`/dlq/messages?${searchParams.toString()}`))
   },
   requeueMessage: (id: string, payload?: any) =>
-    request<void>(`/api/dlq/messages/${id}/requeue`, {
+    request<void>(scoped(`/dlq/messages/${id}/requeue`), {
       method: 'POST',
       body: JSON.stringify(payload || {}),
     }),
-  requeueAll: () => request<{ requeued: number }>('/api/dlq/requeue-all', { method: 'POST' }),
+  getScheduledRequeues: () => request<MessageList<ScheduledRequeue>>(scoped('/dlq/scheduled?limit=100')),
+  scheduleRequeue: (id: string, at: string, payload?: any) =>
+    request<ScheduledRequeue>(scoped(`/dlq/messages/${id}/schedule`), {
+      method: 'POST',
+      body: JSON.stringify({ at, payload }),
+    }),
+  cancelRequeue: (id: string) =>
+    request<void>(scoped(`/dlq/messages/${id}/schedule`), { method: 'DELETE' }),
+  requeueAll: () => request<{ requeued: number }>(scoped('/dlq/requeue-all'), { method: 'POST' }),
   deleteDLQMessage: (id: string) =>
-    request<void>(`/api/dlq/messages/${id}`, { method: 'DELETE' }),
+    request<void>(scoped(`/dlq/messages/${id}`), { method: 'DELETE' }),
   deleteStreamMessage: (name: string, id: string) =>
-    request<void>(`/api/streams/${name}/messages/${id}`, { method: 'DELETE' }),
+    request<void>(scoped(`/streams/${name}/messages/${id}`), { method: 'DELETE' }),
+  publishMessage: (name: string, payload: any, metadata?: Record<string, string>) =>
+    request<any>(scoped(`/streams/${name}/messages`), {
+      method: 'POST',
+      body: JSON.stringify({ payload, metadata }),
+    }),
+  trimStream: (name: string, req: TrimRequest) =>
+    request<TrimResult>(scoped(`/streams/${name}/trim`), {
+      method: 'POST',
+      body: JSON.stringify(req),
+    }),
+  purgeDLQ: (req: PurgeRequest) =>
+    request<PurgeResult>(scoped('/dlq/purge'), {
+      method: 'POST',
+      body: JSON.stringify(req),
+    }),
+  // Purges with an export of the deleted messages, which the server sends
+  // back as the response body before deleting anything.
+  purgeDLQWithExport: async (req: PurgeRequest, format: ExportFormat) => {
+    const response = await fetch(basePath + scoped('/dlq/purge'), {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json', 'X-CSRF-Token': csrfToken() },
+      body: JSON.stringify({ ...req, export: format }),
+    })
+    if (!response.ok) {
+      const errorData = (await response.json().catch(() => null)) as ErrorResponse | null
+      throw new ApiError(response.status, errorData?.message ?? response.statusText)
+    }
+    return response.blob()
+  },
+  replay: (file: File, params: { target?: string; dryRun?: boolean }) => {
+    const searchParams = new URLSearchParams()
+    if (params.target) searchParams.set('target', params.target)
+    if (params.dryRun) searchParams.set('dry_run', 'true')
+    return request<ReplayResult>(scoped(`/replay?${searchParams.toString()}`), {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/x-ndjson' },
+      body: file,
+    })
+  },
 }
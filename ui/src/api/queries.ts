@@ -1,14 +1,57 @@
-import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query'
-import { api } from './client'
-import { PaginationOpts } from './types'
+import { keepPreviousData, useQuery, useMutation, useQueryClient } from '@tanstack/react-query'
+import { useSyncExternalStore } from 'react'
+import { api, getInstance, setInstance, subscribeInstance } from './client'
+import { ExportFormat, PaginationOpts, PurgeRequest, StreamListOpts, TrimRequest } from './types'
 
 export const queryKeys = {
+  capabilities: ['capabilities'] as const,
+  instances: ['instances'] as const,
   overview: ['overview'] as const,
+  diagnostics: ['diagnostics'] as const,
+  redisInfo: ['redis', 'info'] as const,
+  consumerHealth: ['health', 'consumers'] as const,
+  topology: ['topology'] as const,
   streams: ['streams'] as const,
+  streamList: (opts: StreamListOpts) => ['streams', opts] as const,
   stream: (name: string) => ['stream', name] as const,
   streamMessages: (name: string, opts: PaginationOpts) => ['stream', name, 'messages', opts] as const,
   dlqStats: ['dlq', 'stats'] as const,
   dlqMessages: (opts: PaginationOpts) => ['dlq', 'messages', opts] as const,
+  dlqScheduled: ['dlq', 'scheduled'] as const,
+}
+
+export function useCapabilities() {
+  return useQuery({
+    queryKey: queryKeys.capabilities,
+    queryFn: api.getCapabilities,
+    staleTime: Infinity,
+  })
+}
+
+export function useInstances() {
+  return useQuery({
+    queryKey: queryKeys.instances,
+    queryFn: api.getInstances,
+  })
+}
+
+// useInstance returns the name of the selected instance, empty for the
+// server's first one.
+export function useInstance() {
+  return useSyncExternalStore(subscribeInstance, getInstance)
+}
+
+// useSelectInstance switches every instance-scoped query to another
+// instance, refetching what is on screen.
+export function useSelectInstance() {
+  const queryClient = useQueryClient()
+
+  return (name: string) => {
+    setInstance(name)
+    queryClient.resetQueries({
+      predicate: (query) => query.queryKey[0] !== 'capabilities' && query.queryKey[0] !== 'instances',
+    })
+  }
 }
 
 export function useOverview() {
@@ -18,17 +61,47 @@ export function useOverview() {
   })
 }
 
-export function useStreams() {
+export function useDiagnostics() {
+  return useQuery({
+    queryKey: queryKeys.diagnostics,
+    queryFn: api.getDiagnostics,
+    staleTime: Infinity,
+  })
+}
+
+export function useRedisInfo() {
+  return useQuery({
+    queryKey: queryKeys.redisInfo,
+    queryFn: api.getRedisInfo,
+  })
+}
+
+export function useConsumerHealth() {
+  return useQuery({
+    queryKey: queryKeys.consumerHealth,
+    queryFn: api.getConsumerHealth,
+  })
+}
+
+export function useTopology() {
+  return useQuery({
+    queryKey: queryKeys.topology,
+    queryFn: api.getTopology,
+  })
+}
+
+export function useStreams(opts: StreamListOpts = {}) {
   return useQuery({
-    queryKey: queryKeys.streams,
-    queryFn: api.getStreams,
+    queryKey: queryKeys.streamList(opts),
+    queryFn: () => api.getStreams(opts),
+    placeholderData: keepPreviousData,
   })
 }
 
 export function useStream(name: string) {
   return useQuery({
     queryKey: queryKeys.stream(name),
-    queryFn: () => api.getStream(name),
+    queryFn: () => api.getStream(name, true),
     enabled: !!name,
   })
 }
@@ -67,6 +140,34 @@ export function useRequeueMessage() {
   })
 }
 
+export function useScheduledRequeues() {
+  return useQuery({
+    queryKey: queryKeys.dlqScheduled,
+    queryFn: api.getScheduledRequeues,
+  })
+}
+
+export function useScheduleRequeue() {
+  const queryClient = useQueryClient()
+  return useMutation({
+    mutationFn: ({ id, at, payload }: { id: string; at: string; payload?: any }) =>
+      api.scheduleRequeue(id, at, payload),
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: queryKeys.dlqScheduled })
+    },
+  })
+}
+
+export function useCancelRequeue() {
+  const queryClient = useQueryClient()
+  return useMutation({
+    mutationFn: (id: string) => api.cancelRequeue(id),
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: queryKeys.dlqScheduled })
+    },
+  })
+}
+
 export function useRequeueAll() {
   const queryClient = useQueryClient()
   return useMutation({
@@ -100,3 +201,53 @@ export function useDeleteStreamMessage() {
     },
   })
 }
+
+export function usePublishMessage() {
+  const queryClient = useQueryClient()
+  return useMutation({
+    mutationFn: ({ name, payload, metadata }: { name: string; payload: any; metadata?: Record<string, string> }) =>
+      api.publishMessage(name, payload, metadata),
+    onSuccess: (_, { name }) => {
+      queryClient.invalidateQueries({ queryKey: queryKeys.streamMessages(name, {}) })
+      queryClient.invalidateQueries({ queryKey: queryKeys.stream(name) })
+    },
+  })
+}
+
+export function useTrimStream() {
+  const queryClient = useQueryClient()
+  return useMutation({
+    mutationFn: ({ name, req }: { name: string; req: TrimRequest }) => api.trimStream(name, req),
+    onSuccess: (_, { name, req }) => {
+      if (req.dry_run) return
+      queryClient.invalidateQueries({ queryKey: queryKeys.streamMessages(name, {}) })
+      queryClient.invalidateQueries({ queryKey: queryKeys.stream(name) })
+    },
+  })
+}
+
+export function usePurgeDLQ() {
+  const queryClient = useQueryClient()
+  return useMutation({
+    mutationFn: ({ req, exportFormat }: { req: PurgeRequest; exportFormat?: ExportFormat }) =>
+      exportFormat && !req.dry_run ? api.purgeDLQWithExport(req, exportFormat) : api.purgeDLQ(req),
+    onSuccess: (_, { req }) => {
+      if (req.dry_run) return
+      queryClient.invalidateQueries({ queryKey: queryKeys.dlqMessages({}) })
+      queryClient.invalidateQueries({ queryKey: queryKeys.dlqStats })
+    },
+  })
+}
+
+export function useReplay() {
+  const queryClient = useQueryClient()
+  return useMutation({
+    mutationFn: ({ file, target, dryRun }: { file: File; target?: string; dryRun?: boolean }) =>
+      api.replay(file, { target, dryRun }),
+    onSuccess: (_, { dryRun }) => {
+      if (dryRun) return
+      queryClient.invalidateQueries({ queryKey: queryKeys.streams })
+      queryClient.invalidateQueries({ queryKey: queryKeys.overview })
+    },
+  })
+}
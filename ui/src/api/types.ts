@@ -6,6 +6,24 @@ export interface StatsOverview {
   total_dlq_messages: number
 }
 
+export type StreamSort = 'name' | 'length' | 'memory' | 'last_activity'
+
+export interface StreamListOpts {
+  q?: string
+  sort?: StreamSort
+  order?: SortOrder
+  cursor?: string
+  limit?: number
+}
+
+export interface StreamList {
+  streams: StreamInfo[]
+  total_count: number
+  has_more: boolean
+  next_cursor?: string
+  refreshed_at: string
+}
+
 export interface PaginationOpts {
   cursor?: string
   limit?: number
@@ -15,18 +33,60 @@ export interface PaginationOpts {
 export interface StreamInfo {
   name: string
   length: number
-  memory_bytes: number
+  memory_bytes: number | null
   last_entry_id?: string
   last_activity?: string
+  node?: string
+  slot?: number
+  error?: string
 }
 
 export interface StreamDetail extends StreamInfo {
   first_entry_id?: string
+  full?: StreamFullInfo
+}
+
+export interface PendingEntry {
+  id: string
+  consumer?: string
+  delivered_at: string
+  delivery_count: number
+}
+
+export interface StreamConsumerDetail {
+  name: string
+  seen_at: string
+  active_at?: string
+  pending_count: number
+  pending: PendingEntry[]
+}
+
+export interface StreamGroupDetail {
+  name: string
+  last_delivered_id: string
+  entries_read?: number
+  lag?: number
+  pending_count: number
+  pending: PendingEntry[]
+  consumers: StreamConsumerDetail[]
+}
+
+export interface StreamFullInfo {
+  last_generated_id: string
+  entries_added?: number
+  deleted_entries?: number
+  max_deleted_entry_id?: string
+  recorded_first_entry_id?: string
+  radix_tree_keys: number
+  radix_tree_nodes: number
+  groups: StreamGroupDetail[]
 }
 
 export interface Message {
   id: string
+  uuid?: string
   payload: Record<string, any>
+  metadata?: Record<string, string>
   timestamp: string
 }
 
@@ -43,6 +103,165 @@ export interface DLQMessage {
   timestamp: string
   original_topic: string
   error: string
+  attempts: number
+  metadata?: Record<string, string>
+}
+
+export interface ScheduledRequeue {
+  id: string
+  topic?: string
+  due_at: string
+  payload?: Record<string, any>
+}
+
+export interface Capabilities {
+  read_only: boolean
+  user: string
+  role: 'viewer' | 'operator' | 'admin'
+  streams?: string[]
+  can_requeue: boolean
+  can_delete: boolean
+  can_requeue_all: boolean
+  can_publish: boolean
+  can_purge: boolean
+}
+
+export interface StatsOverview {
+  total_streams: number
+  total_messages: number
+  total_dlq_messages: number
+}
+
+export interface InstanceOverview {
+  name: string
+  dlq: string
+  overview?: StatsOverview
+  error?: string
+}
+
+export interface InstancesOverview {
+  instances: InstanceOverview[]
+  total: StatsOverview
+}
+
+export interface ReplicaInfo {
+  addr: string
+  state: string
+  lag_bytes: number
+  lag_seconds: number
+}
+
+export interface ReplicationInfo {
+  role: 'master' | 'replica' | ''
+  replicas?: ReplicaInfo[]
+  master_link_status?: string
+  master_last_io_seconds?: number
+  lag_bytes?: number
+}
+
+export interface SlowLogEntry {
+  id: number
+  time: string
+  duration_us: number
+  command: string
+  args: string[]
+  key?: string
+  redacted_args?: number
+  client_addr?: string
+  client_name?: string
+}
+
+export interface RedisNodeInfo {
+  addr?: string
+  version?: string
+  uptime_seconds: number
+  used_memory: number
+  used_memory_peak: number
+  max_memory: number
+  max_memory_policy?: string
+  fragmentation_ratio: number
+  evicted_keys: number
+  connected_clients: number
+  blocked_clients: number
+  ops_per_sec: number
+  replication: ReplicationInfo
+  slow_log: SlowLogEntry[]
+  slow_log_error?: string
+  error?: string
+}
+
+export interface RedisInfo {
+  nodes: RedisNodeInfo[]
+}
+
+export interface ConsumerStatus {
+  name: string
+  pending: number
+  idle_ms: number
+  stuck: boolean
+}
+
+export interface GroupHealth {
+  stream: string
+  group?: string
+  last_delivered_id?: string
+  entries_read?: number
+  lag: number | null
+  lag_source?: 'redis' | 'range'
+  lag_capped?: boolean
+  lag_seconds?: number
+  pending: number
+  consumers?: ConsumerStatus[]
+  error?: string
+}
+
+export interface ConsumerHealth {
+  healthy: boolean
+  total_lag: number
+  total_pending: number
+  stuck_consumers: number
+  idle_threshold_ms: number
+  checked_at: string
+  groups: GroupHealth[]
+}
+
+export type TopologyNodeKind = 'topic' | 'group' | 'consumer' | 'handler'
+
+export interface TopologyNode {
+  id: string
+  kind: TopologyNodeKind
+  name: string
+  stream?: string
+  group?: string
+  pending?: number
+  registered?: boolean
+  poisoned?: number
+}
+
+export interface TopologyEdge {
+  from: string
+  to: string
+  kind: 'consumes' | 'publishes'
+  inferred?: boolean
+}
+
+export interface Topology {
+  nodes: TopologyNode[]
+  edges: TopologyEdge[]
+  errors?: string[]
+}
+
+export interface RedisCapabilities {
+  version?: string
+  scan_type: boolean
+  memory_usage: boolean
+  xinfo: boolean
+  unavailable?: Record<string, string>
+  probed_at?: string
+}
+
+export interface Diagnostics {
+  redis: RedisCapabilities
 }
 
 export interface ApiResponse<T> {
@@ -54,3 +273,56 @@ export interface ErrorResponse {
   success: boolean
   message: string
 }
+
+export interface ReplayError {
+  line: number
+  id?: string
+  error: string
+}
+
+export interface ReplayResult {
+  dry_run: boolean
+  total: number
+  replayed: number
+  failed: number
+  errors?: ReplayError[]
+}
+
+export type TrimStrategy = 'maxlen' | 'minid'
+
+export interface TrimRequest {
+  strategy: TrimStrategy
+  max_len?: number
+  min_id?: string
+  approximate?: boolean
+  dry_run?: boolean
+}
+
+export interface TrimResult {
+  stream: string
+  strategy: TrimStrategy
+  approximate: boolean
+  dry_run: boolean
+  removed: number
+  estimated?: boolean
+}
+
+export interface PurgeRequest {
+  before?: string
+  topic?: string
+  error_contains?: string
+  confirm?: string
+  dry_run?: boolean
+}
+
+export type ExportFormat = 'ndjson' | 'csv' | 'archive'
+
+export interface PurgeResult {
+  dlq: string
+  dry_run: boolean
+  matched: number
+  deleted: number
+  exported: boolean
+  export_format?: ExportFormat
+  purged_at: string
+}
@@ -12,7 +12,8 @@ import {
     Moon,
     Sun,
     Search,
-    Inbox
+    Inbox,
+    Network
 } from "lucide-react";
 import { cn } from "@/lib/utils";
 
@@ -68,6 +69,13 @@ export function CommandPalette({ open, onOpenChange }: CommandPaletteProps) {
             action: () => { navigate({ to: "/dlq" }); onOpenChange(false); },
             keywords: ["errors", "failed", "dead letter"],
         },
+        {
+            id: "topology",
+            label: "Go to Topology",
+            icon: Network,
+            action: () => { navigate({ to: "/topology" }); onOpenChange(false); },
+            keywords: ["handlers", "groups", "consumers", "graph"],
+        },
         {
             id: "refresh",
             label: "Refresh Page",
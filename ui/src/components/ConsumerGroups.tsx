@@ -0,0 +1,117 @@
+import { StreamFullInfo, StreamGroupDetail } from "@/api/types"
+import { Badge } from "@/components/ui/badge"
+import {
+  Table,
+  TableBody,
+  TableCell,
+  TableHead,
+  TableHeader,
+  TableRow,
+} from "@/components/ui/table"
+import { formatFullDate, formatNumber, formatRelativeTime } from "@/lib/utils"
+import { ChevronDown, ChevronRight } from "lucide-react"
+import { Fragment, useState } from "react"
+
+function known(n: number | undefined) {
+  return n === undefined ? "?" : formatNumber(n)
+}
+
+function GroupConsumers({ group }: { group: StreamGroupDetail }) {
+  if (group.consumers.length === 0) {
+    return <p className="text-sm text-muted-foreground px-4 py-3">No consumers</p>
+  }
+
+  return (
+    <div className="px-4 py-3 space-y-3">
+      {group.consumers.map((consumer) => (
+        <div key={consumer.name} className="space-y-1">
+          <div className="flex items-center gap-2 text-sm">
+            <span className="font-mono">{consumer.name}</span>
+            <span className="text-muted-foreground" title={formatFullDate(consumer.seen_at)}>
+              seen {formatRelativeTime(consumer.seen_at)}
+            </span>
+            {consumer.pending_count > 0 && (
+              <Badge variant="warning">{formatNumber(consumer.pending_count)} pending</Badge>
+            )}
+          </div>
+          {consumer.pending.length > 0 && (
+            <ul className="text-xs font-mono text-muted-foreground pl-4">
+              {consumer.pending.map((entry) => (
+                <li key={entry.id}>
+                  {entry.id} · delivered {entry.delivery_count}× · {formatRelativeTime(entry.delivered_at)}
+                </li>
+              ))}
+              {consumer.pending_count > consumer.pending.length && (
+                <li>… {formatNumber(consumer.pending_count - consumer.pending.length)} more</li>
+              )}
+            </ul>
+          )}
+        </div>
+      ))}
+    </div>
+  )
+}
+
+export function ConsumerGroups({ full }: { full: StreamFullInfo }) {
+  const [expanded, setExpanded] = useState<string | null>(null)
+
+  return (
+    <div className="space-y-4">
+      <div className="flex items-center justify-between">
+        <h2 className="text-lg font-semibold">Consumer Groups</h2>
+        <p className="text-sm text-muted-foreground">
+          {known(full.entries_added)} added since creation · {known(full.deleted_entries)} deleted
+          {full.max_deleted_entry_id && <span className="font-mono"> (up to {full.max_deleted_entry_id})</span>}
+          {" · "}{formatNumber(full.radix_tree_nodes)} radix tree nodes
+        </p>
+      </div>
+
+      {full.groups.length === 0 ? (
+        <p className="text-sm text-muted-foreground">No consumer groups read this stream.</p>
+      ) : (
+        <div className="rounded-lg border overflow-hidden">
+          <Table>
+            <TableHeader>
+              <TableRow className="bg-muted/50">
+                <TableHead className="w-10"></TableHead>
+                <TableHead>Group</TableHead>
+                <TableHead className="text-right">Consumers</TableHead>
+                <TableHead className="text-right">Pending</TableHead>
+                <TableHead className="text-right">Lag</TableHead>
+                <TableHead className="hidden md:table-cell pr-4">Last Delivered</TableHead>
+              </TableRow>
+            </TableHeader>
+            <TableBody>
+              {full.groups.map((group) => (
+                <Fragment key={group.name}>
+                  <TableRow
+                    className="cursor-pointer"
+                    onClick={() => setExpanded(expanded === group.name ? null : group.name)}
+                  >
+                    <TableCell>
+                      {expanded === group.name ? <ChevronDown className="h-4 w-4" /> : <ChevronRight className="h-4 w-4" />}
+                    </TableCell>
+                    <TableCell className="font-mono">{group.name}</TableCell>
+                    <TableCell className="text-right tabular-nums">{formatNumber(group.consumers.length)}</TableCell>
+                    <TableCell className="text-right tabular-nums">{formatNumber(group.pending_count)}</TableCell>
+                    <TableCell className="text-right tabular-nums">{known(group.lag)}</TableCell>
+                    <TableCell className="font-mono text-xs text-muted-foreground hidden md:table-cell pr-4">
+                      {group.last_delivered_id}
+                    </TableCell>
+                  </TableRow>
+                  {expanded === group.name && (
+                    <TableRow className="bg-muted/30 hover:bg-muted/30">
+                      <TableCell colSpan={6} className="p-0">
+                        <GroupConsumers group={group} />
+                      </TableCell>
+                    </TableRow>
+                  )}
+                </Fragment>
+              ))}
+            </TableBody>
+          </Table>
+        </div>
+      )}
+    </div>
+  )
+}
@@ -0,0 +1,120 @@
+import { useConsumerHealth } from "@/api/queries"
+import { GroupHealth } from "@/api/types"
+import { Badge } from "@/components/ui/badge"
+import {
+  Table,
+  TableBody,
+  TableCell,
+  TableHead,
+  TableHeader,
+  TableRow,
+} from "@/components/ui/table"
+import { formatNumber } from "@/lib/utils"
+import { useNavigate } from "@tanstack/react-router"
+
+function formatLag(group: GroupHealth) {
+  if (group.lag === null) return "?"
+  return formatNumber(group.lag) + (group.lag_capped ? "+" : "")
+}
+
+function formatAge(seconds: number | undefined) {
+  if (seconds === undefined) return "—"
+  if (seconds < 60) return `${seconds}s`
+  if (seconds < 3600) return `${Math.floor(seconds / 60)}m`
+  return `${Math.floor(seconds / 3600)}h ${Math.floor((seconds % 3600) / 60)}m`
+}
+
+// Groups with errors or stuck consumers come first, then the most lagging.
+function severity(group: GroupHealth) {
+  if (group.error) return 2
+  if (group.consumers?.some((c) => c.stuck)) return 1
+  return 0
+}
+
+export function ConsumerHealth() {
+  const { data: health } = useConsumerHealth()
+  const navigate = useNavigate()
+
+  if (!health || health.groups.length === 0) return null
+
+  const groups = [...health.groups].sort(
+    (a, b) => severity(b) - severity(a) || (b.lag ?? 0) - (a.lag ?? 0),
+  )
+
+  return (
+    <div className="space-y-4">
+      <div className="flex items-center justify-between">
+        <div className="flex items-center gap-2">
+          <h2 className="text-lg font-semibold">Consumers</h2>
+          {health.healthy ? (
+            <Badge variant="success">HEALTHY</Badge>
+          ) : (
+            <Badge variant="warning">
+              {health.stuck_consumers > 0 ? `${health.stuck_consumers} STUCK` : "DEGRADED"}
+            </Badge>
+          )}
+        </div>
+        <p className="text-sm text-muted-foreground">
+          {formatNumber(health.total_lag)} undelivered · {formatNumber(health.total_pending)} pending
+        </p>
+      </div>
+
+      <div className="rounded-lg border overflow-hidden">
+        <Table>
+          <TableHeader>
+            <TableRow className="bg-muted/50">
+              <TableHead>Stream</TableHead>
+              <TableHead>Group</TableHead>
+              <TableHead className="text-right">Lag</TableHead>
+              <TableHead className="text-right hidden sm:table-cell">Oldest</TableHead>
+              <TableHead className="text-right">Pending</TableHead>
+              <TableHead className="hidden md:table-cell pr-4">Stuck Consumers</TableHead>
+            </TableRow>
+          </TableHeader>
+          <TableBody>
+            {groups.map((group) => {
+              const stuck = group.consumers?.filter((c) => c.stuck) ?? []
+              return (
+                <TableRow
+                  key={`${group.stream}/${group.group ?? ""}`}
+                  className="cursor-pointer"
+                  onClick={() => navigate({ to: '/streams/$name', params: { name: group.stream } })}
+                >
+                  <TableCell className="font-mono">{group.stream}</TableCell>
+                  {group.error ? (
+                    <TableCell colSpan={5} className="text-warning text-xs font-mono">
+                      {group.group && <span>{group.group}: </span>}{group.error}
+                    </TableCell>
+                  ) : (
+                    <>
+                      <TableCell className="font-mono">{group.group}</TableCell>
+                      <TableCell
+                        className="text-right tabular-nums"
+                        title={group.lag_source === 'range' ? "Counted from the last delivered ID" : undefined}
+                      >
+                        {formatLag(group)}
+                      </TableCell>
+                      <TableCell className="text-right tabular-nums hidden sm:table-cell">
+                        {formatAge(group.lag_seconds)}
+                      </TableCell>
+                      <TableCell className="text-right tabular-nums">{formatNumber(group.pending)}</TableCell>
+                      <TableCell className="hidden md:table-cell pr-4 font-mono text-xs">
+                        {stuck.length === 0 ? (
+                          <span className="text-muted-foreground">—</span>
+                        ) : (
+                          <span className="text-warning">
+                            {stuck.map((c) => `${c.name} (${formatNumber(c.pending)}, idle ${formatAge(Math.floor(c.idle_ms / 1000))})`).join(", ")}
+                          </span>
+                        )}
+                      </TableCell>
+                    </>
+                  )}
+                </TableRow>
+              )
+            })}
+          </TableBody>
+        </Table>
+      </div>
+    </div>
+  )
+}
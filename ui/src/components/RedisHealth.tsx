@@ -0,0 +1,153 @@
+import { useRedisInfo } from "@/api/queries"
+import { RedisNodeInfo } from "@/api/types"
+import { Badge } from "@/components/ui/badge"
+import { Card, CardContent } from "@/components/ui/card"
+import {
+  Table,
+  TableBody,
+  TableCell,
+  TableHead,
+  TableHeader,
+  TableRow,
+} from "@/components/ui/table"
+import { cn, formatBytes, formatFullDate, formatNumber, formatRelativeTime } from "@/lib/utils"
+import { AlertTriangle, Server } from "lucide-react"
+
+// Fragmentation above this ratio usually means memory is being wasted.
+const highFragmentation = 1.5
+
+function Metric({ label, value, warning, title }: { label: string; value: string; warning?: boolean; title?: string }) {
+  return (
+    <div title={title}>
+      <p className="text-xs text-muted-foreground">{label}</p>
+      <p className={cn("font-semibold tabular-nums", warning && "text-warning")}>{value}</p>
+    </div>
+  )
+}
+
+function replicationSummary(node: RedisNodeInfo): { value: string; warning: boolean } {
+  const repl = node.replication
+  if (repl.role === "replica") {
+    const down = repl.master_link_status !== "up"
+    const lag = repl.lag_bytes !== undefined ? `${formatBytes(repl.lag_bytes)} behind` : "lag unknown"
+    return { value: down ? `link ${repl.master_link_status || "down"}` : lag, warning: down || (repl.lag_bytes ?? 0) > 0 }
+  }
+
+  const replicas = repl.replicas ?? []
+  if (replicas.length === 0) return { value: "No replicas", warning: false }
+
+  const maxLag = Math.max(...replicas.map((r) => r.lag_seconds))
+  const offline = replicas.filter((r) => r.state !== "online").length
+  return {
+    value: `${replicas.length} replica${replicas.length > 1 ? "s" : ""}, ${maxLag}s lag`,
+    warning: offline > 0 || maxLag > 10,
+  }
+}
+
+function NodeCard({ node }: { node: RedisNodeInfo }) {
+  if (node.error) {
+    return (
+      <Card>
+        <CardContent className="p-4 flex items-start gap-3 text-sm">
+          <AlertTriangle className="h-4 w-4 mt-0.5 text-warning shrink-0" />
+          <div>
+            <p className="font-medium">{node.addr || "Redis"} health is unavailable</p>
+            <p className="text-muted-foreground font-mono text-xs mt-1">{node.error}</p>
+          </div>
+        </CardContent>
+      </Card>
+    )
+  }
+
+  const memory = node.max_memory > 0
+    ? `${formatBytes(node.used_memory)} / ${formatBytes(node.max_memory)}`
+    : formatBytes(node.used_memory)
+  const memoryWarning = node.max_memory > 0 && node.used_memory / node.max_memory > 0.9
+  const replication = replicationSummary(node)
+
+  return (
+    <Card>
+      <CardContent className="p-4 space-y-4">
+        <div className="flex items-center gap-2">
+          <Server className="h-4 w-4 text-muted-foreground" />
+          <span className="font-mono text-sm">{node.addr || "Redis"}</span>
+          {node.version && <Badge variant="outline">v{node.version}</Badge>}
+          {node.replication.role && <Badge variant="secondary">{node.replication.role}</Badge>}
+        </div>
+
+        <div className="grid grid-cols-2 gap-4 sm:grid-cols-4">
+          <Metric
+            label="Memory"
+            value={memory}
+            warning={memoryWarning}
+            title={`Peak ${formatBytes(node.used_memory_peak)}`}
+          />
+          <Metric
+            label="Fragmentation"
+            value={node.fragmentation_ratio ? node.fragmentation_ratio.toFixed(2) : "—"}
+            warning={node.fragmentation_ratio > highFragmentation}
+          />
+          <Metric label="Max memory policy" value={node.max_memory_policy || "—"} />
+          <Metric label="Evicted keys" value={formatNumber(node.evicted_keys)} warning={node.evicted_keys > 0} />
+          <Metric
+            label="Clients"
+            value={formatNumber(node.connected_clients)}
+            title={`${node.blocked_clients} blocked`}
+          />
+          <Metric label="Ops/sec" value={formatNumber(node.ops_per_sec)} />
+          <Metric label="Replication" value={replication.value} warning={replication.warning} />
+          <Metric label="Uptime" value={`${Math.floor(node.uptime_seconds / 86400)}d ${Math.floor((node.uptime_seconds % 86400) / 3600)}h`} />
+        </div>
+
+        {node.slow_log_error ? (
+          <p className="text-xs text-muted-foreground font-mono">{node.slow_log_error}</p>
+        ) : node.slow_log.length > 0 && (
+          <div className="rounded-md border overflow-hidden">
+            <Table>
+              <TableHeader>
+                <TableRow className="bg-muted/50">
+                  <TableHead className="w-[45%]">Slow stream command</TableHead>
+                  <TableHead className="text-right w-[20%]">Duration</TableHead>
+                  <TableHead className="w-[15%] hidden sm:table-cell">Client</TableHead>
+                  <TableHead className="text-right w-[20%] pr-4">When</TableHead>
+                </TableRow>
+              </TableHeader>
+              <TableBody>
+                {node.slow_log.slice(0, 5).map((entry) => (
+                  <TableRow key={entry.id}>
+                    <TableCell className="font-mono text-xs truncate max-w-0" title={[entry.command, ...entry.args].join(" ")}>
+                      {entry.command} {entry.args.join(" ")}
+                      {!!entry.redacted_args && <span className="text-muted-foreground"> …</span>}
+                    </TableCell>
+                    <TableCell className="text-right tabular-nums">{(entry.duration_us / 1000).toFixed(1)} ms</TableCell>
+                    <TableCell className="font-mono text-xs text-muted-foreground hidden sm:table-cell">
+                      {entry.client_name || entry.client_addr || "—"}
+                    </TableCell>
+                    <TableCell className="text-right text-muted-foreground text-sm pr-6" title={formatFullDate(entry.time)}>
+                      {formatRelativeTime(entry.time)}
+                    </TableCell>
+                  </TableRow>
+                ))}
+              </TableBody>
+            </Table>
+          </div>
+        )}
+      </CardContent>
+    </Card>
+  )
+}
+
+export function RedisHealth() {
+  const { data: info } = useRedisInfo()
+
+  if (!info || info.nodes.length === 0) return null
+
+  return (
+    <div className="space-y-3">
+      <h2 className="text-lg font-semibold">Redis</h2>
+      {info.nodes.map((node) => (
+        <NodeCard key={node.addr || "redis"} node={node} />
+      ))}
+    </div>
+  )
+}
@@ -6,9 +6,13 @@ import {
     Search,
     Moon,
     Sun,
-    Inbox
+    Inbox,
+    Network,
+    Server
 } from "lucide-react";
 import { Button } from "@/components/ui/button";
+import { Badge } from "@/components/ui/badge";
+import { useCapabilities, useInstance, useInstances, useSelectInstance } from "@/api/queries";
 import { useEffect, useState } from "react";
 import { cn } from "@/lib/utils";
 
@@ -16,6 +20,7 @@ const navItems = [
     { to: "/", label: "Overview", icon: LayoutDashboard },
     { to: "/streams", label: "Streams", icon: Layers },
     { to: "/dlq", label: "Dead Letter Queue", icon: Inbox },
+    { to: "/topology", label: "Topology", icon: Network },
 ] as const;
 
 interface NavbarProps {
@@ -26,6 +31,18 @@ export function Navbar({ onCommandPaletteOpen }: NavbarProps) {
     const [isDark, setIsDark] = useState(true);
     const routerState = useRouterState();
     const currentPath = routerState.location.pathname;
+    const { data: capabilities } = useCapabilities();
+    const { data: instancesOverview } = useInstances();
+    const selectInstance = useSelectInstance();
+    const instances = instancesOverview?.instances ?? [];
+    const instance = useInstance();
+
+    // A remembered instance may have been removed from the server config.
+    useEffect(() => {
+        if (instance && instances.length > 0 && !instances.some((i) => i.name === instance)) {
+            selectInstance("");
+        }
+    }, [instance, instances, selectInstance]);
 
     useEffect(() => {
         // Check system preference on mount
@@ -91,6 +108,29 @@ export function Navbar({ onCommandPaletteOpen }: NavbarProps) {
                 </div>
 
                 <div className="flex items-center gap-2">
+                    {instances.length > 1 && (
+                        <label className="flex items-center gap-2 rounded-md border px-2 h-9 text-sm" title="Redis instance">
+                            <Server className="h-4 w-4 text-muted-foreground" />
+                            <select
+                                value={instance || instances[0].name}
+                                onChange={(e) => selectInstance(e.target.value)}
+                                className="bg-transparent font-medium focus:outline-none"
+                            >
+                                {instances.map((i) => (
+                                    <option key={i.name} value={i.name}>
+                                        {i.name}{i.error ? " (unavailable)" : ""}
+                                    </option>
+                                ))}
+                            </select>
+                        </label>
+                    )}
+
+                    {capabilities?.read_only && (
+                        <Badge variant="outline" className="hidden sm:inline-flex">
+                            Read-only
+                        </Badge>
+                    )}
+
                     {/* Search trigger */}
                     <Button
                         variant="outline"
@@ -5,6 +5,11 @@ import relativeTime from "dayjs/plugin/relativeTime"
 
 dayjs.extend(relativeTime)
 
+// The server injects the dashboard's mount point into index.html, so the
+// same build works under any prefix. It is empty when served at the root.
+export const basePath =
+  document.querySelector<HTMLMetaElement>('meta[name="windmill-base-path"]')?.content ?? ''
+
 export function cn(...inputs: ClassValue[]) {
   return twMerge(clsx(inputs))
 }
@@ -19,7 +24,8 @@ export function formatNumber(num: number): string {
   return num.toLocaleString()
 }
 
-export function formatBytes(bytes: number): string {
+export function formatBytes(bytes: number | null): string {
+  if (bytes === null) return "Unknown"
   if (bytes === 0) return "0 B"
   const k = 1024
   const sizes = ["B", "KB", "MB", "GB", "TB"]
@@ -1,4 +1,4 @@
-import { useDLQStats, useDLQMessages, useRequeueMessage, useRequeueAll, useDeleteDLQMessage } from "@/api/queries"
+import { useDLQStats, useDLQMessages, useRequeueMessage, useRequeueAll, useDeleteDLQMessage, useCapabilities, useReplay, usePurgeDLQ, useScheduledRequeues, useScheduleRequeue, useCancelRequeue } from "@/api/queries"
 import { useMinLoadingDuration } from "@/hooks/useMinLoadingDuration"
 import { StatsCard } from "@/components/StatsCard"
 import { EmptyState } from "@/components/EmptyState"
@@ -11,12 +11,15 @@ import {
   TableRow,
 } from "@/components/ui/table"
 import { formatNumber, formatTimestamp, formatRelativeTime, formatFullDate } from "@/lib/utils"
-import { AlertCircle, RefreshCw, Trash2, ChevronDown, ChevronRight, RotateCcw, Inbox, Clock } from "lucide-react"
+import { AlertCircle, RefreshCw, Download, Trash2, ChevronDown, ChevronRight, RotateCcw, Inbox, Clock, Upload } from "lucide-react"
 import { Button } from "@/components/ui/button"
 import { Badge } from "@/components/ui/badge"
-import { useState } from "react"
+import { useRef, useState } from "react"
 import { JsonViewer } from "@/components/JsonViewer"
 import { toast } from "sonner"
+import { exportUrls } from "@/api/client"
+import { Input } from "@/components/ui/input"
+import { PurgeRequest, PurgeResult } from "@/api/types"
 import {
   Dialog,
   DialogContent,
@@ -36,10 +39,26 @@ export function DLQ() {
   const requeueMutation = useRequeueMessage()
   const requeueAllMutation = useRequeueAll()
   const deleteMutation = useDeleteDLQMessage()
+  const replayMutation = useReplay()
+  const purgeMutation = usePurgeDLQ()
+  const scheduleMutation = useScheduleRequeue()
+  const cancelScheduleMutation = useCancelRequeue()
+  const { data: scheduledList } = useScheduledRequeues()
+  const scheduled = new Map((scheduledList?.messages ?? []).map((s) => [s.id, s]))
+
+  const { data: capabilities } = useCapabilities()
+  const canRequeue = capabilities?.can_requeue ?? false
+  const canDelete = capabilities?.can_delete ?? false
+  const canRequeueAll = capabilities?.can_requeue_all ?? false
+  const canPublish = capabilities?.can_publish ?? false
+  const canPurge = capabilities?.can_purge ?? false
+
+  const replayInput = useRef<HTMLInputElement>(null)
 
   const [expandedIds, setExpandedIds] = useState<Set<string>>(new Set())
   const [editingMsg, setEditingMsg] = useState<any>(null)
   const [editedPayload, setEditedPayload] = useState("")
+  const [requeueAt, setRequeueAt] = useState("")
 
   const handleRefresh = async () => {
     try {
@@ -92,18 +111,116 @@ export function DLQ() {
     }
   }
 
+  // Replays are checked with a dry run first so the operator sees how many
+  // records would fail before anything is written.
+  const handleReplay = async (file: File) => {
+    try {
+      const check = await replayMutation.mutateAsync({ file, dryRun: true })
+      const failures = check.failed > 0 ? ` ${check.failed} will be skipped (first error on line ${check.errors?.[0]?.line}).` : ''
+      if (!confirm(`Replay ${check.replayed} of ${check.total} messages to their original topics?${failures}`)) return
+
+      const res = await replayMutation.mutateAsync({ file })
+      if (res.failed > 0) {
+        toast.warning(`Replayed ${res.replayed} messages, ${res.failed} failed`)
+      } else {
+        toast.success(`Replayed ${res.replayed} messages`)
+      }
+    } catch (err: any) {
+      toast.error(err.message)
+    } finally {
+      if (replayInput.current) replayInput.current.value = ''
+    }
+  }
+
+  const [purging, setPurging] = useState(false)
+  const [purgeBefore, setPurgeBefore] = useState("")
+  const [purgeTopic, setPurgeTopic] = useState("")
+  const [purgeError, setPurgeError] = useState("")
+  const [purgeExport, setPurgeExport] = useState(true)
+  const [purgeConfirm, setPurgeConfirm] = useState("")
+  const [purgePreview, setPurgePreview] = useState<PurgeResult | null>(null)
+
+  const openPurgeModal = () => {
+    setPurgePreview(null)
+    setPurgeConfirm("")
+    setPurging(true)
+  }
+
+  const purgeRequest = (): PurgeRequest => ({
+    before: purgeBefore ? new Date(purgeBefore).toISOString() : undefined,
+    topic: purgeTopic || undefined,
+    error_contains: purgeError || undefined,
+  })
+
+  const handlePurgePreview = async () => {
+    try {
+      const res = await purgeMutation.mutateAsync({ req: { ...purgeRequest(), dry_run: true } })
+      setPurgePreview(res as PurgeResult)
+    } catch (err: any) {
+      toast.error(err.message)
+    }
+  }
+
+  const handlePurge = async () => {
+    try {
+      const res = await purgeMutation.mutateAsync({
+        req: { ...purgeRequest(), confirm: purgeConfirm },
+        exportFormat: purgeExport ? 'ndjson' : undefined,
+      })
+      if (res instanceof Blob) {
+        const url = URL.createObjectURL(res)
+        const link = document.createElement('a')
+        link.href = url
+        link.download = 'dlq-purge.ndjson'
+        link.click()
+        URL.revokeObjectURL(url)
+        toast.success('DLQ purged, export downloaded')
+      } else {
+        toast.success(`Deleted ${res.deleted} messages`)
+      }
+      setPurging(false)
+      handleRefresh()
+    } catch (err: any) {
+      toast.error(err.message)
+    }
+  }
+
   const openRequeueModal = (msg: any) => {
     setEditingMsg(msg)
     setEditedPayload(JSON.stringify(msg.payload, null, 2))
+    setRequeueAt("")
   }
 
   const saveAndRequeue = async () => {
+    let payload: any
     try {
-      const payload = JSON.parse(editedPayload)
+      payload = JSON.parse(editedPayload)
+    } catch (err: any) {
+      toast.error('Invalid JSON payload')
+      return
+    }
+
+    if (!requeueAt) {
       await handleRequeue(editingMsg.id, payload)
       setEditingMsg(null)
+      return
+    }
+
+    try {
+      const res = await scheduleMutation.mutateAsync({ id: editingMsg.id, at: new Date(requeueAt).toISOString(), payload })
+      toast.success(`Requeue scheduled for ${formatFullDate(res.due_at)}`)
+      setEditingMsg(null)
     } catch (err: any) {
-      toast.error('Invalid JSON payload')
+      toast.error(err.message)
+    }
+  }
+
+  const handleCancelSchedule = async (id: string) => {
+    try {
+      await cancelScheduleMutation.mutateAsync(id)
+      toast.success('Scheduled requeue cancelled')
+    } catch (err: any) {
+      toast.error(err.message)
     }
   }
 
@@ -129,16 +246,57 @@ export function DLQ() {
             <RefreshCw className={`h-4 w-4 ${isLoading ? 'animate-spin' : ''}`} />
             {isLoading ? 'Refreshing...' : 'Refresh'}
           </Button>
-          <Button
-            variant="default"
-            size="sm"
-            className="gap-2"
-            disabled={!hasMessages}
-            onClick={handleRequeueAll}
-          >
-            <RotateCcw className="h-4 w-4" />
-            Requeue All
+          <Button variant="outline" size="sm" className="gap-2" asChild>
+            <a href={exportUrls.dlq('ndjson')} download>
+              <Download className="h-4 w-4" />
+              Export
+            </a>
           </Button>
+          {canPublish && (
+            <>
+              <input
+                ref={replayInput}
+                type="file"
+                accept=".ndjson,application/x-ndjson"
+                className="hidden"
+                onChange={(e) => e.target.files?.[0] && handleReplay(e.target.files[0])}
+              />
+              <Button
+                variant="outline"
+                size="sm"
+                className="gap-2"
+                disabled={replayMutation.isPending}
+                onClick={() => replayInput.current?.click()}
+              >
+                <Upload className="h-4 w-4" />
+                Replay
+              </Button>
+            </>
+          )}
+          {canPurge && (
+            <Button
+              variant="outline"
+              size="sm"
+              className="gap-2"
+              disabled={!hasMessages}
+              onClick={openPurgeModal}
+            >
+              <Trash2 className="h-4 w-4 text-destructive" />
+              Purge
+            </Button>
+          )}
+          {canRequeueAll && (
+            <Button
+              variant="default"
+              size="sm"
+              className="gap-2"
+              disabled={!hasMessages}
+              onClick={handleRequeueAll}
+            >
+              <RotateCcw className="h-4 w-4" />
+              Requeue All
+            </Button>
+          )}
         </div>
       </div>
 
@@ -196,6 +354,17 @@ export function DLQ() {
                       </TableCell>
                       <TableCell>
                         <Badge variant="outline" className="font-mono">{msg.original_topic}</Badge>
+                        {msg.attempts > 0 && (
+                          <Badge variant="secondary" className="ml-2" title="Automatic retries so far">
+                            {msg.attempts} {msg.attempts === 1 ? 'retry' : 'retries'}
+                          </Badge>
+                        )}
+                        {scheduled.has(msg.id) && (
+                          <Badge variant="secondary" className="ml-2 gap-1" title={formatFullDate(scheduled.get(msg.id)!.due_at)}>
+                            <Clock className="h-3 w-3" />
+                            Requeues {formatRelativeTime(scheduled.get(msg.id)!.due_at)}
+                          </Badge>
+                        )}
                       </TableCell>
                       <TableCell
                         className="text-muted-foreground text-xs hidden sm:table-cell"
@@ -205,28 +374,32 @@ export function DLQ() {
                       </TableCell>
                       <TableCell className="text-center">
                         <div className="flex items-center justify-center gap-1">
-                          <Button
-                            variant="ghost"
-                            size="icon"
-                            className="h-8 w-8"
-                            onClick={(e: React.MouseEvent) => {
-                              e.stopPropagation()
-                              handleDelete(msg.id)
-                            }}
-                          >
-                            <Trash2 className="h-4 w-4 text-destructive" />
-                          </Button>
-                          <Button
-                            variant="ghost"
-                            size="icon"
-                            className="h-8 w-8"
-                            onClick={(e: React.MouseEvent) => {
-                              e.stopPropagation()
-                              handleRequeue(msg.id)
-                            }}
-                          >
-                            <RotateCcw className="h-4 w-4 text-primary" />
-                          </Button>
+                          {canDelete && (
+                            <Button
+                              variant="ghost"
+                              size="icon"
+                              className="h-8 w-8"
+                              onClick={(e: React.MouseEvent) => {
+                                e.stopPropagation()
+                                handleDelete(msg.id)
+                              }}
+                            >
+                              <Trash2 className="h-4 w-4 text-destructive" />
+                            </Button>
+                          )}
+                          {canRequeue && (
+                            <Button
+                              variant="ghost"
+                              size="icon"
+                              className="h-8 w-8"
+                              onClick={(e: React.MouseEvent) => {
+                                e.stopPropagation()
+                                handleRequeue(msg.id)
+                              }}
+                            >
+                              <RotateCcw className="h-4 w-4 text-primary" />
+                            </Button>
+                          )}
                         </div>
                       </TableCell>
                     </TableRow>
@@ -247,9 +420,11 @@ export function DLQ() {
                             <div className="space-y-2">
                               <div className="flex items-center justify-between">
                                 <span className="text-xs font-semibold text-muted-foreground uppercase tracking-wider">Payload Content</span>
-                                <Button variant="link" size="sm" className="h-auto p-0 text-xs" onClick={() => openRequeueModal(msg)}>
-                                  Edit and Requeue
-                                </Button>
+                                {canRequeue && (
+                                  <Button variant="link" size="sm" className="h-auto p-0 text-xs" onClick={() => openRequeueModal(msg)}>
+                                    Edit and Requeue
+                                  </Button>
+                                )}
                               </div>
                               <JsonViewer data={msg.payload} />
                             </div>
@@ -259,15 +434,26 @@ export function DLQ() {
                                 {msg.error}
                               </div>
                             </div>
-                            <div className="flex justify-end gap-2 pt-2">
-                              <Button variant="outline" size="sm" onClick={() => handleDelete(msg.id)}>
-                                Delete Message
-                              </Button>
-                              <Button size="sm" className="gap-2" onClick={() => handleRequeue(msg.id)}>
-                                <RotateCcw className="h-4 w-4" />
-                                Requeue Message
-                              </Button>
-                            </div>
+                            {(canDelete || canRequeue) && (
+                              <div className="flex justify-end gap-2 pt-2">
+                                {canRequeue && scheduled.has(msg.id) && (
+                                  <Button variant="outline" size="sm" onClick={() => handleCancelSchedule(msg.id)}>
+                                    Cancel Scheduled Requeue
+                                  </Button>
+                                )}
+                                {canDelete && (
+                                  <Button variant="outline" size="sm" onClick={() => handleDelete(msg.id)}>
+                                    Delete Message
+                                  </Button>
+                                )}
+                                {canRequeue && (
+                                  <Button size="sm" className="gap-2" onClick={() => handleRequeue(msg.id)}>
+                                    <RotateCcw className="h-4 w-4" />
+                                    Requeue Message
+                                  </Button>
+                                )}
+                              </div>
+                            )}
                           </div>
                         </TableCell>
                       </TableRow>
@@ -281,6 +467,64 @@ export function DLQ() {
       </div>
 
       {/* Requeue Modal */}
+      <Dialog open={purging} onOpenChange={setPurging}>
+        <DialogContent className="max-w-lg">
+          <DialogHeader>
+            <DialogTitle className="flex items-center gap-2">
+              <Trash2 className="h-5 w-5 text-destructive" />
+              Purge dead letter queue
+            </DialogTitle>
+            <DialogDescription>
+              Permanently delete the matching messages. Leave every filter empty to purge the whole DLQ.
+            </DialogDescription>
+          </DialogHeader>
+          <div className="space-y-4 py-4">
+            <div className="space-y-2">
+              <label className="text-xs font-semibold text-muted-foreground uppercase tracking-wider">Older Than</label>
+              <Input type="datetime-local" value={purgeBefore} onChange={(e) => { setPurgeBefore(e.target.value); setPurgePreview(null) }} />
+            </div>
+            <div className="space-y-2">
+              <label className="text-xs font-semibold text-muted-foreground uppercase tracking-wider">Original Topic</label>
+              <Input className="font-mono" placeholder="orders.created" value={purgeTopic} onChange={(e) => { setPurgeTopic(e.target.value); setPurgePreview(null) }} />
+            </div>
+            <div className="space-y-2">
+              <label className="text-xs font-semibold text-muted-foreground uppercase tracking-wider">Error Contains</label>
+              <Input placeholder="timeout" value={purgeError} onChange={(e) => { setPurgeError(e.target.value); setPurgePreview(null) }} />
+            </div>
+            <label className="flex items-center gap-2 text-sm text-muted-foreground">
+              <input type="checkbox" checked={purgeExport} onChange={(e) => setPurgeExport(e.target.checked)} />
+              Download an export of the deleted messages first
+            </label>
+            {purgePreview && (
+              <div className="space-y-2">
+                <p className="text-sm">
+                  This will delete <strong>{formatNumber(purgePreview.matched)}</strong> messages. Type <code className="font-mono text-destructive">{purgePreview.dlq}</code> to confirm.
+                </p>
+                <Input className="font-mono" value={purgeConfirm} onChange={(e) => setPurgeConfirm(e.target.value)} />
+              </div>
+            )}
+          </div>
+          <DialogFooter>
+            <Button variant="outline" onClick={() => setPurging(false)}>Cancel</Button>
+            {purgePreview === null ? (
+              <Button onClick={handlePurgePreview} disabled={purgeMutation.isPending}>
+                Preview
+              </Button>
+            ) : (
+              <Button
+                variant="destructive"
+                onClick={handlePurge}
+                disabled={purgePreview.matched === 0 || purgeConfirm !== purgePreview.dlq || purgeMutation.isPending}
+                className="gap-2"
+              >
+                <Trash2 className="h-4 w-4" />
+                Purge Messages
+              </Button>
+            )}
+          </DialogFooter>
+        </DialogContent>
+      </Dialog>
+
       <Dialog open={!!editingMsg} onOpenChange={(open) => !open && setEditingMsg(null)}>
         <DialogContent className="max-w-2xl">
           <DialogHeader>
@@ -305,12 +549,17 @@ export function DLQ() {
                 onChange={(e) => setEditedPayload(e.target.value)}
               />
             </div>
+            <div className="space-y-2">
+              <label className="text-xs font-semibold text-muted-foreground uppercase tracking-wider">Requeue At (Optional)</label>
+              <Input type="datetime-local" value={requeueAt} onChange={(e) => setRequeueAt(e.target.value)} />
+              <p className="text-xs text-muted-foreground">Leave empty to requeue now.</p>
+            </div>
           </div>
           <DialogFooter>
             <Button variant="outline" onClick={() => setEditingMsg(null)}>Cancel</Button>
-            <Button onClick={saveAndRequeue} className="gap-2">
-              <RotateCcw className="h-4 w-4" />
-              Requeue Message
+            <Button onClick={saveAndRequeue} className="gap-2" disabled={scheduleMutation.isPending}>
+              {requeueAt ? <Clock className="h-4 w-4" /> : <RotateCcw className="h-4 w-4" />}
+              {requeueAt ? 'Schedule Requeue' : 'Requeue Message'}
             </Button>
           </DialogFooter>
         </DialogContent>
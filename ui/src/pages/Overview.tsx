@@ -1,6 +1,8 @@
-import { useOverview, useStreams } from "@/api/queries"
+import { useConsumerHealth, useDiagnostics, useInstance, useInstances, useOverview, useRedisInfo, useSelectInstance, useStreams } from "@/api/queries"
 import { useMinLoadingDuration } from "@/hooks/useMinLoadingDuration"
 import { StatsCard } from "@/components/StatsCard"
+import { RedisHealth } from "@/components/RedisHealth"
+import { ConsumerHealth } from "@/components/ConsumerHealth"
 import { EmptyState } from "@/components/EmptyState"
 import {
   Table,
@@ -11,7 +13,7 @@ import {
   TableRow,
 } from "@/components/ui/table"
 import { formatBytes, formatNumber, formatRelativeTime, formatFullDate } from "@/lib/utils"
-import { Activity, Layers, RefreshCw, Inbox } from "lucide-react"
+import { Activity, AlertTriangle, Layers, RefreshCw, Inbox } from "lucide-react"
 import { useNavigate } from "@tanstack/react-router"
 import { Link } from "@tanstack/react-router"
 import { Button } from "@/components/ui/button"
@@ -19,7 +21,16 @@ import { toast } from "sonner"
 
 export function Overview() {
   const { data: overview, isLoading: overviewLoading, refetch: refetchOverview, isFetching: overviewFetching } = useOverview()
-  const { data: streams, isLoading: streamsLoading, refetch: refetchStreams, isFetching: streamsFetching } = useStreams()
+  const { refetch: refetchRedisInfo } = useRedisInfo()
+  const { refetch: refetchConsumerHealth } = useConsumerHealth()
+  const { data: streamList, isLoading: streamsLoading, refetch: refetchStreams, isFetching: streamsFetching } = useStreams({ sort: 'last_activity', order: 'desc', limit: 5 })
+  const { data: diagnostics } = useDiagnostics()
+  const { data: instancesOverview } = useInstances()
+  const selectInstance = useSelectInstance()
+  const instances = instancesOverview?.instances ?? []
+  const currentInstance = useInstance() || instances[0]?.name
+  const unavailable = Object.keys(diagnostics?.redis.unavailable ?? {})
+  const streams = streamList?.streams
   const navigate = useNavigate()
 
   const isRefreshing = (overviewFetching && !overviewLoading) || (streamsFetching && !streamsLoading)
@@ -30,7 +41,7 @@ export function Overview() {
 
   const handleRefresh = async () => {
     try {
-      await Promise.all([refetchOverview(), refetchStreams()])
+      await Promise.all([refetchOverview(), refetchStreams(), refetchRedisInfo(), refetchConsumerHealth()])
       toast.success("Dashboard refreshed")
     } catch (error) {
       toast.error("Failed to refresh dashboard")
@@ -66,6 +77,19 @@ export function Overview() {
         </Button>
       </div>
 
+      {/* Redis limitations */}
+      {unavailable.length > 0 && (
+        <div className="flex items-start gap-3 rounded-lg border border-warning/50 bg-warning/10 p-4 text-sm">
+          <AlertTriangle className="h-4 w-4 mt-0.5 text-warning shrink-0" />
+          <div>
+            <p className="font-medium">Some Redis commands are unavailable</p>
+            <p className="text-muted-foreground mt-1">
+              Redis refused {unavailable.join(', ')}. Windmill falls back to other commands, so some metrics may be missing or slower to load.
+            </p>
+          </div>
+        </div>
+      )}
+
       {/* Stats Grid */}
       <div className="grid gap-4 sm:grid-cols-2 lg:grid-cols-3">
         <StatsCard
@@ -88,6 +112,51 @@ export function Overview() {
         />
       </div>
 
+      {/* Instances */}
+      {instances.length > 1 && (
+        <div className="space-y-3">
+          <div className="flex items-center justify-between">
+            <h2 className="text-lg font-semibold">Instances</h2>
+            <span className="text-sm text-muted-foreground">
+              {formatNumber(instancesOverview?.total.total_messages || 0)} messages, {formatNumber(instancesOverview?.total.total_dlq_messages || 0)} in DLQs
+            </span>
+          </div>
+          <div className="rounded-lg border overflow-hidden">
+            <Table>
+              <TableHeader>
+                <TableRow className="bg-muted/50">
+                  <TableHead className="w-[8%] text-center">Status</TableHead>
+                  <TableHead className="w-[30%]">Instance</TableHead>
+                  <TableHead className="text-right w-[17%]">Streams</TableHead>
+                  <TableHead className="text-right w-[20%]">Messages</TableHead>
+                  <TableHead className="text-right w-[25%] pr-4">DLQ</TableHead>
+                </TableRow>
+              </TableHeader>
+              <TableBody>
+                {instances.map((instance) => (
+                  <TableRow
+                    key={instance.name}
+                    className={`cursor-pointer hover:bg-muted/50 transition-colors ${instance.name === currentInstance ? 'bg-primary/5' : ''}`}
+                    onClick={() => selectInstance(instance.name)}
+                  >
+                    <TableCell className="text-center" title={instance.error}>
+                      <div className={`h-2 w-2 rounded-full mx-auto ${instance.error ? 'bg-error' : instance.overview?.total_dlq_messages ? 'bg-warning' : 'bg-success'}`} />
+                    </TableCell>
+                    <TableCell className="font-medium">
+                      {instance.name}
+                      <span className="ml-2 font-mono text-xs text-muted-foreground">{instance.dlq}</span>
+                    </TableCell>
+                    <TableCell className="text-right tabular-nums">{instance.overview ? formatNumber(instance.overview.total_streams) : '—'}</TableCell>
+                    <TableCell className="text-right tabular-nums">{instance.overview ? formatNumber(instance.overview.total_messages) : '—'}</TableCell>
+                    <TableCell className="text-right tabular-nums pr-6">{instance.overview ? formatNumber(instance.overview.total_dlq_messages) : '—'}</TableCell>
+                  </TableRow>
+                ))}
+              </TableBody>
+            </Table>
+          </div>
+        </div>
+      )}
+
       {/* Streams Table */}
       <div className="space-y-3">
         <div className="flex items-center justify-between">
@@ -116,14 +185,14 @@ export function Overview() {
                 </TableRow>
               </TableHeader>
               <TableBody>
-                {streams?.slice(0, 5).map((stream: any) => (
+                {streams?.map((stream: any) => (
                   <TableRow
                     key={stream.name}
                     className="group cursor-pointer hover:bg-muted/50 transition-colors"
                     onClick={() => navigate({ to: '/streams/$name', params: { name: stream.name } })}
                   >
-                    <TableCell className="text-center">
-                      <div className="h-2 w-2 rounded-full bg-success mx-auto" />
+                    <TableCell className="text-center" title={stream.error}>
+                      <div className={`h-2 w-2 rounded-full mx-auto ${stream.error ? 'bg-warning' : 'bg-success'}`} />
                     </TableCell>
                     <TableCell className="font-mono text-sm">{stream.name}</TableCell>
                     <TableCell className="text-right tabular-nums">{formatNumber(stream.length)}</TableCell>
@@ -145,6 +214,12 @@ export function Overview() {
           </div>
         )}
       </div>
+
+      {/* Consumer group lag and stuck consumers */}
+      <ConsumerHealth />
+
+      {/* Redis server health */}
+      <RedisHealth />
     </div>
   )
 }
@@ -1,7 +1,8 @@
 import { useParams } from "@tanstack/react-router"
-import { useStream, useStreamMessages, useDeleteStreamMessage } from "@/api/queries"
+import { useStream, useStreamMessages, useDeleteStreamMessage, usePublishMessage, useTrimStream, useCapabilities } from "@/api/queries"
 import { StatsCard } from "@/components/StatsCard"
 import { EmptyState } from "@/components/EmptyState"
+import { ConsumerGroups } from "@/components/ConsumerGroups"
 import {
   Table,
   TableBody,
@@ -11,13 +12,24 @@ import {
   TableRow,
 } from "@/components/ui/table"
 import { formatBytes, formatNumber, formatTimestamp } from "@/lib/utils"
-import { Database, Layers, ArrowLeft, RefreshCw, Trash2, ChevronDown, ChevronRight, Hash, Clock, Inbox } from "lucide-react"
+import { Database, Layers, ArrowLeft, RefreshCw, Download, Trash2, ChevronDown, ChevronRight, Hash, Clock, Inbox, Send, Copy, Scissors } from "lucide-react"
 import { Link } from "@tanstack/react-router"
 import { Button } from "@/components/ui/button"
 import { Badge } from "@/components/ui/badge"
 import { useState } from "react"
 import { JsonViewer } from "@/components/JsonViewer"
 import { toast } from "sonner"
+import { exportUrls } from "@/api/client"
+import { Input } from "@/components/ui/input"
+import { TrimRequest, TrimStrategy } from "@/api/types"
+import {
+  Dialog,
+  DialogContent,
+  DialogHeader,
+  DialogTitle,
+  DialogFooter,
+  DialogDescription,
+} from "@/components/ui/dialog"
 
 export function StreamDetail() {
   const { name } = useParams({ from: '/streams/$name' })
@@ -25,7 +37,21 @@ export function StreamDetail() {
   const [opts, setOpts] = useState({ limit: 50, order: 'desc' as const })
   const { data: messageList, isLoading: messagesLoading, refetch: refetchMessages } = useStreamMessages(name, opts)
   const deleteMutation = useDeleteStreamMessage()
+  const publishMutation = usePublishMessage()
+  const trimMutation = useTrimStream()
+  const { data: capabilities } = useCapabilities()
+  const canDelete = capabilities?.can_delete ?? false
+  const canPublish = capabilities?.can_publish ?? false
   const [expandedIds, setExpandedIds] = useState<Set<string>>(new Set())
+  const [composing, setComposing] = useState<{ source?: string } | null>(null)
+  const [draftPayload, setDraftPayload] = useState("")
+  const [draftMetadata, setDraftMetadata] = useState("")
+  const [trimming, setTrimming] = useState(false)
+  const [trimStrategy, setTrimStrategy] = useState<TrimStrategy>('maxlen')
+  const [trimValue, setTrimValue] = useState("")
+  const [trimApprox, setTrimApprox] = useState(false)
+  const [trimPreview, setTrimPreview] = useState<number | null>(null)
+  const [trimEstimated, setTrimEstimated] = useState(false)
 
   const handleRefresh = () => {
     refetchStream()
@@ -53,6 +79,62 @@ export function StreamDetail() {
     }
   }
 
+  // Opens the publish dialog, prefilled from msg when cloning an existing
+  // message.
+  const openComposer = (msg?: any) => {
+    setComposing({ source: msg?.id })
+    setDraftPayload(JSON.stringify(msg?.payload ?? {}, null, 2))
+    setDraftMetadata(JSON.stringify(msg?.metadata ?? {}, null, 2))
+  }
+
+  const handlePublish = async () => {
+    let payload: any
+    let metadata: Record<string, string>
+    try {
+      payload = JSON.parse(draftPayload)
+      metadata = JSON.parse(draftMetadata || '{}')
+    } catch {
+      toast.error('Invalid JSON')
+      return
+    }
+
+    try {
+      const msg = await publishMutation.mutateAsync({ name, payload, metadata })
+      toast.success(`Published ${msg.id}`)
+      setComposing(null)
+    } catch (err: any) {
+      toast.error(err.message)
+    }
+  }
+
+  const trimRequest = (dryRun: boolean): TrimRequest => ({
+    strategy: trimStrategy,
+    ...(trimStrategy === 'maxlen' ? { max_len: Number(trimValue) } : { min_id: trimValue }),
+    approximate: trimApprox,
+    dry_run: dryRun,
+  })
+
+  const handleTrimPreview = async () => {
+    try {
+      const res = await trimMutation.mutateAsync({ name, req: trimRequest(true) })
+      setTrimPreview(res.removed)
+      setTrimEstimated(!!res.estimated)
+    } catch (err: any) {
+      setTrimPreview(null)
+      toast.error(err.message)
+    }
+  }
+
+  const handleTrim = async () => {
+    try {
+      const res = await trimMutation.mutateAsync({ name, req: trimRequest(false) })
+      toast.success(`Removed ${res.removed} entries`)
+      setTrimming(false)
+    } catch (err: any) {
+      toast.error(err.message)
+    }
+  }
+
   if (streamLoading) {
     return (
       <div className="flex items-center justify-center h-64 text-muted-foreground">
@@ -87,17 +169,55 @@ export function StreamDetail() {
           <div>
             <div className="flex items-center gap-2 flex-wrap">
               <h1 className="text-2xl font-bold tracking-tight font-mono">{stream.name}</h1>
-              <Badge variant="success">ACTIVE</Badge>
+              {stream.error ? (
+                <Badge variant="warning" title={stream.error}>DEGRADED</Badge>
+              ) : (
+                <Badge variant="success">ACTIVE</Badge>
+              )}
             </div>
             <p className="text-muted-foreground text-sm mt-1">
               Stream activity and message history
+              {stream.node && (
+                <span className="font-mono"> · {stream.node}{stream.slot !== undefined && ` · slot ${stream.slot}`}</span>
+              )}
             </p>
+            {stream.error && (
+              <p className="text-warning text-xs mt-1 font-mono">{stream.error}</p>
+            )}
           </div>
         </div>
-        <Button variant="outline" size="sm" onClick={handleRefresh} className="gap-2 w-fit">
-          <RefreshCw className="h-4 w-4" />
-          Refresh
-        </Button>
+        <div className="flex items-center gap-2">
+          {canPublish && (
+            <Button variant="default" size="sm" onClick={() => openComposer()} className="gap-2 w-fit">
+              <Send className="h-4 w-4" />
+              Publish
+            </Button>
+          )}
+          {canDelete && (
+            <Button
+              variant="outline"
+              size="sm"
+              onClick={() => {
+                setTrimPreview(null)
+                setTrimming(true)
+              }}
+              className="gap-2 w-fit"
+            >
+              <Scissors className="h-4 w-4" />
+              Trim
+            </Button>
+          )}
+          <Button variant="outline" size="sm" className="gap-2 w-fit" asChild>
+            <a href={exportUrls.stream(name, 'ndjson')} download>
+              <Download className="h-4 w-4" />
+              Export
+            </a>
+          </Button>
+          <Button variant="outline" size="sm" onClick={handleRefresh} className="gap-2 w-fit">
+            <RefreshCw className="h-4 w-4" />
+            Refresh
+          </Button>
+        </div>
       </div>
 
       {/* Stats Grid */}
@@ -124,6 +244,8 @@ export function StreamDetail() {
         />
       </div>
 
+      {stream.full && <ConsumerGroups full={stream.full} />}
+
       {/* Messages Table */}
       <div className="space-y-4">
         <div className="flex items-center justify-between">
@@ -175,18 +297,34 @@ export function StreamDetail() {
                       <TableCell className="max-w-xs truncate font-mono text-xs text-muted-foreground hidden md:table-cell">
                         {JSON.stringify(msg.payload)}
                       </TableCell>
-                      <TableCell className="text-right">
-                        <Button
-                          variant="ghost"
-                          size="icon"
-                          className="h-8 w-8"
-                          onClick={(e: React.MouseEvent) => {
-                            e.stopPropagation()
-                            handleDelete(msg.id)
-                          }}
-                        >
-                          <Trash2 className="h-4 w-4 text-destructive" />
-                        </Button>
+                      <TableCell className="text-right whitespace-nowrap">
+                        {canPublish && (
+                          <Button
+                            variant="ghost"
+                            size="icon"
+                            className="h-8 w-8"
+                            title="Clone and edit"
+                            onClick={(e: React.MouseEvent) => {
+                              e.stopPropagation()
+                              openComposer(msg)
+                            }}
+                          >
+                            <Copy className="h-4 w-4" />
+                          </Button>
+                        )}
+                        {canDelete && (
+                          <Button
+                            variant="ghost"
+                            size="icon"
+                            className="h-8 w-8"
+                            onClick={(e: React.MouseEvent) => {
+                              e.stopPropagation()
+                              handleDelete(msg.id)
+                            }}
+                          >
+                            <Trash2 className="h-4 w-4 text-destructive" />
+                          </Button>
+                        )}
                       </TableCell>
                     </TableRow>
                     {expandedIds.has(msg.id) && (
@@ -207,6 +345,12 @@ export function StreamDetail() {
                               <span className="text-xs font-semibold text-muted-foreground uppercase tracking-wider">Payload Content</span>
                               <JsonViewer data={msg.payload} />
                             </div>
+                            {msg.metadata && Object.keys(msg.metadata).length > 0 && (
+                              <div className="space-y-1">
+                                <span className="text-xs font-semibold text-muted-foreground uppercase tracking-wider">Metadata</span>
+                                <JsonViewer data={msg.metadata} />
+                              </div>
+                            )}
                           </div>
                         </TableCell>
                       </TableRow>
@@ -229,6 +373,121 @@ export function StreamDetail() {
           </div>
         )}
       </div>
+
+      <Dialog open={trimming} onOpenChange={setTrimming}>
+        <DialogContent className="max-w-lg">
+          <DialogHeader>
+            <DialogTitle className="flex items-center gap-2">
+              <Scissors className="h-5 w-5 text-primary" />
+              Trim stream
+            </DialogTitle>
+            <DialogDescription>
+              Permanently remove the oldest entries of <code className="text-primary font-mono">{name}</code>. Entries still pending or undelivered in a consumer group are never removed.
+            </DialogDescription>
+          </DialogHeader>
+          <div className="space-y-4 py-4">
+            <div className="flex gap-2">
+              <Button
+                variant={trimStrategy === 'maxlen' ? 'default' : 'outline'}
+                size="sm"
+                onClick={() => {
+                  setTrimStrategy('maxlen')
+                  setTrimPreview(null)
+                }}
+              >
+                Keep newest N
+              </Button>
+              <Button
+                variant={trimStrategy === 'minid' ? 'default' : 'outline'}
+                size="sm"
+                onClick={() => {
+                  setTrimStrategy('minid')
+                  setTrimPreview(null)
+                }}
+              >
+                Older than ID
+              </Button>
+            </div>
+            <Input
+              className="font-mono"
+              type={trimStrategy === 'maxlen' ? 'number' : 'text'}
+              placeholder={trimStrategy === 'maxlen' ? '10000' : stream.first_entry_id || '1704067200000-0'}
+              value={trimValue}
+              onChange={(e) => {
+                setTrimValue(e.target.value)
+                setTrimPreview(null)
+              }}
+            />
+            <label className="flex items-center gap-2 text-sm text-muted-foreground">
+              <input type="checkbox" checked={trimApprox} onChange={(e) => setTrimApprox(e.target.checked)} />
+              Approximate (cheaper, may remove fewer entries)
+            </label>
+            {trimPreview !== null && (
+              <p className="text-sm">
+                This will remove {trimApprox ? 'up to ' : trimEstimated ? 'about ' : ''}<strong>{formatNumber(trimPreview)}</strong> entries.
+              </p>
+            )}
+          </div>
+          <DialogFooter>
+            <Button variant="outline" onClick={() => setTrimming(false)}>Cancel</Button>
+            {trimPreview === null ? (
+              <Button onClick={handleTrimPreview} disabled={!trimValue || trimMutation.isPending}>
+                Preview
+              </Button>
+            ) : (
+              <Button variant="destructive" onClick={handleTrim} disabled={trimPreview === 0 || trimMutation.isPending} className="gap-2">
+                <Scissors className="h-4 w-4" />
+                Trim Stream
+              </Button>
+            )}
+          </DialogFooter>
+        </DialogContent>
+      </Dialog>
+
+      <Dialog open={!!composing} onOpenChange={(open) => !open && setComposing(null)}>
+        <DialogContent className="max-w-2xl">
+          <DialogHeader>
+            <DialogTitle className="flex items-center gap-2">
+              <Send className="h-5 w-5 text-primary" />
+              {composing?.source ? 'Clone message' : 'Publish message'}
+            </DialogTitle>
+            <DialogDescription>
+              This will publish a new message with a fresh UUID to <code className="text-primary font-mono">{name}</code>.
+            </DialogDescription>
+          </DialogHeader>
+          <div className="space-y-4 py-4">
+            {composing?.source && (
+              <div className="space-y-2">
+                <label className="text-xs font-semibold text-muted-foreground uppercase tracking-wider">Cloned From</label>
+                <div className="font-mono bg-muted p-2.5 rounded-md text-sm">{composing.source}</div>
+              </div>
+            )}
+            <div className="space-y-2">
+              <label className="text-xs font-semibold text-muted-foreground uppercase tracking-wider">Payload (JSON)</label>
+              <textarea
+                className="w-full min-h-[200px] bg-muted/50 border rounded-md p-4 font-mono text-sm focus:outline-none focus:ring-2 focus:ring-primary resize-y"
+                value={draftPayload}
+                onChange={(e) => setDraftPayload(e.target.value)}
+              />
+            </div>
+            <div className="space-y-2">
+              <label className="text-xs font-semibold text-muted-foreground uppercase tracking-wider">Metadata (JSON, optional)</label>
+              <textarea
+                className="w-full min-h-[100px] bg-muted/50 border rounded-md p-4 font-mono text-sm focus:outline-none focus:ring-2 focus:ring-primary resize-y"
+                value={draftMetadata}
+                onChange={(e) => setDraftMetadata(e.target.value)}
+              />
+            </div>
+          </div>
+          <DialogFooter>
+            <Button variant="outline" onClick={() => setComposing(null)}>Cancel</Button>
+            <Button onClick={handlePublish} disabled={publishMutation.isPending} className="gap-2">
+              <Send className="h-4 w-4" />
+              Publish Message
+            </Button>
+          </DialogFooter>
+        </DialogContent>
+      </Dialog>
     </div>
   )
 }
@@ -1,4 +1,4 @@
-import { useStreams } from "@/api/queries"
+import { useOverview, useStreams } from "@/api/queries"
 import { useMinLoadingDuration } from "@/hooks/useMinLoadingDuration"
 import { StatsCard } from "@/components/StatsCard"
 import { EmptyState } from "@/components/EmptyState"
@@ -11,17 +11,28 @@ import {
   TableRow,
 } from "@/components/ui/table"
 import { formatBytes, formatNumber, formatRelativeTime, formatFullDate } from "@/lib/utils"
-import { Database, Layers, Search, RefreshCw, Activity, Inbox } from "lucide-react"
+import { Database, Layers, Search, RefreshCw, Activity, Inbox, ArrowUp, ArrowDown, AlertTriangle } from "lucide-react"
 import { useNavigate } from "@tanstack/react-router"
 import { Button } from "@/components/ui/button"
 import { Input } from "@/components/ui/input"
 import { useState } from "react"
+import { SortOrder, StreamSort } from "@/api/types"
 import { toast } from "sonner"
 import Avatar from "boring-avatars"
 
+const pageSize = 50
+
 export function Streams() {
-  const { data: streams, isLoading: rawLoading, refetch, isFetching } = useStreams()
   const [search, setSearch] = useState("")
+  const [sort, setSort] = useState<StreamSort>('name')
+  const [order, setOrder] = useState<SortOrder>('asc')
+  // Cursors of the pages before the current one, to go back.
+  const [cursors, setCursors] = useState<string[]>([])
+  const cursor = cursors[cursors.length - 1]
+
+  const { data: streamList, isLoading: rawLoading, refetch, isFetching } = useStreams({ q: search, sort, order, cursor, limit: pageSize })
+  const { data: overview } = useOverview()
+  const streams = streamList?.streams
   const navigate = useNavigate()
 
   // Include isFetching so loading state shows when returning to tab with stale data
@@ -37,8 +48,28 @@ export function Streams() {
     }
   }
 
-  const filteredStreams = streams?.filter((s: any) =>
-    s.name.toLowerCase().includes(search.toLowerCase())
+  const handleSearch = (value: string) => {
+    setSearch(value)
+    setCursors([])
+  }
+
+  // Clicking the current sort column flips the order; another column
+  // starts descending, except the name.
+  const handleSort = (column: StreamSort) => {
+    if (column === sort) {
+      setOrder(order === 'asc' ? 'desc' : 'asc')
+    } else {
+      setSort(column)
+      setOrder(column === 'name' ? 'asc' : 'desc')
+    }
+    setCursors([])
+  }
+
+  const sortHeader = (column: StreamSort, label: string) => (
+    <button className="inline-flex items-center gap-1 hover:text-foreground" onClick={() => handleSort(column)}>
+      {label}
+      {sort === column && (order === 'asc' ? <ArrowUp className="h-3 w-3" /> : <ArrowDown className="h-3 w-3" />)}
+    </button>
   )
 
   return (
@@ -48,7 +79,7 @@ export function Streams() {
         <div>
           <h1 className="text-2xl font-bold tracking-tight">Redis Streams</h1>
           <p className="text-muted-foreground text-sm mt-1">
-            Monitoring {streams?.length || 0} active ingestion points
+            Monitoring {overview?.total_streams || 0} active ingestion points
           </p>
         </div>
         <Button variant="outline" size="sm" onClick={handleRefresh} disabled={isLoading} className="gap-2 w-fit">
@@ -61,12 +92,12 @@ export function Streams() {
       <div className="grid gap-4 sm:grid-cols-2">
         <StatsCard
           title="Total Streams"
-          value={streams?.length || 0}
+          value={overview?.total_streams || 0}
           icon={Layers}
         />
         <StatsCard
           title="Total Messages"
-          value={formatNumber(streams?.reduce((acc: number, s: any) => acc + s.length, 0) || 0)}
+          value={formatNumber(overview?.total_messages || 0)}
           icon={Activity}
         />
       </div>
@@ -79,31 +110,31 @@ export function Streams() {
             placeholder="Filter streams by name..."
             className="pl-9"
             value={search}
-            onChange={(e) => setSearch(e.target.value)}
+            onChange={(e) => handleSearch(e.target.value)}
           />
         </div>
 
-        {(!filteredStreams || filteredStreams.length === 0) ? (
+        {(!streams || streams.length === 0) ? (
           <EmptyState
             icon={Inbox}
             title={search ? "No streams match your search" : "No streams found"}
             description={search ? "Try adjusting your search query." : "No active Redis streams are being monitored."}
-            action={search ? { label: "Clear search", onClick: () => setSearch("") } : undefined}
+            action={search ? { label: "Clear search", onClick: () => handleSearch("") } : undefined}
           />
         ) : (
           <div className="rounded-lg border overflow-hidden">
             <Table>
               <TableHeader>
                 <TableRow className="bg-muted/50">
-                  <TableHead className="w-[35%]">Stream Name</TableHead>
-                  <TableHead className="text-right w-[15%]">Messages</TableHead>
-                  <TableHead className="text-right w-[15%] hidden sm:table-cell">Memory</TableHead>
-                  <TableHead className="text-right w-[15%] hidden md:table-cell">Last Activity</TableHead>
+                  <TableHead className="w-[35%]">{sortHeader('name', 'Stream Name')}</TableHead>
+                  <TableHead className="text-right w-[15%]">{sortHeader('length', 'Messages')}</TableHead>
+                  <TableHead className="text-right w-[15%] hidden sm:table-cell">{sortHeader('memory', 'Memory')}</TableHead>
+                  <TableHead className="text-right w-[15%] hidden md:table-cell">{sortHeader('last_activity', 'Last Activity')}</TableHead>
                   <TableHead className="text-right w-[20%] hidden lg:table-cell pr-6">Last Entry ID</TableHead>
                 </TableRow>
               </TableHeader>
               <TableBody>
-                {filteredStreams?.map((stream: any) => (
+                {streams?.map((stream: any) => (
                   <TableRow
                     key={stream.name}
                     className="group cursor-pointer hover:bg-muted/50 transition-colors"
@@ -117,7 +148,21 @@ export function Streams() {
                           variant="beam"
                           colors={["#14b8a6", "#06b6d4", "#8b5cf6", "#ec4899", "#f97316"]}
                         />
-                        {stream.name}
+                        <div>
+                          <div className="flex items-center gap-2">
+                            {stream.name}
+                            {stream.error && (
+                              <span title={stream.error}>
+                                <AlertTriangle className="h-4 w-4 text-warning" />
+                              </span>
+                            )}
+                          </div>
+                          {stream.node && (
+                            <div className="text-xs text-muted-foreground">
+                              {stream.node}{stream.slot !== undefined && ` · slot ${stream.slot}`}
+                            </div>
+                          )}
+                        </div>
                       </div>
                     </TableCell>
                     <TableCell className="text-right tabular-nums">{formatNumber(stream.length)}</TableCell>
@@ -141,6 +186,27 @@ export function Streams() {
             </Table>
           </div>
         )}
+
+        {streamList && (cursors.length > 0 || streamList.has_more) && (
+          <div className="flex items-center justify-between text-sm text-muted-foreground">
+            <span>
+              {formatNumber(streamList.total_count)} streams, updated {formatRelativeTime(streamList.refreshed_at)}
+            </span>
+            <div className="flex gap-2">
+              <Button variant="outline" size="sm" disabled={cursors.length === 0} onClick={() => setCursors(cursors.slice(0, -1))}>
+                Previous
+              </Button>
+              <Button
+                variant="outline"
+                size="sm"
+                disabled={!streamList.has_more}
+                onClick={() => streamList.next_cursor && setCursors([...cursors, streamList.next_cursor])}
+              >
+                Next
+              </Button>
+            </div>
+          </div>
+        )}
       </div>
     </div>
   )
@@ -0,0 +1,208 @@
+import { useTopology } from "@/api/queries"
+import { useMinLoadingDuration } from "@/hooks/useMinLoadingDuration"
+import { EmptyState } from "@/components/EmptyState"
+import {
+  Table,
+  TableBody,
+  TableCell,
+  TableHead,
+  TableHeader,
+  TableRow,
+} from "@/components/ui/table"
+import { Topology as TopologyData, TopologyEdge, TopologyNode } from "@/api/types"
+import { formatNumber } from "@/lib/utils"
+import { AlertTriangle, Network, RefreshCw } from "lucide-react"
+import { Button } from "@/components/ui/button"
+import { Badge } from "@/components/ui/badge"
+import { useNavigate } from "@tanstack/react-router"
+import { toast } from "sonner"
+
+interface Linked {
+  node: TopologyNode
+  inferred: boolean
+}
+
+function outgoing(topology: TopologyData, from: string, kind: TopologyEdge['kind']) {
+  const nodes = new Map(topology.nodes.map((n) => [n.id, n]))
+  return topology.edges
+    .filter((e) => e.from === from && e.kind === kind)
+    .flatMap((e) => {
+      const node = nodes.get(e.to)
+      return node ? [{ node, inferred: !!e.inferred }] : []
+    })
+}
+
+// handlersOf returns the handlers fed by a group, directly or through its
+// consumers, without duplicates.
+function handlersOf(topology: TopologyData, groupID: string) {
+  const linked = [
+    ...outgoing(topology, groupID, 'consumes'),
+    ...outgoing(topology, groupID, 'consumes').flatMap((c) => outgoing(topology, c.node.id, 'consumes')),
+  ].filter((l) => l.node.kind === 'handler')
+  return linked.filter((l, i) => linked.findIndex((o) => o.node.id === l.node.id) === i)
+}
+
+function HandlerBadges({ topology, handlers }: { topology: TopologyData, handlers: Linked[] }) {
+  if (handlers.length === 0) return <span className="text-muted-foreground">—</span>
+  return (
+    <div className="flex flex-wrap gap-1.5">
+      {handlers.map(({ node, inferred }) => {
+        const publishes = outgoing(topology, node.id, 'publishes')
+        return (
+          <span
+            key={node.id}
+            className="inline-flex items-center gap-1"
+            title={inferred ? "Linked from its name or its poisoned messages" : undefined}
+          >
+            <Badge variant={node.registered ? "secondary" : "outline"} className={inferred ? "border-dashed" : undefined}>
+              {node.name}
+            </Badge>
+            {!!node.poisoned && (
+              <Badge variant="warning">{formatNumber(node.poisoned)} poisoned</Badge>
+            )}
+            {publishes.length > 0 && (
+              <span className="text-xs text-muted-foreground">
+                → {publishes.map((p) => p.node.name).join(", ")}
+              </span>
+            )}
+          </span>
+        )
+      })}
+    </div>
+  )
+}
+
+export function Topology() {
+  const { data: topology, isLoading: rawLoading, refetch, isFetching } = useTopology()
+  const navigate = useNavigate()
+
+  const isLoading = useMinLoadingDuration(rawLoading || isFetching)
+
+  const handleRefresh = async () => {
+    try {
+      await refetch()
+      toast.success("Topology updated")
+    } catch (error) {
+      toast.error("Failed to refresh topology")
+    }
+  }
+
+  const topics = topology?.nodes.filter((n) => n.kind === 'topic') ?? []
+  const handlers = topology?.nodes.filter((n) => n.kind === 'handler') ?? []
+  // Handlers without a known topic yet: registered before handling a message.
+  const unlinked = handlers.filter((h) => !topology?.edges.some((e) => e.to === h.id))
+
+  return (
+    <div className="space-y-8">
+      {/* Header */}
+      <div className="flex flex-col gap-4 sm:flex-row sm:items-center sm:justify-between">
+        <div>
+          <h1 className="text-2xl font-bold tracking-tight">Topology</h1>
+          <p className="text-muted-foreground text-sm mt-1">
+            {topics.length} topics · {handlers.length} handlers
+          </p>
+        </div>
+        <Button variant="outline" size="sm" onClick={handleRefresh} disabled={isLoading} className="gap-2 w-fit">
+          <RefreshCw className={`h-4 w-4 ${isLoading ? 'animate-spin' : ''}`} />
+          {isLoading ? 'Refreshing...' : 'Refresh'}
+        </Button>
+      </div>
+
+      {topology?.errors && topology.errors.length > 0 && (
+        <div className="rounded-lg border border-warning/50 bg-warning/10 p-4 text-sm space-y-1">
+          {topology.errors.map((err) => (
+            <p key={err} className="flex items-center gap-2 font-mono text-xs text-warning">
+              <AlertTriangle className="h-4 w-4 shrink-0" />
+              {err}
+            </p>
+          ))}
+        </div>
+      )}
+
+      {!topology || topics.length === 0 ? (
+        <EmptyState
+          icon={Network}
+          title="No topology yet"
+          description="Topics appear once they have consumer groups, poisoned messages or registered handlers."
+        />
+      ) : (
+        <div className="rounded-lg border overflow-hidden">
+          <Table>
+            <TableHeader>
+              <TableRow className="bg-muted/50">
+                <TableHead>Topic</TableHead>
+                <TableHead>Group</TableHead>
+                <TableHead className="hidden md:table-cell">Consumers</TableHead>
+                <TableHead className="pr-4">Handlers</TableHead>
+              </TableRow>
+            </TableHeader>
+            <TableBody>
+              {topics.flatMap((topic) => {
+                const consumed = outgoing(topology, topic.id, 'consumes')
+                const groups = consumed.filter((l) => l.node.kind === 'group')
+                const direct = consumed.filter((l) => l.node.kind === 'handler')
+                const publishers = handlers.filter((h) =>
+                  topology.edges.some((e) => e.from === h.id && e.to === topic.id),
+                )
+
+                const topicCell = (
+                  <TableCell
+                    className="font-mono cursor-pointer hover:underline"
+                    onClick={() => navigate({ to: '/streams/$name', params: { name: topic.name } })}
+                  >
+                    {topic.name}
+                    {publishers.length > 0 && (
+                      <p className="text-xs text-muted-foreground font-sans">
+                        from {publishers.map((p) => p.name).join(", ")}
+                      </p>
+                    )}
+                  </TableCell>
+                )
+
+                const rows = groups.map(({ node: group }, i) => {
+                  const consumers = outgoing(topology, group.id, 'consumes').filter((l) => l.node.kind === 'consumer')
+                  return (
+                    <TableRow key={group.id}>
+                      {i === 0 ? topicCell : <TableCell />}
+                      <TableCell className="font-mono">{group.name}</TableCell>
+                      <TableCell className="hidden md:table-cell font-mono text-xs">
+                        {consumers.length === 0 ? (
+                          <span className="text-muted-foreground">—</span>
+                        ) : (
+                          consumers.map(({ node }) => `${node.name} (${formatNumber(node.pending ?? 0)})`).join(", ")
+                        )}
+                      </TableCell>
+                      <TableCell className="pr-4">
+                        <HandlerBadges topology={topology} handlers={handlersOf(topology, group.id)} />
+                      </TableCell>
+                    </TableRow>
+                  )
+                })
+
+                if (direct.length > 0 || rows.length === 0) {
+                  rows.push(
+                    <TableRow key={`${topic.id}/direct`}>
+                      {rows.length === 0 ? topicCell : <TableCell />}
+                      <TableCell className="text-muted-foreground">—</TableCell>
+                      <TableCell className="hidden md:table-cell text-muted-foreground">—</TableCell>
+                      <TableCell className="pr-4">
+                        <HandlerBadges topology={topology} handlers={direct} />
+                      </TableCell>
+                    </TableRow>,
+                  )
+                }
+                return rows
+              })}
+            </TableBody>
+          </Table>
+        </div>
+      )}
+
+      {unlinked.length > 0 && (
+        <p className="text-sm text-muted-foreground">
+          Registered without a topic yet: {unlinked.map((h) => h.name).join(", ")}
+        </p>
+      )}
+    </div>
+  )
+}
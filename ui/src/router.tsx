@@ -3,7 +3,9 @@ import { Overview } from './pages/Overview'
 import { Streams } from './pages/Streams'
 import { StreamDetail } from './pages/StreamDetail'
 import { DLQ } from './pages/DLQ'
+import { Topology } from './pages/Topology'
 import { Layout } from './components/layout/Layout'
+import { basePath } from './lib/utils'
 
 const rootRoute = createRootRoute({
   component: () => (
@@ -37,9 +39,15 @@ const dlqRoute = createRoute({
   component: DLQ,
 })
 
-const routeTree = rootRoute.addChildren([indexRoute, streamsRoute, streamDetailRoute, dlqRoute])
+const topologyRoute = createRoute({
+  getParentRoute: () => rootRoute,
+  path: '/topology',
+  component: Topology,
+})
+
+const routeTree = rootRoute.addChildren([indexRoute, streamsRoute, streamDetailRoute, dlqRoute, topologyRoute])
 
-export const router = createRouter({ routeTree })
+export const router = createRouter({ routeTree, basepath: basePath || '/' })
 
 declare module '@tanstack/react-router' {
   interface Register {
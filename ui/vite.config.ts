@@ -5,6 +5,9 @@ import path from 'path'
 
 // https://vite.dev/config/
 export default defineConfig({
+  // Assets are resolved relative to the <base> element the Go server
+  // injects, so the dashboard can be mounted under any path prefix.
+  base: './',
   plugins: [react(), tailwindcss()],
   resolve: {
     alias: {
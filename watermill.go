@@ -0,0 +1,95 @@
+package windmill
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+
+	"github.com/scmofeoluwa/windmill/internal/monitor"
+)
+
+// WatermillHandler describes a Watermill handler for the topology view:
+// the topic it subscribes to, the topic it publishes to, if any, and the
+// consumer group its Redis Streams subscriber reads with.
+type WatermillHandler = monitor.HandlerRegistration
+
+// RouterOpts configures RegisterRouter.
+type RouterOpts struct {
+	// Instance names the instance whose streams the router consumes, the
+	// first one by default.
+	Instance string
+
+	// ConsumerGroup is the ConsumerGroup of the router's redisstream
+	// subscribers. When empty, handlers are linked to the group named
+	// after them, or to the only group of their topic.
+	ConsumerGroup string
+}
+
+// RegisterRouter makes router report its handlers to the topology view
+// (GET /api/topology). Their names are registered when the router starts
+// and their topics as soon as each handles a message. Call it before
+// router.Run.
+//
+// Registrations are kept in memory, so the dashboard serving them must
+// run in the same process as the router.
+func (w *Windmill) RegisterRouter(router *message.Router, opts RouterOpts) error {
+	topology, err := w.topology(opts.Instance)
+	if err != nil {
+		return err
+	}
+
+	router.AddPlugin(func(r *message.Router) error {
+		for name := range r.Handlers() {
+			topology.RegisterHandler(WatermillHandler{Name: name, ConsumerGroup: opts.ConsumerGroup})
+		}
+		return nil
+	})
+
+	router.AddMiddleware(func(h message.HandlerFunc) message.HandlerFunc {
+		return func(msg *message.Message) ([]*message.Message, error) {
+			ctx := msg.Context()
+			topology.RegisterHandler(WatermillHandler{
+				Name:           message.HandlerNameFromCtx(ctx),
+				SubscribeTopic: message.SubscribeTopicFromCtx(ctx),
+				PublishTopic:   message.PublishTopicFromCtx(ctx),
+				ConsumerGroup:  opts.ConsumerGroup,
+			})
+			return h(msg)
+		}
+	})
+
+	return nil
+}
+
+// RegisterHandler adds a handler to the topology view of the named
+// instance, or of the first one when instance is empty. Registering the
+// same handler again fills in the fields left empty before.
+func (w *Windmill) RegisterHandler(instance string, handler WatermillHandler) error {
+	if handler.Name == "" {
+		return errors.New("windmill: handler name is required")
+	}
+
+	topology, err := w.topology(instance)
+	if err != nil {
+		return err
+	}
+
+	topology.RegisterHandler(handler)
+	return nil
+}
+
+// topology returns the topology of the named instance, or of the first
+// one when name is empty.
+func (w *Windmill) topology(name string) (*monitor.TopologyService, error) {
+	if name == "" {
+		return w.instances[0].topology, nil
+	}
+
+	for _, inst := range w.instances {
+		if inst.name == name {
+			return inst.topology, nil
+		}
+	}
+	return nil, fmt.Errorf("windmill: unknown instance %q", name)
+}
@@ -1,9 +1,17 @@
 package windmill
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 
@@ -14,36 +22,366 @@ import (
 type Config struct {
 	RedisClient redis.UniversalClient
 	DLQName     string
+
+	// Auth authenticates requests (a WINDMILL_USERNAME/PASSWORD admin by default).
+	Auth Authenticator
+
+	// AllowedOrigins lists extra origins allowed to send mutating requests.
+	AllowedOrigins []string
+
+	// FrameAncestors lists the origins allowed to embed the dashboard (none by default).
+	FrameAncestors []string
+
+	// ReadOnly removes the mutating routes and the workers that write to Redis.
+	ReadOnly bool
+
+	// BasePath is the URL prefix the dashboard is served under, e.g. "/windmill".
+	BasePath string
+
+	// Streams and ExcludeStreams are path.Match patterns selecting the listed streams.
+	Streams        []string
+	ExcludeStreams []string
+
+	// StreamRefreshInterval is how often Run refreshes the stream list (10s by default).
+	StreamRefreshInterval time.Duration
+
+	// Retention trims matching streams every RetentionInterval (1m by default).
+	Retention         []RetentionPolicy
+	RetentionInterval time.Duration
+
+	// Retry requeues matching DLQ messages, checked every RetryInterval (30s by default).
+	Retry         []RetryPolicy
+	RetryInterval time.Duration
+
+	// ScheduleInterval is how often Run requeues due DLQ schedules (5s by default).
+	ScheduleInterval time.Duration
+
+	// InstanceID prefixes this replica's leader election ID (hostname and PID by default).
+	InstanceID string
+
+	// LeaderLease is how long a leader holds its lease without renewing it (15s by default).
+	LeaderLease time.Duration
+
+	// Instances lists several Redis deployments, served under /api/instances/{name}.
+	Instances []Instance
+
+	// Logger receives worker errors and failed exports (slog.Default() by default).
+	Logger *slog.Logger
+}
+
+// RetentionPolicy bounds the length and/or age of the streams matching
+// its Stream pattern.
+type RetentionPolicy = monitor.RetentionPolicy
+
+// RetryPolicy retries the DLQ messages of matching topics with exponential
+// backoff.
+type RetryPolicy = monitor.RetryPolicy
+
+// Instance is a Redis deployment monitored alongside others, with its own
+// DLQ, streams and background work.
+type Instance struct {
+	// Name identifies the instance in URLs and logs: letters, digits, '-', '_' and '.'.
+	Name        string
+	RedisClient redis.UniversalClient
+	DLQName     string
+
+	// Streams, ExcludeStreams, Retention and Retry override the Config fields.
+	Streams        []string
+	ExcludeStreams []string
+	Retention      []RetentionPolicy
+	Retry          []RetryPolicy
 }
 
 type Windmill struct {
-	handler http.Handler
+	handler   http.Handler
+	logger    *slog.Logger
+	instances []*instance
+}
+
+// instance is the background work of one Instance.
+type instance struct {
+	name     string
+	leader   *monitor.LeaderElection
+	topology *monitor.TopologyService
+
+	// workers run on the leader only, replicaWorkers on every replica.
+	workers        []monitor.Worker
+	replicaWorkers []monitor.Worker
 }
 
 func New(config Config) (*Windmill, error) {
-	if config.RedisClient == nil {
-		return nil, errors.New("windmill: redis client is required")
+	instances, err := resolveInstances(config)
+	if err != nil {
+		return nil, err
+	}
+
+	basePath, err := normalizeBasePath(config.BasePath)
+	if err != nil {
+		return nil, err
+	}
+
+	auth, err := resolveAuth(config.Auth)
+	if err != nil {
+		return nil, err
 	}
 
-	if config.DLQName == "" {
-		return nil, errors.New("windmill: dlq name is required")
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
 	}
 
-	username := os.Getenv("WINDMILL_USERNAME")
-	password := os.Getenv("WINDMILL_PASSWORD")
+	wm := &Windmill{logger: logger}
+	apiInstances := make([]api.Instance, 0, len(instances))
+	for _, inst := range instances {
+		mon, background, err := newInstance(config, inst, logger.With("instance", inst.Name))
+		if err != nil {
+			if len(config.Instances) > 0 {
+				err = fmt.Errorf("instance %q: %w", inst.Name, err)
+			}
+			return nil, fmt.Errorf("windmill: %w", err)
+		}
 
-	if username == "" || password == "" {
-		return nil, errors.New("windmill: WINDMILL_USERNAME and WINDMILL_PASSWORD environment variables are required")
+		wm.instances = append(wm.instances, background)
+		apiInstances = append(apiInstances, api.Instance{Name: inst.Name, Monitor: mon})
 	}
 
-	mon := monitor.New(config.RedisClient, config.DLQName)
-	apiHandler := api.New(mon)
+	apiHandler := api.NewWithInstances(apiInstances, api.Config{
+		Auth:           auth,
+		AllowedOrigins: config.AllowedOrigins,
+		FrameAncestors: config.FrameAncestors,
+		ReadOnly:       config.ReadOnly,
+		BasePath:       basePath,
+		Logger:         logger,
+	})
+	wm.handler = apiHandler.Handler()
 
-	return &Windmill{
-		handler: apiHandler.Handler(),
+	return wm, nil
+}
+
+// newInstance builds the monitor of inst and the workers running its
+// background work.
+func newInstance(config Config, inst Instance, logger *slog.Logger) (*monitor.Monitor, *instance, error) {
+	refreshInterval := config.StreamRefreshInterval
+	if refreshInterval <= 0 {
+		refreshInterval = 10 * time.Second
+	}
+
+	catalog := monitor.CatalogOpts{
+		Include: inst.Streams,
+		Exclude: inst.ExcludeStreams,
+		MaxAge:  3 * refreshInterval,
+	}
+	if err := catalog.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	mon := monitor.NewWithOptions(inst.RedisClient, inst.DLQName, monitor.Options{
+		Leader: monitor.LeaderOpts{
+			ID:    config.InstanceID,
+			Lease: config.LeaderLease,
+		},
+		Catalog: catalog,
+	})
+
+	var workers []monitor.Worker
+	if len(inst.Retention) > 0 {
+		retention := monitor.NewRetentionWorker(mon.Streams(), inst.Retention, logger)
+		if err := retention.Validate(); err != nil {
+			return nil, nil, err
+		}
+
+		interval := config.RetentionInterval
+		if interval <= 0 {
+			interval = time.Minute
+		}
+		if !config.ReadOnly {
+			workers = append(workers, monitor.Worker{Name: "retention", Interval: interval, Run: retention.Run})
+		}
+	}
+
+	if len(inst.Retry) > 0 {
+		retry := monitor.NewRetryWorker(mon.DLQ(), inst.Retry, logger)
+		if err := retry.Validate(); err != nil {
+			return nil, nil, err
+		}
+
+		interval := config.RetryInterval
+		if interval <= 0 {
+			interval = 30 * time.Second
+		}
+		if !config.ReadOnly {
+			workers = append(workers, monitor.Worker{Name: "retry", Interval: interval, Run: retry.Run})
+		}
+	}
+
+	if !config.ReadOnly {
+		scheduleInterval := config.ScheduleInterval
+		if scheduleInterval <= 0 {
+			scheduleInterval = 5 * time.Second
+		}
+		workers = append(workers, monitor.Worker{
+			Name:     "schedule",
+			Interval: scheduleInterval,
+			Run: func(ctx context.Context) error {
+				return mon.DLQ().RunScheduled(ctx, time.Now())
+			},
+		})
+	}
+
+	return mon, &instance{
+		name:     inst.Name,
+		leader:   mon.Leader(),
+		topology: mon.Topology(),
+		workers:  workers,
+		replicaWorkers: []monitor.Worker{
+			{Name: "streams", Interval: refreshInterval, Run: mon.Streams().RefreshCatalog},
+			mon.CapabilitiesWorker(refreshInterval, func(caps *monitor.RedisCapabilities) {
+				logCapabilities(caps, logger)
+			}),
+		},
 	}, nil
 }
 
 func (w *Windmill) Handler() http.Handler {
 	return w.handler
 }
+
+// Run runs the background workers, such as scheduled requeues and, when
+// configured, stream retention and DLQ retries, until ctx is done. Every
+// replica may call Run: they elect a leader through Redis and only the
+// leader runs the workers. When ctx is done the leader stops its workers
+// and releases leadership so another replica takes over right away. Every
+// replica also keeps its cached list of streams fresh and probes the Redis
+// commands it may use until a probe succeeds. With several instances, each
+// elects its own leader. With Config.ReadOnly, Run only does the latter.
+func (w *Windmill) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, inst := range w.instances {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			inst.run(ctx, w.logger.With("instance", inst.name))
+		}()
+	}
+	wg.Wait()
+}
+
+func (i *instance) run(ctx context.Context, logger *slog.Logger) {
+	workers := make([]monitor.Worker, len(i.workers))
+	for j, worker := range i.workers {
+		workers[j] = i.leader.Fence(worker)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		monitor.RunWorkers(ctx, logger, i.replicaWorkers...)
+	}()
+
+	// In read-only mode there is nothing to lead.
+	if len(workers) == 0 {
+		wg.Wait()
+		return
+	}
+
+	i.leader.Run(ctx, logger, func(ctx context.Context) {
+		monitor.RunWorkers(ctx, logger, workers...)
+	})
+	wg.Wait()
+}
+
+var instanceNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// resolveInstances returns config.Instances, or the single instance named
+// after api.DefaultInstance formed by the top-level fields when there are
+// none.
+func resolveInstances(config Config) ([]Instance, error) {
+	if len(config.Instances) == 0 {
+		if config.RedisClient == nil {
+			return nil, errors.New("windmill: redis client is required")
+		}
+
+		if config.DLQName == "" {
+			return nil, errors.New("windmill: dlq name is required")
+		}
+
+		return []Instance{{
+			Name:           api.DefaultInstance,
+			RedisClient:    config.RedisClient,
+			DLQName:        config.DLQName,
+			Streams:        config.Streams,
+			ExcludeStreams: config.ExcludeStreams,
+			Retention:      config.Retention,
+			Retry:          config.Retry,
+		}}, nil
+	}
+
+	if config.RedisClient != nil || config.DLQName != "" || len(config.Streams) > 0 ||
+		len(config.ExcludeStreams) > 0 || len(config.Retention) > 0 || len(config.Retry) > 0 {
+		return nil, errors.New("windmill: with Instances, set RedisClient, DLQName, Streams, ExcludeStreams, Retention and Retry on each instance")
+	}
+
+	seen := make(map[string]bool, len(config.Instances))
+	for _, inst := range config.Instances {
+		if !instanceNamePattern.MatchString(inst.Name) {
+			return nil, fmt.Errorf("windmill: invalid instance name %q", inst.Name)
+		}
+
+		if seen[inst.Name] {
+			return nil, fmt.Errorf("windmill: duplicate instance name %q", inst.Name)
+		}
+		seen[inst.Name] = true
+
+		if inst.RedisClient == nil {
+			return nil, fmt.Errorf("windmill: instance %q: redis client is required", inst.Name)
+		}
+
+		if inst.DLQName == "" {
+			return nil, fmt.Errorf("windmill: instance %q: dlq name is required", inst.Name)
+		}
+	}
+
+	return config.Instances, nil
+}
+
+// logCapabilities warns about each Redis command a probe found unavailable.
+func logCapabilities(caps *monitor.RedisCapabilities, logger *slog.Logger) {
+	for command, reason := range caps.Unavailable {
+		logger.Warn("redis command unavailable, falling back", "command", command, "error", reason)
+	}
+}
+
+func normalizeBasePath(basePath string) (string, error) {
+	basePath = strings.TrimSuffix(basePath, "/")
+	if basePath == "" {
+		return "", nil
+	}
+
+	if !strings.HasPrefix(basePath, "/") || strings.ContainsAny(basePath, "?#") {
+		return "", fmt.Errorf("windmill: invalid base path %q", basePath)
+	}
+
+	return path.Clean(basePath), nil
+}
+
+func resolveAuth(auth Authenticator) (Authenticator, error) {
+	if auth == nil {
+		username := os.Getenv("WINDMILL_USERNAME")
+		password := os.Getenv("WINDMILL_PASSWORD")
+
+		if username == "" || password == "" {
+			return nil, errors.New("windmill: Config.Auth or WINDMILL_USERNAME and WINDMILL_PASSWORD environment variables are required")
+		}
+
+		auth = BasicAuth(User{Username: username, Password: password, Role: RoleAdmin})
+	}
+
+	if v, ok := auth.(api.Validator); ok {
+		if err := v.Validate(); err != nil {
+			return nil, fmt.Errorf("windmill: invalid auth: %w", err)
+		}
+	}
+
+	return auth, nil
+}